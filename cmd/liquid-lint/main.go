@@ -0,0 +1,108 @@
+// Command liquid-lint checks Liquid templates with the rules of the lint
+// package.
+//
+// Usage:
+//
+//	liquid-lint [flags] template.liquid...
+//
+// Diagnostics are printed as file:line:column: severity: message (rule).
+// The exit status is 1 when an error diagnostic is reported, 2 on usage or
+// read errors.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Notifuse/liquidgo/liquid"
+	"github.com/Notifuse/liquidgo/liquid/lint"
+	"github.com/Notifuse/liquidgo/liquid/tags"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("liquid-lint", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	enable := flags.String("enable", "", "comma-separated rules to run instead of all rules")
+	disable := flags.String("disable", "", "comma-separated rules to skip")
+	partials := flags.String("partials", "", "directory partials are read from (enables undefined-partial)")
+	pattern := flags.String("pattern", "_%s.liquid", "file name pattern of partials in -partials")
+	errorMode := flags.String("error-mode", "lax", "parser error mode: lax, warn, strict, strict2 or rigid")
+	listRules := flags.Bool("rules", false, "list the rules and exit")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: liquid-lint [flags] template.liquid...")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	env := liquid.NewEnvironment()
+	tags.RegisterStandardTags(env)
+	tags.RegisterLayoutTags(env)
+	tags.RegisterMacroTags(env)
+	tags.RegisterComponentTags(env)
+	tags.RegisterCacheTag(env)
+	tags.RegisterTryTag(env)
+	env.SetErrorMode(*errorMode)
+
+	linter := lint.New(env)
+	if *listRules {
+		for _, rule := range linter.Rules() {
+			fmt.Fprintf(stdout, "%-20s %-8s %s\n", rule.Name, rule.Severity, rule.Doc)
+		}
+		return 0
+	}
+	if *partials != "" {
+		linter.SetFileSystem(liquid.NewLocalFileSystem(*partials, *pattern))
+	}
+	if *enable != "" {
+		if err := linter.EnableOnly(splitList(*enable)...); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+	}
+	if *disable != "" {
+		if err := linter.Disable(splitList(*disable)...); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	status := 0
+	for _, path := range flags.Args() {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			status = 2
+			continue
+		}
+		for _, diagnostic := range linter.Lint(path, string(source)) {
+			fmt.Fprintln(stdout, diagnostic)
+			if diagnostic.Severity == lint.SeverityError && status == 0 {
+				status = 1
+			}
+		}
+	}
+	return status
+}
+
+func splitList(list string) []string {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	page := filepath.Join(dir, "page.liquid")
+	source := "{% assign unused = 1 %}\n{{ title | shout }}\n{% render 'card' %}{% render 'missing' %}"
+	if err := os.WriteFile(page, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "_card.liquid"), []byte("card"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	status := run([]string{"-partials", dir, "-disable", "unbounded-for", page}, &stdout, &stderr)
+	if status != 1 {
+		t.Errorf("run() = %d, want 1 (stderr: %s)", status, stderr.String())
+	}
+	want := page + ":1:1: warning: assign variable \"unused\" is never read (unused-variable)\n" +
+		page + ":2:12: error: unknown filter \"shout\" (unknown-filter)\n" +
+		page + ":3:20: error: render of undefined partial \"missing\" (undefined-partial)\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("output =\n%s\nwant\n%s", got, want)
+	}
+
+	stdout.Reset()
+	if status := run([]string{"-enable", "deprecated-include", page}, &stdout, &stderr); status != 0 || stdout.Len() != 0 {
+		t.Errorf("run(-enable) = %d with output %q, want 0 and no output", status, stdout.String())
+	}
+	if status := run([]string{"-disable", "nope", page}, &stdout, &stderr); status != 2 {
+		t.Errorf("run(-disable nope) = %d, want 2", status)
+	}
+
+	stdout.Reset()
+	if status := run([]string{"-rules"}, &stdout, &stderr); status != 0 || !strings.Contains(stdout.String(), "nil-comparison") {
+		t.Errorf("run(-rules) = %d with output %q", status, stdout.String())
+	}
+}
@@ -0,0 +1,54 @@
+// Command liquid-lsp is a Language Server Protocol server for Liquid
+// templates, speaking JSON-RPC over stdin and stdout.
+//
+// Usage:
+//
+//	liquid-lsp [-partials dir] [-pattern _%s.liquid] [-error-mode lax]
+//
+// Open documents are parsed in the chosen error mode and checked with the
+// rules of the lint package. The server completes tag and filter names, shows
+// the {% doc %} block of partials named by render and include on hover, and
+// jumps to their files. Partials are read from -partials, or from the
+// workspace root. The error mode can also be set by the client with the
+// errorMode initialization option.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Notifuse/liquidgo/liquid"
+	"github.com/Notifuse/liquidgo/liquid/tags"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("liquid-lsp", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	partials := flags.String("partials", "", "directory partials are read from (default: the workspace root)")
+	pattern := flags.String("pattern", "_%s.liquid", "file name pattern of partials")
+	errorMode := flags.String("error-mode", "lax", "parser error mode: lax, warn, strict, strict2 or rigid")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	env := liquid.NewEnvironment()
+	tags.RegisterStandardTags(env)
+	tags.RegisterLayoutTags(env)
+	tags.RegisterMacroTags(env)
+	tags.RegisterComponentTags(env)
+	tags.RegisterCacheTag(env)
+	tags.RegisterTryTag(env)
+	env.SetErrorMode(*errorMode)
+
+	if err := newServer(env, *partials, *pattern, stdout).serve(stdin); err != nil {
+		fmt.Fprintln(stderr, "liquid-lsp:", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// JSON-RPC error codes used by the server.
+const (
+	codeParseError     = -32700
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Diagnostic severities and completion item kinds of the protocol.
+const (
+	severityError       = 1
+	severityWarning     = 2
+	severityInformation = 3
+
+	kindFunction = 3
+	kindKeyword  = 14
+)
+
+// message is a JSON-RPC request, response or notification.
+type message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *responseError   `json:"error,omitempty"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type textRange struct {
+	Start position `json:"start"`
+	End   position `json:"end"`
+}
+
+type location struct {
+	URI   string    `json:"uri"`
+	Range textRange `json:"range"`
+}
+
+type diagnostic struct {
+	Range    textRange `json:"range"`
+	Severity int       `json:"severity"`
+	Code     string    `json:"code,omitempty"`
+	Source   string    `json:"source"`
+	Message  string    `json:"message"`
+}
+
+type completionItem struct {
+	Label  string `json:"label"`
+	Kind   int    `json:"kind"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type markupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type hover struct {
+	Contents markupContent `json:"contents"`
+	Range    textRange     `json:"range"`
+}
+
+type initializeParams struct {
+	RootURI               string `json:"rootUri"`
+	InitializationOptions struct {
+		ErrorMode string `json:"errorMode"`
+	} `json:"initializationOptions"`
+}
+
+type textDocumentItem struct {
+	URI  string `json:"uri"`
+	Text string `json:"text"`
+}
+
+type didOpenParams struct {
+	TextDocument textDocumentItem `json:"textDocument"`
+}
+
+type didChangeParams struct {
+	TextDocument   textDocumentItem `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+type textDocumentPositionParams struct {
+	TextDocument textDocumentItem `json:"textDocument"`
+	Position     position         `json:"position"`
+}
+
+type publishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Diagnostics []diagnostic `json:"diagnostics"`
+}
+
+// readMessage reads a message framed by a Content-Length header.
+func readMessage(r *bufio.Reader) (*message, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length %q", header.Get("Content-Length"))
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	msg := &message{}
+	if err := json.Unmarshal(body, msg); err != nil {
+		return nil, &responseError{Code: codeParseError, Message: err.Error()}
+	}
+	return msg, nil
+}
+
+func (e *responseError) Error() string {
+	return e.Message
+}
+
+// writeMessage writes msg framed by a Content-Length header.
+func writeMessage(w io.Writer, msg *message) error {
+	msg.JSONRPC = "2.0"
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+// lineOffset returns the byte offset in line of a character offset counted in
+// UTF-16 code units, as positions are in the protocol.
+func lineOffset(line string, character int) int {
+	units := 0
+	for i, r := range line {
+		if units >= character {
+			return i
+		}
+		units += utf16.RuneLen(r)
+	}
+	return len(line)
+}
+
+// lineCharacter is the inverse of lineOffset.
+func lineCharacter(line string, offset int) int {
+	if offset > len(line) {
+		offset = len(line)
+	}
+	units := 0
+	for _, r := range line[:offset] {
+		units += utf16.RuneLen(r)
+	}
+	return units
+}
+
+// lineRange returns the range of line (0-based) from byte offset start to
+// the end of the line, without trailing whitespace.
+func lineRange(lines []string, line, start int) textRange {
+	if line < 0 || line >= len(lines) {
+		return textRange{Start: position{Line: line}, End: position{Line: line}}
+	}
+	text := strings.TrimRightFunc(lines[line], func(r rune) bool { return r == ' ' || r == '\t' || r == '\r' })
+	if start <= 0 {
+		start = len(text) - len(strings.TrimLeft(text, " \t"))
+	}
+	if start > len(text) || !utf8.ValidString(text[:start]) {
+		start = len(text)
+	}
+	return textRange{
+		Start: position{Line: line, Character: lineCharacter(text, start)},
+		End:   position{Line: line, Character: lineCharacter(text, len(text))},
+	}
+}
+
+// sourcePosition converts a 1-based line and byte column to a position.
+func sourcePosition(lines []string, line, column int) position {
+	if line < 1 || line > len(lines) {
+		return position{Line: line - 1}
+	}
+	text := lines[line-1]
+	offset := column - 1
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(text) || !utf8.ValidString(text[:offset]) {
+		offset = len(text)
+	}
+	return position{Line: line - 1, Character: lineCharacter(text, offset)}
+}
@@ -0,0 +1,417 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Notifuse/liquidgo/liquid"
+	"github.com/Notifuse/liquidgo/liquid/lint"
+)
+
+var (
+	// partialReference matches a partial named by render or include.
+	partialReference = regexp.MustCompile(`\b(?:render|include)\s+(['"])([^'"]*)['"]`)
+	tagPrefix        = regexp.MustCompile(`^-?\s*(\w*)$`)
+	filterPrefix     = regexp.MustCompile(`\|\s*(\w*)$`)
+)
+
+// server answers the requests of one client.
+type server struct {
+	env        *liquid.Environment
+	linter     *lint.Linter
+	fileSystem liquid.FileSystem
+	partials   string
+	pattern    string
+	documents  map[string]string
+	out        io.Writer
+	shutdown   bool
+}
+
+func newServer(env *liquid.Environment, partials, pattern string, out io.Writer) *server {
+	return &server{
+		env:       env,
+		linter:    lint.New(env),
+		partials:  partials,
+		pattern:   pattern,
+		documents: make(map[string]string),
+		out:       out,
+	}
+}
+
+// serve handles messages from in until the client sends exit. It returns an
+// error when the input ends or exit comes before shutdown.
+func (s *server) serve(in io.Reader) error {
+	r := bufio.NewReader(in)
+	for {
+		msg, err := readMessage(r)
+		var parseErr *responseError
+		if errors.As(err, &parseErr) {
+			if err := writeMessage(s.out, &message{Error: parseErr, ID: nullID()}); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("exit before shutdown")
+			}
+			return nil
+		}
+		result, rpcErr := s.handle(msg.Method, msg.Params)
+		if msg.ID == nil {
+			continue
+		}
+		response := &message{ID: msg.ID, Error: rpcErr}
+		if rpcErr == nil {
+			if response.Result, err = json.Marshal(result); err != nil {
+				return err
+			}
+		}
+		if err := writeMessage(s.out, response); err != nil {
+			return err
+		}
+	}
+}
+
+func nullID() *json.RawMessage {
+	id := json.RawMessage("null")
+	return &id
+}
+
+func (s *server) handle(method string, params json.RawMessage) (interface{}, *responseError) {
+	switch method {
+	case "initialize":
+		var p initializeParams
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+		s.initialize(p)
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":   1,
+				"completionProvider": map[string]interface{}{"triggerCharacters": []string{"%", "|", " "}},
+				"hoverProvider":      true,
+				"definitionProvider": true,
+			},
+			"serverInfo": map[string]string{"name": "liquid-lsp", "version": liquid.Version},
+		}, nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var p didOpenParams
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+		s.update(p.TextDocument.URI, p.TextDocument.Text)
+		return nil, nil
+	case "textDocument/didChange":
+		var p didChangeParams
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+		if n := len(p.ContentChanges); n > 0 {
+			s.update(p.TextDocument.URI, p.ContentChanges[n-1].Text)
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var p didOpenParams
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+		delete(s.documents, p.TextDocument.URI)
+		s.publish(p.TextDocument.URI, []diagnostic{})
+		return nil, nil
+	case "textDocument/completion", "textDocument/hover", "textDocument/definition":
+		var p textDocumentPositionParams
+		if err := unmarshalParams(params, &p); err != nil {
+			return nil, err
+		}
+		text, ok := s.documents[p.TextDocument.URI]
+		if !ok {
+			return nil, nil
+		}
+		switch method {
+		case "textDocument/completion":
+			return s.complete(text, p.Position), nil
+		case "textDocument/hover":
+			return s.hover(text, p.Position), nil
+		default:
+			return s.definition(text, p.Position), nil
+		}
+	}
+	if strings.HasPrefix(method, "$/") {
+		return nil, nil
+	}
+	return nil, &responseError{Code: codeMethodNotFound, Message: "method not found: " + method}
+}
+
+func unmarshalParams(params json.RawMessage, v interface{}) *responseError {
+	if len(params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// initialize applies the client settings. Partials are read from the
+// workspace root unless a directory was given on the command line.
+func (s *server) initialize(p initializeParams) {
+	if p.InitializationOptions.ErrorMode != "" {
+		s.env.SetErrorMode(p.InitializationOptions.ErrorMode)
+	}
+	if s.partials == "" {
+		s.partials = uriPath(p.RootURI)
+	}
+	if s.partials != "" {
+		s.fileSystem = liquid.NewLocalFileSystem(s.partials, s.pattern)
+		s.linter.SetFileSystem(s.fileSystem)
+	}
+}
+
+// update stores the text of a document and publishes its diagnostics.
+func (s *server) update(uri, text string) {
+	s.documents[uri] = text
+	s.publish(uri, s.diagnose(uri, text))
+}
+
+func (s *server) publish(uri string, diagnostics []diagnostic) {
+	params, _ := json.Marshal(publishDiagnosticsParams{URI: uri, Diagnostics: diagnostics})
+	_ = writeMessage(s.out, &message{Method: "textDocument/publishDiagnostics", Params: params})
+}
+
+// diagnose parses text in the environment's error mode and runs the lint
+// rules over it. Parse errors and warnings (in warn mode) cover their line.
+func (s *server) diagnose(uri, text string) []diagnostic {
+	lines := strings.Split(text, "\n")
+	diagnostics := []diagnostic{}
+	name := uriPath(uri)
+	if name == "" {
+		name = uri
+	}
+	tmpl, parsed := liquid.ParseAll(name, text, &liquid.TemplateOptions{Environment: s.env, LineNumbers: true})
+	valid := true
+	for _, d := range parsed {
+		severity := severityWarning
+		if d.Severity == liquid.DiagnosticError {
+			severity, valid = severityError, false
+		}
+		diagnostics = append(diagnostics, diagnostic{
+			Range: textRange{
+				Start: sourcePosition(lines, d.Line, d.Column),
+				End:   sourcePosition(lines, d.EndLine, d.EndColumn),
+			},
+			Severity: severity,
+			Code:     d.Code,
+			Source:   "liquid",
+			Message:  d.Message,
+		})
+	}
+	// Lint rules would report the parts of the template that failed to parse
+	if !valid {
+		return diagnostics
+	}
+
+	for _, d := range s.linter.LintTemplate(tmpl, text) {
+		severity := severityError
+		switch d.Severity {
+		case lint.SeverityWarning:
+			severity = severityWarning
+		case lint.SeverityInfo:
+			severity = severityInformation
+		}
+		diagnostics = append(diagnostics, diagnostic{
+			Range:    lineRange(lines, d.Line-1, d.Column-1),
+			Severity: severity,
+			Code:     d.Rule,
+			Source:   "liquid",
+			Message:  d.Message,
+		})
+	}
+	return diagnostics
+}
+
+// complete offers tag names after {% and filter names after a pipe.
+func (s *server) complete(text string, pos position) []completionItem {
+	before := textBefore(text, pos)
+	open := strings.LastIndex(before, "{%")
+	if output := strings.LastIndex(before, "{{"); output > open {
+		open = output
+	}
+	if open < 0 || strings.Contains(before[open:], "%}") || strings.Contains(before[open:], "}}") {
+		return []completionItem{}
+	}
+
+	markup := before[open+2:]
+	items := []completionItem{}
+	if m := tagPrefix.FindStringSubmatch(markup); m != nil && strings.HasPrefix(before[open:], "{%") {
+		for name := range s.env.Tags() {
+			if strings.HasPrefix(name, m[1]) {
+				items = append(items, completionItem{Label: name, Kind: kindKeyword, Detail: "tag"})
+			}
+		}
+	} else if m := filterPrefix.FindStringSubmatch(markup); m != nil {
+		for _, method := range s.env.FilterMethodNames() {
+			if name := filterName(method); strings.HasPrefix(name, m[1]) {
+				items = append(items, completionItem{Label: name, Kind: kindFunction, Detail: "filter"})
+			}
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Label < items[j].Label })
+	return items
+}
+
+// filterName converts a filter method name to the name used in templates:
+// StripHTML becomes strip_html, Base64URLSafeEncode base64_url_safe_encode.
+func filterName(method string) string {
+	runes := []rune(method)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !unicode.IsUpper(prev) || nextLower {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// hover shows the {% doc %} block of the partial under the cursor.
+func (s *server) hover(text string, pos position) interface{} {
+	name, rng, ok := partialAt(text, pos)
+	if !ok || s.fileSystem == nil {
+		return nil
+	}
+	source, err := s.fileSystem.ReadTemplateFile(name)
+	if err != nil {
+		return nil
+	}
+	doc := partialDoc(s.env, source)
+	if doc == "" {
+		return nil
+	}
+	return hover{Contents: markupContent{Kind: "plaintext", Value: doc}, Range: rng}
+}
+
+// partialDoc returns the body of the first doc tag of source with each line
+// trimmed, or "".
+func partialDoc(env *liquid.Environment, source string) string {
+	tmpl, err := liquid.ParseTemplate(source, &liquid.TemplateOptions{Environment: env})
+	if err != nil {
+		return ""
+	}
+	var find func(node interface{}) string
+	find = func(node interface{}) string {
+		if tag, ok := node.(interface {
+			TagName() string
+			Nodelist() []interface{}
+		}); ok && tag.TagName() == "doc" && len(tag.Nodelist()) > 0 {
+			body, _ := tag.Nodelist()[0].(string)
+			lines := strings.Split(strings.TrimSpace(body), "\n")
+			for i, line := range lines {
+				lines[i] = strings.TrimSpace(line)
+			}
+			return strings.Join(lines, "\n")
+		}
+		for _, child := range liquid.ForParseTreeVisitor(node, nil).Children() {
+			if doc := find(child); doc != "" {
+				return doc
+			}
+		}
+		return ""
+	}
+	return find(tmpl.Root())
+}
+
+// definition returns the file of the partial under the cursor.
+func (s *server) definition(text string, pos position) interface{} {
+	name, _, ok := partialAt(text, pos)
+	if !ok || s.fileSystem == nil {
+		return nil
+	}
+	fs, ok := s.fileSystem.(interface {
+		FullPath(templatePath string) (string, error)
+	})
+	if !ok {
+		return nil
+	}
+	path, err := fs.FullPath(name)
+	if err != nil {
+		return nil
+	}
+	if _, err := s.fileSystem.ReadTemplateFile(name); err != nil {
+		return nil
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return location{URI: pathURI(path)}
+}
+
+// partialAt returns the partial named by the render or include tag under the
+// cursor, with the range of the name.
+func partialAt(text string, pos position) (string, textRange, bool) {
+	lines := strings.Split(text, "\n")
+	if pos.Line < 0 || pos.Line >= len(lines) {
+		return "", textRange{}, false
+	}
+	line := lines[pos.Line]
+	offset := lineOffset(line, pos.Character)
+	for _, m := range partialReference.FindAllStringSubmatchIndex(line, -1) {
+		if offset >= m[0] && offset <= m[1] && m[5] > m[4] {
+			return line[m[4]:m[5]], textRange{
+				Start: position{Line: pos.Line, Character: lineCharacter(line, m[4])},
+				End:   position{Line: pos.Line, Character: lineCharacter(line, m[5])},
+			}, true
+		}
+	}
+	return "", textRange{}, false
+}
+
+// textBefore returns the text of the document before pos.
+func textBefore(text string, pos position) string {
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		i := strings.IndexByte(text[offset:], '\n')
+		if i < 0 {
+			return text
+		}
+		offset += i + 1
+	}
+	end := strings.IndexByte(text[offset:], '\n')
+	if end < 0 {
+		end = len(text) - offset
+	}
+	return text[:offset+lineOffset(text[offset:offset+end], pos.Character)]
+}
+
+// uriPath returns the file path of a file URI, or "".
+func uriPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+func pathURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
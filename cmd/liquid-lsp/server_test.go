@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// session sends requests to a new server and returns its messages, keyed by
+// request id, with notifications under their method.
+func session(t *testing.T, args []string, requests ...string) map[string]json.RawMessage {
+	t.Helper()
+	var in, out, stderr bytes.Buffer
+	for _, request := range requests {
+		in.WriteString("Content-Length: ")
+		in.WriteString(jsonInt(len(request)))
+		in.WriteString("\r\n\r\n")
+		in.WriteString(request)
+	}
+	if status := run(args, &in, &out, &stderr); status != 0 {
+		t.Fatalf("run() = %d, stderr: %s", status, stderr.String())
+	}
+
+	messages := make(map[string]json.RawMessage)
+	r := bufio.NewReader(&out)
+	for {
+		msg, err := readMessage(r)
+		if err != nil {
+			break
+		}
+		if msg.Error != nil {
+			t.Errorf("response %s: error %v", *msg.ID, msg.Error.Message)
+		}
+		if msg.Method != "" {
+			messages[msg.Method] = msg.Params
+		} else {
+			messages[string(*msg.ID)] = msg.Result
+		}
+	}
+	return messages
+}
+
+func jsonInt(n int) string {
+	b, _ := json.Marshal(n)
+	return string(b)
+}
+
+func TestServer(t *testing.T) {
+	dir := t.TempDir()
+	card := "{% doc %}\n  Renders a product card.\n  @param product\n{% enddoc %}{{ product.title }}"
+	if err := os.WriteFile(filepath.Join(dir, "_card.liquid"), []byte(card), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	uri := pathURI(filepath.Join(dir, "page.liquid"))
+	text := "{{ title | up }}\n{% render 'card' %}\n{% ass"
+	open, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0", "method": "textDocument/didOpen",
+		"params": map[string]interface{}{"textDocument": map[string]string{"uri": uri, "languageId": "liquid", "text": text}},
+	})
+	at := func(id, method string, line, character int) string {
+		b, _ := json.Marshal(map[string]interface{}{
+			"jsonrpc": "2.0", "id": json.RawMessage(id), "method": method,
+			"params": map[string]interface{}{
+				"textDocument": map[string]string{"uri": uri},
+				"position":     map[string]int{"line": line, "character": character},
+			},
+		})
+		return string(b)
+	}
+
+	messages := session(t, nil,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"rootUri":"`+pathURI(dir)+`"}}`,
+		`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+		string(open),
+		at("2", "textDocument/completion", 0, 13),
+		at("3", "textDocument/completion", 2, 6),
+		at("4", "textDocument/hover", 1, 13),
+		at("5", "textDocument/definition", 1, 13),
+		at("6", "textDocument/hover", 0, 4),
+		`{"jsonrpc":"2.0","id":7,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	)
+
+	if !strings.Contains(string(messages["1"]), `"hoverProvider":true`) {
+		t.Errorf("initialize result = %s", messages["1"])
+	}
+
+	var published publishDiagnosticsParams
+	if err := json.Unmarshal(messages["textDocument/publishDiagnostics"], &published); err != nil {
+		t.Fatal(err)
+	}
+	if published.URI != uri || len(published.Diagnostics) != 1 || published.Diagnostics[0].Severity != severityError ||
+		published.Diagnostics[0].Range.Start.Line != 2 || published.Diagnostics[0].Code != "unterminated-tag" {
+		t.Errorf("diagnostics = %+v, want an unterminated tag on line 2", published)
+	}
+
+	labels := func(id string) []string {
+		var items []completionItem
+		if err := json.Unmarshal(messages[id], &items); err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, item := range items {
+			names = append(names, item.Label)
+		}
+		return names
+	}
+	if got, want := labels("2"), []string{"upcase"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("filter completion = %v, want %v", got, want)
+	}
+	if got, want := labels("3"), []string{"assign"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("tag completion = %v, want %v", got, want)
+	}
+
+	var h hover
+	if err := json.Unmarshal(messages["4"], &h); err != nil {
+		t.Fatal(err)
+	}
+	if h.Contents.Value != "Renders a product card.\n@param product" || h.Range.Start.Character != 11 || h.Range.End.Character != 15 {
+		t.Errorf("hover = %+v", h)
+	}
+	var loc location
+	if err := json.Unmarshal(messages["5"], &loc); err != nil {
+		t.Fatal(err)
+	}
+	if want := pathURI(filepath.Join(dir, "_card.liquid")); loc.URI != want {
+		t.Errorf("definition = %s, want %s", loc.URI, want)
+	}
+	if string(messages["6"]) != "null" {
+		t.Errorf("hover outside a partial name = %s, want null", messages["6"])
+	}
+}
+
+func TestServerErrorModes(t *testing.T) {
+	text := "ok\n{{ hello. }}"
+	open, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0", "method": "textDocument/didOpen",
+		"params": map[string]interface{}{"textDocument": map[string]string{"uri": "untitled:1", "text": text}},
+	})
+	for mode, want := range map[string]int{"lax": 0, "warn": severityWarning, "strict": severityError} {
+		messages := session(t, []string{"-error-mode", mode},
+			`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+			string(open),
+			`{"jsonrpc":"2.0","id":2,"method":"shutdown"}`,
+			`{"jsonrpc":"2.0","method":"exit"}`,
+		)
+		var published publishDiagnosticsParams
+		if err := json.Unmarshal(messages["textDocument/publishDiagnostics"], &published); err != nil {
+			t.Fatal(err)
+		}
+		got := 0
+		if len(published.Diagnostics) > 0 && published.Diagnostics[0].Range.Start.Line == 1 {
+			got = published.Diagnostics[0].Severity
+		}
+		if got != want {
+			t.Errorf("%s mode: diagnostics = %+v, want severity %d on line 1", mode, published.Diagnostics, want)
+		}
+	}
+}
+
+func TestFilterName(t *testing.T) {
+	for method, want := range map[string]string{
+		"Upcase":              "upcase",
+		"StripHTML":           "strip_html",
+		"URLEncode":           "url_encode",
+		"Base64URLSafeEncode": "base64_url_safe_encode",
+		"NewlineToBr":         "newline_to_br",
+	} {
+		if got := filterName(method); got != want {
+			t.Errorf("filterName(%q) = %q, want %q", method, got, want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package integration
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/Notifuse/liquidgo/liquid"
+	"github.com/Notifuse/liquidgo/liquid/tags"
+)
+
+func TestCacheTagSharedAcrossRecipients(t *testing.T) {
+	env := liquid.NewEnvironment()
+	tags.RegisterStandardTags(env)
+	tags.RegisterCacheTag(env)
+	store := liquid.NewMemoryFragmentStore(liquid.MemoryFragmentStoreOptions{MaxEntries: 10})
+	env.SetFragmentStore(store)
+
+	source := `Hi {{ name }}: {% cache 'grid', collection.id, expires_in: 300 %}{% for product in collection.products %}[{{ product.title }}]{% endfor %}{% endcache %}`
+	tmpl, err := liquid.ParseTemplate(source, &liquid.TemplateOptions{Environment: env})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	collection := map[string]interface{}{
+		"id":       42,
+		"products": []interface{}{map[string]interface{}{"title": "Tea"}, map[string]interface{}{"title": "Cake"}},
+	}
+
+	var wg sync.WaitGroup
+	for _, name := range []string{"Ann", "Bob", "Cid", "Dee"} {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			got := tmpl.Render(map[string]interface{}{"name": name, "collection": collection}, nil)
+			if want := "Hi " + name + ": [Tea][Cake]"; got != want {
+				t.Errorf("Render() = %q, want %q", got, want)
+			}
+		}(name)
+	}
+	wg.Wait()
+
+	if stats := store.Stats(); stats.Entries != 1 || stats.Hits+stats.Misses != 4 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+}
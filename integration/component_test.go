@@ -0,0 +1,43 @@
+package integration
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Notifuse/liquidgo/liquid"
+	"github.com/Notifuse/liquidgo/liquid/tags"
+)
+
+var cardComponents = map[string]string{
+	"card":  `<div>{% render 'title', text: title %}{{ slot }}<footer>{{ slots.footer | default: "-" }}</footer></div>`,
+	"title": `<h2>{{ text }}</h2>`,
+}
+
+func TestComponentInLoop(t *testing.T) {
+	env := newTagEnvironment(NewStubFileSystem(cardComponents), tags.RegisterComponentTags)
+	source := `{% for product in products %}{% component 'card', title: product.title %}{{ product.price }}{% if product.sale %}{% slot 'footer' %}Sale{% endslot %}{% endif %}{% endcomponent %}{% endfor %}`
+	tmpl := parseTemplate(t, env, source)
+
+	assigns := map[string]interface{}{
+		"products": []interface{}{
+			map[string]interface{}{"title": "Tea", "price": 5, "sale": true},
+			map[string]interface{}{"title": "Cake", "price": 7},
+		},
+	}
+	want := `<div><h2>Tea</h2>5<footer>Sale</footer></div><div><h2>Cake</h2>7<footer>-</footer></div>`
+	if got := tmpl.Render(assigns, nil); got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestComponentStaticAnalysis(t *testing.T) {
+	env := newTagEnvironment(NewStubFileSystem(cardComponents), tags.RegisterComponentTags)
+	tmpl := parseTemplate(t, env, `{% component 'card', title: page.title %}{{ page.body }}{% endcomponent %}`)
+
+	if got, want := liquid.AnalyzeVariables(tmpl).Globals(), []string{"page.body", "page.title"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Globals() = %v, want %v", got, want)
+	}
+	if got, want := liquid.Dependencies(tmpl, nil).Partials(), []string{"card", "title"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Partials() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,75 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/Notifuse/liquidgo/liquid"
+	"github.com/Notifuse/liquidgo/liquid/tags"
+)
+
+func TestConditionGroupingInTemplates(t *testing.T) {
+	sources := map[string]string{
+		`{% if (user.admin or user.editor) and post.published %}edit{% else %}view{% endif %}`:       "view",
+		`{% if user.editor or (user.admin and post.published) %}edit{% else %}view{% endif %}`:       "edit",
+		`{% if not post.published %}draft{% endif %}`:                                                "draft",
+		`{% unless not (user.admin and user.editor) %}both{% else %}one{% endunless %}`:              "one",
+		`{% if post.published %}a{% elsif not (user.admin or user.editor) %}b{% else %}c{% endif %}`: "c",
+		`{% if (post.views + 10) * 2 > 100 and not post.published %}hot{% endif %}`:                  "hot",
+	}
+	assigns := map[string]interface{}{
+		"user": map[string]interface{}{"admin": false, "editor": true},
+		"post": map[string]interface{}{"published": false, "views": 45},
+	}
+	for _, mode := range []string{"strict", "rigid", "warn"} {
+		env := liquid.NewEnvironment()
+		tags.RegisterStandardTags(env)
+		env.SetErrorMode(mode)
+		for source, want := range sources {
+			tmpl, err := liquid.ParseTemplate(source, &liquid.TemplateOptions{Environment: env})
+			if err != nil {
+				t.Errorf("%s: ParseTemplate(%q) error = %v", mode, source, err)
+				continue
+			}
+			if got := tmpl.Render(assigns, nil); got != want {
+				t.Errorf("%s: Render(%q) = %q, want %q", mode, source, got, want)
+			}
+		}
+	}
+}
+
+func TestConditionGroupingLaxKeepsLegacyParsing(t *testing.T) {
+	env := liquid.NewEnvironment()
+	tags.RegisterStandardTags(env)
+	env.SetErrorMode("lax")
+	tmpl, err := liquid.ParseTemplate(`{% if (a == 1 or b == 3) and c == 4 %}Y{% else %}N{% endif %}`, &liquid.TemplateOptions{Environment: env})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	if got := tmpl.Render(map[string]interface{}{"a": 1, "b": 2, "c": 3}, nil); got != "Y" {
+		t.Errorf("Render() = %q, want %q", got, "Y")
+	}
+}
+
+func TestConditionGroupingCompiled(t *testing.T) {
+	env := liquid.NewEnvironment()
+	tags.RegisterStandardTags(env)
+	env.SetErrorMode("strict")
+	tmpl, err := liquid.ParseTemplate(`{% if not (a or b) and c %}yes{% else %}no{% endif %}`, &liquid.TemplateOptions{Environment: env})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	data, err := tmpl.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+	loaded, err := liquid.LoadCompiled(data, &liquid.TemplateOptions{Environment: env})
+	if err != nil {
+		t.Fatalf("LoadCompiled() error = %v", err)
+	}
+	if got := loaded.Render(map[string]interface{}{"a": false, "b": false, "c": true}, nil); got != "yes" {
+		t.Errorf("Render() = %q, want %q", got, "yes")
+	}
+	if got := loaded.Render(map[string]interface{}{"a": false, "b": true, "c": true}, nil); got != "no" {
+		t.Errorf("Render() = %q, want %q", got, "no")
+	}
+}
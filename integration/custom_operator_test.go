@@ -0,0 +1,102 @@
+package integration
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Notifuse/liquidgo/liquid"
+)
+
+var testOperators = map[string]liquid.ConditionOperator{
+	"startswith": func(_ *liquid.Condition, left, right interface{}) (bool, error) {
+		return strings.HasPrefix(liquid.ToS(left, nil), liquid.ToS(right, nil)), nil
+	},
+	"in": func(_ *liquid.Condition, left, right interface{}) (bool, error) {
+		list, _ := right.([]interface{})
+		for _, item := range list {
+			if item == left {
+				return true, nil
+			}
+		}
+		return false, nil
+	},
+}
+
+// registerTestOperators returns a function registering testOperators, for newTagEnvironment.
+func registerTestOperators(t *testing.T) func(*liquid.Environment) {
+	return func(env *liquid.Environment) {
+		for name, op := range testOperators {
+			if err := env.RegisterOperator(name, op); err != nil {
+				t.Fatalf("RegisterOperator(%q) error = %v", name, err)
+			}
+		}
+	}
+}
+
+func TestRegisteredOperatorsInTemplates(t *testing.T) {
+	sources := map[string]string{
+		`{% if subject startswith 'Re:' %}reply{% endif %}`:                           "reply",
+		`{% if status in allowed and not (subject startswith 'Fwd:') %}ok{% endif %}`: "ok",
+		`{% unless status in allowed %}blocked{% else %}allowed{% endunless %}`:       "allowed",
+		`{{ subject startswith 'Re:' }}`:                                              "true",
+		`{% assign reply = subject startswith 'Re:' %}{{ reply }}`:                    "true",
+		`{{ status in ['draft', 'sent'] }}`:                                           "true",
+		`{{ message.in }}`:                                                            "inbox",
+	}
+	assigns := map[string]interface{}{
+		"subject": "Re: hello",
+		"status":  "sent",
+		"allowed": []interface{}{"sent", "queued"},
+		"message": map[string]interface{}{"in": "inbox"},
+	}
+	for _, mode := range []string{"lax", "strict"} {
+		env := newTagEnvironment(nil, registerTestOperators(t))
+		env.SetErrorMode(mode)
+		for source, want := range sources {
+			tmpl, err := liquid.ParseTemplate(source, &liquid.TemplateOptions{Environment: env})
+			if err != nil {
+				t.Errorf("%s: ParseTemplate(%q) error = %v", mode, source, err)
+				continue
+			}
+			if got := tmpl.Render(assigns, nil); got != want {
+				t.Errorf("%s: Render(%q) = %q, want %q", mode, source, got, want)
+			}
+		}
+	}
+}
+
+func TestRegisteredOperatorsArePerEnvironment(t *testing.T) {
+	source := `{% if subject startswith 'Re:' %}reply{% endif %}`
+	env := newTagEnvironment(nil, registerTestOperators(t))
+	env.SetErrorMode("strict")
+	parseTemplate(t, env, source)
+
+	env = newTagEnvironment(nil)
+	env.SetErrorMode("strict")
+	if _, err := liquid.ParseTemplate(source, &liquid.TemplateOptions{Environment: env}); err == nil {
+		t.Error("Expected a syntax error for an operator registered with another environment")
+	}
+
+	env.SetErrorMode("lax")
+	tmpl := parseTemplate(t, env, source)
+	if got := tmpl.Render(map[string]interface{}{"subject": "Re: hello"}, nil); !strings.Contains(got, "Unknown operator startswith") {
+		t.Errorf("Render() = %q, want an unknown operator error", got)
+	}
+}
+
+func TestRegisteredOperatorsCompiled(t *testing.T) {
+	env := newTagEnvironment(nil, registerTestOperators(t))
+	tmpl := parseTemplate(t, env, `{% if subject startswith 'Re:' %}reply{% endif %}|{{ status in allowed }}`)
+	data, err := tmpl.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+	loaded, err := liquid.LoadCompiled(data, &liquid.TemplateOptions{Environment: env})
+	if err != nil {
+		t.Fatalf("LoadCompiled() error = %v", err)
+	}
+	assigns := map[string]interface{}{"subject": "Re: hello", "status": "sent", "allowed": []interface{}{"sent"}}
+	if got := loaded.Render(assigns, nil); got != "reply|true" {
+		t.Errorf("Render() = %q, want %q", got, "reply|true")
+	}
+}
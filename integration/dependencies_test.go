@@ -0,0 +1,104 @@
+package integration
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Notifuse/liquidgo/liquid"
+	"github.com/Notifuse/liquidgo/liquid/tags"
+)
+
+func dependencyGraph(t *testing.T, source string, partials map[string]string) *liquid.DependencyGraph {
+	t.Helper()
+	env := liquid.NewEnvironment()
+	tags.RegisterStandardTags(env)
+	tmpl, err := liquid.ParseTemplate(source, &liquid.TemplateOptions{Environment: env, LineNumbers: true})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	tmpl.SetName("page")
+	return liquid.Dependencies(tmpl, NewStubFileSystem(partials))
+}
+
+func TestDependenciesGraph(t *testing.T) {
+	graph := dependencyGraph(t, "{% render 'header' %}\n{% if x %}{% include 'product' %}{% endif %}{% render 'footer' %}", map[string]string{
+		"header":  "{% render 'logo' %}{% render 'nav' %}",
+		"product": "{% for v in variants %}{% render 'price' %}{% endfor %}",
+		"footer":  "{% render 'nav' %}",
+		"logo":    "logo",
+		"nav":     "{% render 'link' %}",
+		"link":    "link",
+		"price":   "price",
+	})
+
+	if graph.Root != "page" {
+		t.Errorf("Root = %q, want %q", graph.Root, "page")
+	}
+	wantPage := []liquid.PartialDependency{
+		{Name: "header", Tag: "render", LineNumber: 1},
+		{Name: "product", Tag: "include", LineNumber: 2},
+		{Name: "footer", Tag: "render", LineNumber: 2},
+	}
+	if !reflect.DeepEqual(graph.Dependencies["page"], wantPage) {
+		t.Errorf("Dependencies[page] = %+v, want %+v", graph.Dependencies["page"], wantPage)
+	}
+
+	wantPartials := []string{"footer", "header", "link", "logo", "nav", "price", "product"}
+	if got := graph.Partials(); !reflect.DeepEqual(got, wantPartials) {
+		t.Errorf("Partials() = %v, want %v", got, wantPartials)
+	}
+	if got, want := graph.Dependents("nav"), []string{"footer", "header", "page"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Dependents(nav) = %v, want %v", got, want)
+	}
+	if got := graph.Dependents("page"); len(got) != 0 {
+		t.Errorf("Dependents(page) = %v, want none", got)
+	}
+	if len(graph.Cycles) != 0 || len(graph.Diagnostics) != 0 {
+		t.Errorf("Cycles = %v, Diagnostics = %+v, want none", graph.Cycles, graph.Diagnostics)
+	}
+}
+
+func TestDependenciesCycles(t *testing.T) {
+	graph := dependencyGraph(t, "{% render 'a' %}", map[string]string{
+		"a": "{% render 'b' %}",
+		"b": "{% render 'c' %}{% render 'b' %}",
+		"c": "{% include 'a' %}",
+	})
+
+	want := [][]string{{"a", "b", "c", "a"}, {"b", "b"}}
+	if !reflect.DeepEqual(graph.Cycles, want) {
+		t.Errorf("Cycles = %v, want %v", graph.Cycles, want)
+	}
+	if got := graph.Dependents("c"); !reflect.DeepEqual(got, []string{"a", "b", "page"}) {
+		t.Errorf("Dependents(c) = %v", got)
+	}
+}
+
+func TestDependenciesDiagnostics(t *testing.T) {
+	graph := dependencyGraph(t, "{% render 'gone' %}\n{% include name %}\n{% render 'broken' %}{% render 'gone' %}", map[string]string{
+		"broken": "{% if %}",
+	})
+
+	if got := graph.Missing(); !reflect.DeepEqual(got, []string{"gone"}) {
+		t.Errorf("Missing() = %v, want [gone]", got)
+	}
+	if len(graph.Diagnostics) != 4 {
+		t.Fatalf("Diagnostics = %+v, want 4", graph.Diagnostics)
+	}
+
+	dynamic := graph.Diagnostics[1]
+	if dynamic.Name != "" || dynamic.Tag != "include" || dynamic.LineNumber != 2 || dynamic.Missing {
+		t.Errorf("Dynamic diagnostic = %+v", dynamic)
+	}
+	if _, ok := dynamic.Err.(*liquid.ArgumentError); !ok {
+		t.Errorf("Dynamic diagnostic error = %T, want *liquid.ArgumentError", dynamic.Err)
+	}
+
+	broken := graph.Diagnostics[2]
+	if broken.Name != "broken" || broken.Missing || broken.Template != "page" {
+		t.Errorf("Parse error diagnostic = %+v", broken)
+	}
+	if _, ok := broken.Err.(*liquid.SyntaxError); !ok {
+		t.Errorf("Parse error diagnostic error = %T, want *liquid.SyntaxError", broken.Err)
+	}
+}
@@ -0,0 +1,111 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/Notifuse/liquidgo/liquid"
+	"github.com/Notifuse/liquidgo/liquid/tags"
+)
+
+// settingsHash keeps its keys in insertion order.
+type settingsHash struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (h *settingsHash) Keys() []string             { return h.keys }
+func (h *settingsHash) Get(key string) interface{} { return h.values[key] }
+
+func TestForKeyValueOverHashes(t *testing.T) {
+	sources := map[string]string{
+		`{% for key, value in prices %}{{ key }}={{ value }};{% endfor %}`:                                                     "apple=3;banana=1;cherry=7;",
+		`{% for item in prices %}{{ item[0] }}={{ item[1] }};{% endfor %}`:                                                     "apple=3;banana=1;cherry=7;",
+		`{% for key, value in prices reversed %}{{ key }};{% endfor %}`:                                                        "cherry;banana;apple;",
+		`{% for key, value in prices offset: 1 limit: 1 %}{{ key }}{% endfor %}`:                                               "banana",
+		`{% for key, value in prices %}{{ forloop.index }}/{{ forloop.length }}{% if forloop.last %}!{% endif %} {% endfor %}`: "1/3 2/3 3/3! ",
+		`{% for key, value in empty_hash %}x{% else %}none{% endfor %}`:                                                        "none",
+		`{% for key, value in settings %}{{ key }};{% endfor %}`:                                                               "color;size;weight;",
+		`{% for key, value in settings insertion_order %}{{ key }}={{ value }};{% endfor %}`:                                   "size=L;color=red;weight=2;",
+		`{% for key, value in prices insertion_order %}{{ key }};{% endfor %}`:                                                 "apple;banana;cherry;",
+		`{% for key, value in {"b": 2, "a": 1} %}{{ key }}{{ value }}{% endfor %}`:                                             "a1b2",
+		`{% for name, qty in pairs %}{{ name }}x{{ qty }} {% endfor %}`:                                                        "ax1 bx2 ",
+		`{% for key, value in prices %}{% if key == "banana" %}{% break %}{% endif %}{{ key }}{% endfor %}`:                    "apple",
+	}
+	assigns := map[string]interface{}{
+		"prices":     map[string]interface{}{"cherry": 7, "apple": 3, "banana": 1},
+		"empty_hash": map[string]interface{}{},
+		"settings": &settingsHash{
+			keys:   []string{"size", "color", "weight"},
+			values: map[string]interface{}{"size": "L", "color": "red", "weight": 2},
+		},
+		"pairs": []interface{}{[]interface{}{"a", 1}, []interface{}{"b", 2}},
+	}
+
+	env := liquid.NewEnvironment()
+	tags.RegisterStandardTags(env)
+	for source, want := range sources {
+		tmpl, err := liquid.ParseTemplate(source, &liquid.TemplateOptions{Environment: env})
+		if err != nil {
+			t.Errorf("ParseTemplate(%q) error = %v", source, err)
+			continue
+		}
+		if got := tmpl.Render(assigns, nil); got != want {
+			t.Errorf("Render(%q) = %q, want %q", source, got, want)
+		}
+	}
+}
+
+func TestForKeyValueRequiresPairs(t *testing.T) {
+	env := liquid.NewEnvironment()
+	tags.RegisterStandardTags(env)
+	assigns := map[string]interface{}{
+		"numbers": []interface{}{1, 2, 3},
+		"mixed":   []interface{}{[]interface{}{"a", 1}, "b"},
+	}
+	for _, source := range []string{
+		`{% for k, v in numbers %}{{ k }}={{ v }};{% endfor %}`,
+		`{% for k, v in mixed %}{{ k }}={{ v }};{% endfor %}`,
+		`{% for k, v in (1..3) %}{{ k }}={{ v }};{% endfor %}`,
+	} {
+		tmpl, err := liquid.ParseTemplate(source, &liquid.TemplateOptions{Environment: env})
+		if err != nil {
+			t.Errorf("ParseTemplate(%q) error = %v", source, err)
+			continue
+		}
+		want := "Liquid error: for loop with key and value requires a hash or [key, value] pairs"
+		if got := tmpl.Render(assigns, nil); got != want {
+			t.Errorf("Render(%q) = %q, want %q", source, got, want)
+		}
+	}
+}
+
+func TestForKeyValueCompiled(t *testing.T) {
+	env := liquid.NewEnvironment()
+	tags.RegisterStandardTags(env)
+	tmpl, err := liquid.ParseTemplate(`{% for key, value in settings insertion_order %}{{ key }}={{ value }};{% endfor %}`, &liquid.TemplateOptions{Environment: env})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	data, err := tmpl.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+	loaded, err := liquid.LoadCompiled(data, &liquid.TemplateOptions{Environment: env})
+	if err != nil {
+		t.Fatalf("LoadCompiled() error = %v", err)
+	}
+	settings := &settingsHash{keys: []string{"b", "a"}, values: map[string]interface{}{"b": 2, "a": 1}}
+	if got := loaded.Render(map[string]interface{}{"settings": settings}, nil); got != "b=2;a=1;" {
+		t.Errorf("Render() = %q, want %q", got, "b=2;a=1;")
+	}
+}
+
+func TestForKeyValueVariableAnalysis(t *testing.T) {
+	analysis := analyzeTemplate(t, `{% for code, country in countries %}{{ code }}: {{ country.name }}{% endfor %}`, nil)
+
+	assertReferences(t, analysis, []analyzedReference{
+		{"countries", "countries", liquid.GlobalVariable, true, 1, ""},
+		{"code", "code", liquid.LoopVariable, false, 1, ""},
+		{"country.name", "countries[].name", liquid.LoopVariable, true, 1, ""},
+	})
+}
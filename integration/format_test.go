@@ -0,0 +1,83 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/Notifuse/liquidgo/liquid"
+	"github.com/Notifuse/liquidgo/liquid/tags"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name, source, want string
+	}{
+		{
+			name:   "blank blocks",
+			source: "{%if user%}\n{%assign greeting='Hi '|append:user.name%}\n{%else%}\n      {%assign greeting = 'Hello'%}\n{%endif%}\n<p>{{greeting}}</p>\n",
+			want:   "{% if user %}\n  {% assign greeting = 'Hi ' | append: user.name %}\n{% else %}\n  {% assign greeting = 'Hello' %}\n{% endif %}\n<p>{{ greeting }}</p>\n",
+		},
+		{
+			name:   "trim markers",
+			source: "<ul>\n{%- for p in products limit:2 -%}\n<li>{{p.title|upcase}}</li>\n{%- if p.sale -%}\n<b>{{ p.price|minus:  1 }}</b>\n{%- endif -%}\n{%- endfor -%}\n</ul>",
+			want:   "<ul>\n{%- for p in products limit: 2 -%}\n  <li>{{ p.title | upcase }}</li>\n  {%- if p.sale -%}\n    <b>{{ p.price | minus: 1 }}</b>\n  {%- endif -%}\n{%- endfor -%}\n</ul>",
+		},
+		{
+			name:   "case",
+			source: "{% case p.type %}\n{% when 'a','b' %}\n{% assign n=1 %}\n{% else %}\n{% assign n=2 %}\n{% endcase %}{{n}}",
+			want:   "{% case p.type %}\n{% when 'a', 'b' %}\n  {% assign n = 1 %}\n{% else %}\n  {% assign n = 2 %}\n{% endcase %}{{ n }}",
+		},
+		{
+			name:   "verbatim bodies",
+			source: "{%raw%}{{  x  }}{%endraw%}\n{%comment%}  {%if%}  {%endcomment%}\n{%liquid assign a = 1%}{%# note  here %}{{a}}",
+			want:   "{% raw %}{{  x  }}{%endraw%}\n{% comment %}  {%if%}  {%endcomment%}\n{%liquid assign a = 1%}{%# note  here %}{{ a }}",
+		},
+		{
+			name:   "rendered whitespace",
+			source: "{% capture x %}\n    {% assign a = 1 %}\n{% endcapture %}[{{ x }}]",
+			want:   "{% capture x %}\n    {% assign a = 1 %}\n{% endcapture %}[{{ x }}]",
+		},
+	}
+
+	env := liquid.NewEnvironment()
+	tags.RegisterStandardTags(env)
+	assigns := map[string]interface{}{
+		"user": map[string]interface{}{"name": "Ann"},
+		"p":    map[string]interface{}{"type": "b"},
+		"products": []interface{}{
+			map[string]interface{}{"title": "hat", "price": 5, "sale": true},
+			map[string]interface{}{"title": "cap", "price": 3},
+		},
+	}
+	for _, test := range tests {
+		got, err := liquid.Format(test.source, liquid.FormatOptions{Environment: env})
+		if err != nil {
+			t.Errorf("%s: Format() error = %v", test.name, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: Format() = %q, want %q", test.name, got, test.want)
+		}
+		before, _ := liquid.ParseTemplate(test.source, &liquid.TemplateOptions{Environment: env})
+		after, err := liquid.ParseTemplate(got, &liquid.TemplateOptions{Environment: env})
+		if err != nil {
+			t.Errorf("%s: ParseTemplate(formatted) error = %v", test.name, err)
+			continue
+		}
+		if want, got := before.Render(assigns, nil), after.Render(assigns, nil); got != want {
+			t.Errorf("%s: formatted template renders %q, want %q", test.name, got, want)
+		}
+	}
+}
+
+func TestFormatIndent(t *testing.T) {
+	env := liquid.NewEnvironment()
+	tags.RegisterStandardTags(env)
+	source := "{% for a in b %}\n{% if a %}\n{% assign c = a %}\n{% endif %}\n{% endfor %}"
+	got, err := liquid.Format(source, liquid.FormatOptions{Environment: env, Indent: "\t"})
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if want := "{% for a in b %}\n\t{% if a %}\n\t\t{% assign c = a %}\n\t{% endif %}\n{% endfor %}"; got != want {
+		t.Errorf("Format() = %q, want %q", got, want)
+	}
+}
@@ -229,6 +229,30 @@ func _withGlobalFilter(t *testing.T, filters []interface{}, fn func()) {
 	fn()
 }
 
+// newTagEnvironment creates an environment with the standard tags and the ones
+// added by register, reading partials from fileSystem when it isn't nil.
+func newTagEnvironment(fileSystem liquid.FileSystem, register ...func(*liquid.Environment)) *liquid.Environment {
+	env := liquid.NewEnvironment()
+	tags.RegisterStandardTags(env)
+	for _, fn := range register {
+		fn(env)
+	}
+	if fileSystem != nil {
+		env.SetFileSystem(fileSystem)
+	}
+	return env
+}
+
+// parseTemplate parses source with env, failing the test on error.
+func parseTemplate(t *testing.T, env *liquid.Environment, source string) *liquid.Template {
+	t.Helper()
+	tmpl, err := liquid.ParseTemplate(source, &liquid.TemplateOptions{Environment: env})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	return tmpl
+}
+
 // StubFileSystem is a mock file system for testing partials/includes.
 type StubFileSystem struct {
 	fileReadCount int
@@ -0,0 +1,63 @@
+package integration
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Notifuse/liquidgo/liquid"
+)
+
+func newThemeFileSystem() liquid.FileSystem {
+	return liquid.NewLayeredFileSystem(
+		NewStubFileSystem(map[string]string{
+			"header": "<tenant>{% render 'parent:header', title: title %}</tenant>",
+		}),
+		NewStubFileSystem(map[string]string{
+			"header": "<theme>{% render 'parent:header', title: title %}</theme>",
+			"footer": "<theme-footer>{% include 'parent:footer' %}</theme-footer>",
+		}),
+		NewStubFileSystem(map[string]string{
+			"header": "{{ title }}",
+			"footer": "default footer",
+		}),
+	)
+}
+
+func TestLayeredFileSystemParentChain(t *testing.T) {
+	env := newTagEnvironment(newThemeFileSystem())
+	tmpl := parseTemplate(t, env, `{% render 'header', title: 'Hi' %}|{% include 'footer' %}`)
+
+	want := "<tenant><theme>Hi</theme></tenant>|<theme-footer>default footer</theme-footer>"
+	if got := tmpl.Render(nil, nil); got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestLayeredFileSystemWithSharedPartialCache(t *testing.T) {
+	env := newTagEnvironment(newThemeFileSystem())
+	env.SetPartialCache(liquid.NewSharedPartialCache(liquid.SharedPartialCacheOptions{}))
+	tmpl := parseTemplate(t, env, `{% render 'header', title: 'Hi' %}`)
+
+	for i := 0; i < 2; i++ {
+		if got := tmpl.Render(nil, nil); got != "<tenant><theme>Hi</theme></tenant>" {
+			t.Errorf("Render() = %q", got)
+		}
+	}
+	if stats := env.PartialCache().Stats(); stats.Entries != 3 || stats.Hits != 3 {
+		t.Errorf("Stats() = %+v, want 3 entries hit on the second render", stats)
+	}
+}
+
+func TestLayeredFileSystemDependencies(t *testing.T) {
+	env := newTagEnvironment(newThemeFileSystem())
+	tmpl := parseTemplate(t, env, `{% render 'header' %}`)
+
+	graph := liquid.Dependencies(tmpl, nil)
+	want := []string{"header", "parent:header", "parent:parent:header"}
+	if got := graph.Partials(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Partials() = %v, want %v", got, want)
+	}
+	if len(graph.Cycles) != 0 || len(graph.Diagnostics) != 0 {
+		t.Errorf("Cycles = %v, Diagnostics = %+v, want none", graph.Cycles, graph.Diagnostics)
+	}
+}
@@ -0,0 +1,68 @@
+package integration
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Notifuse/liquidgo/liquid"
+	"github.com/Notifuse/liquidgo/liquid/tags"
+)
+
+var emailLayouts = map[string]string{
+	"email": `<html><body>{% block header %}{% render 'logo' %}{% endblock %}` +
+		`{% block body %}{% endblock %}{% block footer %}<p>Unsubscribe</p>{% endblock %}</body></html>`,
+	"transactional": `{% layout 'email' %}{% block body %}<table>{% block content %}{% endblock %}</table>{% endblock %}` +
+		`{% block footer %}<p>Order {{ order.number }}</p>{{ block.super }}{% endblock %}`,
+	"logo": `<img src="logo.png">`,
+}
+
+func TestLayoutEmailInheritance(t *testing.T) {
+	env := newTagEnvironment(NewStubFileSystem(emailLayouts), tags.RegisterLayoutTags)
+	env.SetPartialCache(liquid.NewSharedPartialCache(liquid.SharedPartialCacheOptions{}))
+	tmpl := parseTemplate(t, env, `{% layout 'transactional' %}
+{% block content %}{% for item in order.items %}<tr><td>{{ item }}</td></tr>{% endfor %}{% endblock %}`)
+	tmpl.SetName("receipt")
+
+	assigns := map[string]interface{}{
+		"order": map[string]interface{}{"number": 1001, "items": []interface{}{"Tea", "Cake"}},
+	}
+	want := `<html><body><img src="logo.png"><table><tr><td>Tea</td></tr><tr><td>Cake</td></tr></table>` +
+		`<p>Order 1001</p><p>Unsubscribe</p></body></html>`
+	for i := 0; i < 2; i++ {
+		if got := tmpl.Render(assigns, nil); got != want {
+			t.Errorf("Render() = %q, want %q", got, want)
+		}
+	}
+	if stats := env.PartialCache().Stats(); stats.Misses != 3 || stats.Hits != 3 {
+		t.Errorf("Stats() = %+v, want the layouts and partial parsed once", stats)
+	}
+}
+
+func TestLayoutMissingBlockIsSyntaxError(t *testing.T) {
+	env := newTagEnvironment(NewStubFileSystem(emailLayouts), tags.RegisterLayoutTags)
+	tmpl := parseTemplate(t, env, `{% layout 'transactional' %}{% block sidebar %}x{% endblock %}`)
+	tmpl.SetName("receipt")
+
+	_, err := tmpl.Execute(nil, &liquid.RenderOptions{RethrowErrors: true})
+	syntaxErr, ok := err.(*liquid.SyntaxError)
+	if !ok {
+		t.Fatalf("Expected SyntaxError, got %v", err)
+	}
+	if syntaxErr.Err.TemplateName != "receipt" || !strings.Contains(err.Error(), "not defined by layout 'transactional'") {
+		t.Errorf("Unexpected error %v (template %q)", err, syntaxErr.Err.TemplateName)
+	}
+}
+
+func TestLayoutDependencies(t *testing.T) {
+	env := newTagEnvironment(NewStubFileSystem(emailLayouts), tags.RegisterLayoutTags)
+	tmpl := parseTemplate(t, env, `{% layout 'transactional' %}{% block content %}{% endblock %}`)
+
+	graph := liquid.Dependencies(tmpl, nil)
+	if got, want := graph.Partials(), []string{"email", "logo", "transactional"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Partials() = %v, want %v", got, want)
+	}
+	if got := graph.Dependencies["transactional"]; len(got) != 1 || got[0].Tag != "layout" {
+		t.Errorf("Dependencies[transactional] = %+v, want the layout tag", got)
+	}
+}
@@ -0,0 +1,72 @@
+package integration
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Notifuse/liquidgo/liquid"
+)
+
+var cardPartial = map[string]string{
+	"card": `{{ title }}:{{ tags | join: "," }}:{{ user.name }}`,
+}
+
+func TestLiteralExpressionsInTags(t *testing.T) {
+	sources := map[string]string{
+		`{% assign values = [1, "b", x] %}{{ values | join: "," }}`:                           "1,b,X",
+		`{% assign h = {"name": x, size: 2, tags: ['a', 'b']} %}{{ h.name }}/{{ h.tags[1] }}`: "X/b",
+		`{% assign nested = [[1, 2], [x,]] %}{{ nested.last.first }}{{ nested.size }}`:        "X2",
+		`{% for i in [3, 2, 1] %}{{ i }}{% endfor %}`:                                         "321",
+		`{% for i in [3, 2, 1] reversed limit: 2 %}{{ i }}{% endfor %}`:                       "23",
+		`{% for i in [] %}{{ i }}{% else %}none{% endfor %}`:                                  "none",
+		`{% render 'card', title: x, tags: ['a', 'b'], user: {name: "Ann"} %}`:                "X:a,b:Ann",
+		`{% include 'card', title: x, tags: [1, 2,], user: {"name": x} %}`:                    "X:1,2:X",
+		`{% assign merged = list | concat: [3, 4] %}{{ merged | join: "" }}`:                  "1234",
+		`{% if [1, 2] contains 2 %}yes{% endif %}`:                                            "yes",
+	}
+	for _, mode := range []string{"lax", "strict", "rigid"} {
+		env := newTagEnvironment(NewStubFileSystem(cardPartial))
+		env.SetErrorMode(mode)
+		for source, want := range sources {
+			tmpl, err := liquid.ParseTemplate(source, &liquid.TemplateOptions{Environment: env})
+			if err != nil {
+				t.Errorf("%s: ParseTemplate(%q) error = %v", mode, source, err)
+				continue
+			}
+			got := tmpl.Render(map[string]interface{}{"x": "X", "list": []interface{}{1, 2}}, nil)
+			if got != want {
+				t.Errorf("%s: Render(%q) = %q, want %q", mode, source, got, want)
+			}
+		}
+	}
+}
+
+func TestLiteralExpressionsCompiled(t *testing.T) {
+	env := newTagEnvironment(NewStubFileSystem(cardPartial))
+	env.SetErrorMode("lax")
+	options := &liquid.TemplateOptions{Environment: env}
+	tmpl, err := liquid.ParseTemplate(`{% assign h = {a: [x, 2], "b c": {d: x}} %}{{ h.a | join: "," }}/{{ h["b c"].d }}`, options)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	data, err := tmpl.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+	loaded, err := liquid.LoadCompiled(data, options)
+	if err != nil {
+		t.Fatalf("LoadCompiled() error = %v", err)
+	}
+	if got := loaded.Render(map[string]interface{}{"x": "X"}, nil); got != "X,2/X" {
+		t.Errorf("Render() = %q, want %q", got, "X,2/X")
+	}
+}
+
+func TestLiteralExpressionsStaticAnalysis(t *testing.T) {
+	env := newTagEnvironment(NewStubFileSystem(cardPartial))
+	env.SetErrorMode("lax")
+	tmpl := parseTemplate(t, env, `{% assign h = {name: customer.name, items: [order.total, 1]} %}{{ h.name }}`)
+	if got, want := liquid.AnalyzeVariables(tmpl).Globals(), []string{"customer.name", "order.total"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Globals() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,42 @@
+package integration
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Notifuse/liquidgo/liquid"
+	"github.com/Notifuse/liquidgo/liquid/tags"
+)
+
+var buttonMacros = map[string]string{
+	"buttons": `{% macro button(label, url, color: 'blue') %}<a class="btn-{{ color }}" href="{{ url }}">{{ label }}</a>{% endmacro %}`,
+}
+
+func TestMacrosImportedFromFileSystem(t *testing.T) {
+	env := newTagEnvironment(NewStubFileSystem(buttonMacros), tags.RegisterMacroTags)
+	tmpl := parseTemplate(t, env, `{% import 'buttons' as ui %}{% for product in products %}{% call ui.button 'Buy', product.url, color: color %}{% endfor %}`)
+
+	assigns := map[string]interface{}{
+		"color": "green",
+		"products": []interface{}{
+			map[string]interface{}{"url": "/tea"},
+			map[string]interface{}{"url": "/cake"},
+		},
+	}
+	want := `<a class="btn-green" href="/tea">Buy</a><a class="btn-green" href="/cake">Buy</a>`
+	if got := tmpl.Render(assigns, nil); got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestMacroStaticAnalysis(t *testing.T) {
+	env := newTagEnvironment(NewStubFileSystem(buttonMacros), tags.RegisterMacroTags)
+	tmpl := parseTemplate(t, env, `{% import 'buttons' %}{% macro price(amount) %}{{ amount | money }}{% endmacro %}{% call price order.total %}`)
+
+	if got, want := liquid.AnalyzeVariables(tmpl).Globals(), []string{"order.total"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Globals() = %v, want %v", got, want)
+	}
+	if got, want := liquid.Dependencies(tmpl, nil).Partials(), []string{"buttons"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Partials() = %v, want %v", got, want)
+	}
+}
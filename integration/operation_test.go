@@ -0,0 +1,99 @@
+package integration
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Notifuse/liquidgo/liquid"
+	"github.com/Notifuse/liquidgo/liquid/tags"
+)
+
+func TestOperatorsInTemplates(t *testing.T) {
+	sources := map[string]string{
+		`{{ item.price * item.quantity + shipping }}`:                                            "26",
+		`{{ (item.price + shipping) * item.quantity }}`:                                          "36",
+		`{{ item.price * item.quantity - discount | round: 2 }}`:                                 "17.5",
+		`{% assign total = item.price * item.quantity %}{{ total }}`:                             "21",
+		`{{ item.price | plus: item.quantity * 2 }}`:                                             "13",
+		`{% if item.price * item.quantity > 20 %}big{% else %}small{% endif %}`:                  "big",
+		`{% if item.price * item.quantity == 21 %}exact{% endif %}`:                              "exact",
+		`{% if item.quantity % 2 == 1 and item.price > 5 %}odd{% endif %}`:                       "odd",
+		`{{ item.quantity > 2 and item.price < 5 }} {{ not item.gift }}`:                         "false true",
+		`{% for i in (1..3) %}{{ i * 10 }}{% unless forloop.last %},{% endunless %}{% endfor %}`: "10,20,30",
+	}
+	assigns := map[string]interface{}{
+		"item":     map[string]interface{}{"price": 7, "quantity": 3, "gift": false},
+		"shipping": 5,
+		"discount": 3.5,
+	}
+	for _, mode := range []string{"lax", "strict"} {
+		env := liquid.NewEnvironment()
+		tags.RegisterStandardTags(env)
+		env.SetErrorMode(mode)
+		for source, want := range sources {
+			tmpl, err := liquid.ParseTemplate(source, &liquid.TemplateOptions{Environment: env})
+			if err != nil {
+				t.Errorf("%s: ParseTemplate(%q) error = %v", mode, source, err)
+				continue
+			}
+			if got := tmpl.Render(assigns, nil); got != want {
+				t.Errorf("%s: Render(%q) = %q, want %q", mode, source, got, want)
+			}
+		}
+	}
+}
+
+func TestNumericEqualityInConditions(t *testing.T) {
+	env := liquid.NewEnvironment()
+	tags.RegisterStandardTags(env)
+	sources := map[string]string{
+		`{% if a == 1.0 %}y{% else %}n{% endif %}`:      "y",
+		`{% if a != 1.0 %}y{% else %}n{% endif %}`:      "n",
+		`{% if b == 1 %}y{% else %}n{% endif %}`:        "y",
+		`{% if big == a %}y{% else %}n{% endif %}`:      "y",
+		`{% if a == "1" %}y{% else %}n{% endif %}`:      "n",
+		`{% if half * 2 == a %}y{% else %}n{% endif %}`: "y",
+	}
+	assigns := map[string]interface{}{"a": 1, "b": 1.0, "big": int64(1), "half": 0.5}
+	for source, want := range sources {
+		tmpl, err := liquid.ParseTemplate(source, &liquid.TemplateOptions{Environment: env})
+		if err != nil {
+			t.Errorf("ParseTemplate(%q) error = %v", source, err)
+			continue
+		}
+		if got := tmpl.Render(assigns, nil); got != want {
+			t.Errorf("Render(%q) = %q, want %q", source, got, want)
+		}
+	}
+}
+
+func TestOperatorsZeroDivision(t *testing.T) {
+	env := liquid.NewEnvironment()
+	tags.RegisterStandardTags(env)
+
+	tmpl, err := liquid.ParseTemplate(`{{ total / count }}|{% if total % count > 1 %}x{% endif %}|after`, &liquid.TemplateOptions{Environment: env})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	assigns := map[string]interface{}{"total": 10, "count": 0}
+	if got, want := tmpl.Render(assigns, nil), "Liquid error: divided by 0|Liquid error: divided by 0|after"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+
+	_, err = tmpl.Execute(assigns, &liquid.RenderOptions{RethrowErrors: true})
+	if _, ok := err.(*liquid.ZeroDivisionError); !ok {
+		t.Errorf("Execute() error = %v, want a ZeroDivisionError", err)
+	}
+}
+
+func TestOperatorsStrictSyntaxErrors(t *testing.T) {
+	env := liquid.NewEnvironment()
+	tags.RegisterStandardTags(env)
+	env.SetErrorMode("strict")
+	for _, source := range []string{`{{ a + }}`, `{{ (a + b }}`, `{{ a * * b }}`} {
+		_, err := liquid.ParseTemplate(source, &liquid.TemplateOptions{Environment: env})
+		if err == nil || !strings.Contains(err.Error(), "Liquid syntax error") {
+			t.Errorf("ParseTemplate(%q) error = %v, want a syntax error", source, err)
+		}
+	}
+}
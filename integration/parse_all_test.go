@@ -0,0 +1,132 @@
+package integration
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Notifuse/liquidgo/liquid"
+	"github.com/Notifuse/liquidgo/liquid/tags"
+)
+
+// diagnosticSpans formats diagnostics as "line:column-endLine:endColumn code".
+func diagnosticSpans(diagnostics []liquid.Diagnostic) []string {
+	var spans []string
+	for _, d := range diagnostics {
+		spans = append(spans, fmt.Sprintf("%d:%d-%d:%d %s", d.Line, d.Column, d.EndLine, d.EndColumn, d.Code))
+	}
+	return spans
+}
+
+func TestParseAll(t *testing.T) {
+	tests := []struct {
+		name, source string
+		want         []string
+	}{
+		{
+			name:   "unclosed block at end of template",
+			source: "{% if a %}\n{{ a }}",
+			want:   []string{"1:1-1:11 unclosed-tag"},
+		},
+		{
+			name:   "unclosed block at end tag of enclosing block",
+			source: "{% for x in y %}{% if x %}{{ x }}{% endfor %}{{ z }}",
+			want:   []string{"1:17-1:27 unclosed-tag"},
+		},
+		{
+			name:   "unknown and unexpected tags",
+			source: "{% bogus %}{{ a }}\n{% endif %}{% else %}",
+			want:   []string{"1:1-1:12 unknown-tag", "2:1-2:12 unexpected-tag", "2:12-2:22 unexpected-tag"},
+		},
+		{
+			name:   "unknown tag inside a block",
+			source: "{% for x in y %}{% bogus %}{% else %}{% endfor %}",
+			want:   []string{"1:17-1:28 unknown-tag"},
+		},
+		{
+			name:   "invalid markup",
+			source: "{% if %}{{ inner }}{% endif %}{% assign = 1 %}{{ ok. }}",
+			want:   []string{"1:1-1:9 invalid-tag", "1:31-1:47 invalid-tag", "1:47-1:56 invalid-output"},
+		},
+		{
+			name:   "unterminated tag",
+			source: "{{ a }}\n{% if",
+			want:   []string{"2:1-2:3 unterminated-tag"},
+		},
+		{
+			name:   "unterminated output",
+			source: "{{ a }}\n{{ b",
+			want:   []string{"2:1-2:3 unterminated-output"},
+		},
+		{
+			name:   "liquid tag lines",
+			source: "{% if a %}{% liquid bogus %}{% endif %}",
+			want:   []string{"1:21-1:26 unknown-tag"},
+		},
+		{
+			name:   "unclosed raw",
+			source: "ok {% raw %}x",
+			want:   []string{"1:4-1:13 unclosed-tag"},
+		},
+	}
+
+	env := liquid.NewEnvironment()
+	tags.RegisterStandardTags(env)
+	env.SetErrorMode("strict")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, diagnostics := liquid.ParseAll("page", tt.source, &liquid.TemplateOptions{Environment: env, LineNumbers: true})
+			if got := diagnosticSpans(diagnostics); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseAll(%q) = %v, want %v", tt.source, got, tt.want)
+			}
+			for _, d := range diagnostics {
+				if d.Template != "page" || d.Severity != liquid.DiagnosticError || d.Message == "" {
+					t.Errorf("diagnostic = %+v", d)
+				}
+			}
+		})
+	}
+}
+
+func TestParseAllKeepsValidNodes(t *testing.T) {
+	env := liquid.NewEnvironment()
+	tags.RegisterStandardTags(env)
+	env.SetErrorMode("strict")
+	source := "{% if %}{{ inner }}{% endif %}{% bogus %}{% for x in items %}{{ x.title }}"
+
+	tmpl, diagnostics := liquid.ParseAll("page", source, &liquid.TemplateOptions{Environment: env})
+	if len(diagnostics) != 3 {
+		t.Errorf("diagnostics = %v, want 3", diagnostics)
+	}
+	if got, want := liquid.AnalyzeVariables(tmpl).Globals(), []string{"inner", "items", "items[].title"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Globals() = %v, want %v", got, want)
+	}
+
+	if _, err := liquid.ParseTemplate(source, &liquid.TemplateOptions{Environment: env}); err == nil {
+		t.Error("ParseTemplate() error = nil, want the first syntax error")
+	}
+}
+
+func TestParseAllErrorModes(t *testing.T) {
+	source := "{{ hello. }}{% if a %}"
+	for mode, want := range map[string][]string{
+		"lax":    {"1:13-1:23 unclosed-tag"},
+		"warn":   {"1:1-1:13 invalid-output", "1:13-1:23 unclosed-tag"},
+		"strict": {"1:1-1:13 invalid-output", "1:13-1:23 unclosed-tag"},
+	} {
+		env := liquid.NewEnvironment()
+		tags.RegisterStandardTags(env)
+		env.SetErrorMode(mode)
+		tmpl, diagnostics := liquid.ParseAll("page", source, &liquid.TemplateOptions{Environment: env, LineNumbers: true})
+		if got := diagnosticSpans(diagnostics); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s mode: ParseAll() = %v, want %v", mode, got, want)
+			continue
+		}
+		if severity := diagnostics[0].Severity; mode == "warn" && severity != liquid.DiagnosticWarning {
+			t.Errorf("warn mode: severity = %v, want warning", severity)
+		}
+		if mode == "warn" && len(tmpl.Warnings()) != 1 {
+			t.Errorf("warn mode: Warnings() = %v, want 1", tmpl.Warnings())
+		}
+	}
+}
@@ -78,17 +78,26 @@ func TestParsingQuirks_ErrorOnEmptyFilter(t *testing.T) {
 	}
 }
 
-// TestParsingQuirks_MeaninglessParensError tests meaningless parentheses error.
-// Ported from: test_meaningless_parens_error
+// TestParsingQuirks_MeaninglessParensError tests parentheses in strict mode.
+// Ported from: test_meaningless_parens_error. Unlike Ruby Liquid, parentheses
+// group conditions, so only unbalanced parentheses are an error.
 func TestParsingQuirks_MeaninglessParensError(t *testing.T) {
 	env := liquid.NewEnvironment()
 	env.SetErrorMode("strict")
 	tags.RegisterStandardTags(env)
 
 	markup := "a == 'foo' or (b == 'bar' and c == 'baz') or false"
-	template := "{% if " + markup + " %} YES {% endif %}"
+	template, err := liquid.ParseTemplate("{% if "+markup+" %} YES {% endif %}", &liquid.TemplateOptions{
+		Environment: env,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error in strict mode, got %v", err)
+	}
+	if got := template.Render(map[string]interface{}{"b": "bar", "c": "baz"}, nil); got != " YES " {
+		t.Errorf("Render() = %q, want %q", got, " YES ")
+	}
 
-	_, err := liquid.ParseTemplate(template, &liquid.TemplateOptions{
+	_, err = liquid.ParseTemplate("{% if a == 'foo' or (b == 'bar' and c == 'baz' %} YES {% endif %}", &liquid.TemplateOptions{
 		Environment: env,
 	})
 	if err == nil {
@@ -0,0 +1,50 @@
+package integration
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/Notifuse/liquidgo/liquid"
+	"github.com/Notifuse/liquidgo/liquid/tags"
+)
+
+func TestSharedPartialCacheConcurrentRenders(t *testing.T) {
+	env := liquid.NewEnvironment()
+	tags.RegisterStandardTags(env)
+	fileSystem := NewStubFileSystem(map[string]string{
+		"item": "<{{ item }}>",
+	})
+	env.SetFileSystem(fileSystem)
+	env.SetPartialCache(liquid.NewSharedPartialCache(liquid.SharedPartialCacheOptions{MaxEntries: 10}))
+
+	tmpl, err := liquid.ParseTemplate(`{% render 'item' for items as item %}`, &liquid.TemplateOptions{Environment: env})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+
+	// Warm the cache, then render concurrently from it
+	if out := tmpl.Render(map[string]interface{}{"items": []interface{}{0}}, nil); out != "<0>" {
+		t.Fatalf("Render() = %q", out)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := tmpl.Execute(map[string]interface{}{"items": []interface{}{1, 2}}, nil)
+			if err != nil || result.Output != "<1><2>" {
+				t.Errorf("Execute() = %q, %v", result.Output, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats := env.PartialCache().Stats()
+	if stats.Hits != 20 || stats.Misses != 1 || stats.Entries != 1 {
+		t.Errorf("Stats() = %+v, want 20 hits, 1 miss and 1 entry", stats)
+	}
+	if fileSystem.FileReadCount() != 1 {
+		t.Errorf("FileReadCount() = %d, want 1", fileSystem.FileReadCount())
+	}
+}
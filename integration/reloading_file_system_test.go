@@ -0,0 +1,68 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Notifuse/liquidgo/liquid"
+	"github.com/Notifuse/liquidgo/liquid/tags"
+)
+
+func writePartial(t *testing.T, dir, name, source string, mtime time.Time) {
+	t.Helper()
+	path := filepath.Join(dir, "_"+name+".liquid")
+	if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloadingFileSystemPicksUpEditedPartials(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Unix(1700000000, 0)
+	writePartial(t, dir, "page", "<main>{% render 'card', name: name %}</main>", start)
+	writePartial(t, dir, "card", "Hello {{ name }}", start)
+
+	env := liquid.NewEnvironment()
+	tags.RegisterStandardTags(env)
+	env.SetPartialCache(liquid.NewSharedPartialCache(liquid.SharedPartialCacheOptions{}))
+	var changed []string
+	fileSystem := liquid.NewReloadingFileSystem(liquid.NewLocalFileSystem(dir, ""), liquid.ReloadingFileSystemOptions{
+		Environment: env,
+		OnChange:    func(name string) { changed = append(changed, name) },
+	})
+	env.SetFileSystem(fileSystem)
+
+	render := func() string {
+		t.Helper()
+		tmpl, err := fileSystem.Template("page")
+		if err != nil {
+			t.Fatalf("Template() error = %v", err)
+		}
+		return tmpl.Render(map[string]interface{}{"name": "Ann"}, nil)
+	}
+
+	if got := render(); got != "<main>Hello Ann</main>" {
+		t.Fatalf("Render() = %q", got)
+	}
+
+	writePartial(t, dir, "card", "Welcome back {{ name }}", start.Add(time.Second))
+	fileSystem.Poll()
+	if got := render(); got != "<main>Welcome back Ann</main>" {
+		t.Errorf("Render() after editing the partial = %q", got)
+	}
+
+	writePartial(t, dir, "page", "<section>{% render 'card', name: name %}</section>", start.Add(time.Second))
+	fileSystem.Poll()
+	if got := render(); got != "<section>Welcome back Ann</section>" {
+		t.Errorf("Render() after editing the root template = %q", got)
+	}
+
+	if len(changed) != 2 || changed[0] != "card" || changed[1] != "page" {
+		t.Errorf("OnChange called with %v, want [card page]", changed)
+	}
+}
@@ -0,0 +1,89 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/Notifuse/liquidgo/liquid"
+	"github.com/Notifuse/liquidgo/liquid/tags"
+)
+
+func TestNodeSpans(t *testing.T) {
+	env := liquid.NewEnvironment()
+	tags.RegisterStandardTags(env)
+	source := "<p>\n  {%- if user -%}\n    {{ user.name }}\n  {%- endif %}\n</p>{% raw %}{{ x }}{% endraw %}"
+	tmpl, err := liquid.ParseTemplate(source, &liquid.TemplateOptions{Environment: env})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+
+	nodes := tmpl.Root().Nodelist()
+	ifTag := nodes[1].(*tags.IfTag)
+	if got, want := ifTag.Span().Source(source), "{%- if user -%}\n    {{ user.name }}\n  {%- endif %}"; got != want {
+		t.Errorf("if Span().Source() = %q, want %q", got, want)
+	}
+	if got, want := ifTag.Span().String(), "line 2, col 3"; got != want {
+		t.Errorf("if Span() = %s, want %s", got, want)
+	}
+	var variable *liquid.Variable
+	for _, node := range ifTag.Nodelist() {
+		if v, ok := node.(*liquid.Variable); ok {
+			variable = v
+		}
+	}
+	if variable == nil {
+		t.Fatalf("if nodelist = %v, want a variable", ifTag.Nodelist())
+	}
+	if got, want := variable.Span(), (liquid.Span{
+		Start: liquid.Position{Offset: 26, Line: 3, Column: 5},
+		End:   liquid.Position{Offset: 41, Line: 3, Column: 20},
+	}); got != want {
+		t.Errorf("variable Span() = %#v, want %#v", got, want)
+	}
+	if got, want := nodes[len(nodes)-1].(*tags.RawTag).Span().Source(source), "{% raw %}{{ x }}{% endraw %}"; got != want {
+		t.Errorf("raw Span().Source() = %q, want %q", got, want)
+	}
+
+	// Spans are kept by compiled templates
+	data, err := tmpl.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+	loaded, err := liquid.LoadCompiled(data, &liquid.TemplateOptions{Environment: env})
+	if err != nil {
+		t.Fatalf("LoadCompiled() error = %v", err)
+	}
+	if got := loaded.Root().Nodelist()[1].(*tags.IfTag).Span(); got != ifTag.Span() {
+		t.Errorf("compiled if Span() = %#v, want %#v", got, ifTag.Span())
+	}
+}
+
+func TestErrorSpans(t *testing.T) {
+	env := liquid.NewEnvironment()
+	tags.RegisterStandardTags(env)
+
+	_, err := liquid.ParseTemplate("{% if a %}\n  {% for %}{% endfor %}{% endif %}", &liquid.TemplateOptions{Environment: env})
+	syntaxErr, ok := err.(*liquid.SyntaxError)
+	if !ok {
+		t.Fatalf("ParseTemplate() error = %v, want a SyntaxError", err)
+	}
+	if span := syntaxErr.Err.Span; span == nil || span.String() != "line 2, col 3" {
+		t.Errorf("syntax error Span = %v, want line 2, col 3", span)
+	}
+
+	tmpl, err := liquid.ParseTemplate("{% if true %}\n ok {{ errors.argument_error }}{% endif %}", &liquid.TemplateOptions{Environment: env})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	ctx := liquid.BuildContext(liquid.ContextConfig{
+		Environment:        env,
+		StaticEnvironments: []map[string]interface{}{{"errors": NewErrorDrop()}},
+	})
+	tmpl.Render(ctx, &liquid.RenderOptions{})
+	errors := tmpl.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("Errors() = %v, want 1", errors)
+	}
+	if span := errors[0].(liquid.LiquidError).GetError().Span; span == nil || span.String() != "line 2, col 5" {
+		t.Errorf("render error Span = %v, want line 2, col 5", span)
+	}
+}
@@ -780,3 +780,29 @@ func TestNilComparisons(t *testing.T) {
 		})
 	}
 }
+
+func TestExecuteReportsAssignsWithoutPersistingThem(t *testing.T) {
+	env := liquid.NewEnvironment()
+	tags.RegisterStandardTags(env)
+	tmpl, err := liquid.ParseTemplate(`{% assign foo = 'bar' %}{% capture greeting %}hi{% endcapture %}{{ foo }}`, &liquid.TemplateOptions{Environment: env})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+
+	result, err := tmpl.Execute(nil, nil)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result.Output != "bar" {
+		t.Errorf("Expected 'bar', got %q", result.Output)
+	}
+	if result.Assigns["foo"] != "bar" || result.Assigns["greeting"] != "hi" {
+		t.Errorf("Expected foo and greeting in assigns, got %v", result.Assigns)
+	}
+	if result.ResourceUsage.AssignScore == 0 {
+		t.Error("Expected an assign score")
+	}
+	if len(tmpl.InstanceAssigns()) != 0 {
+		t.Errorf("Expected template instance assigns to be untouched, got %v", tmpl.InstanceAssigns())
+	}
+}
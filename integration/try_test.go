@@ -0,0 +1,40 @@
+package integration
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Notifuse/liquidgo/liquid"
+	"github.com/Notifuse/liquidgo/liquid/tags"
+)
+
+var recommendationPartials = map[string]string{
+	"recommendations": `{% for product in products %}{{ product.title | shout }}{% endfor %}`,
+}
+
+func TestTryTagKeepsRenderBangRendering(t *testing.T) {
+	env := newTagEnvironment(NewStubFileSystem(recommendationPartials), tags.RegisterTryTag)
+	source := "Hi {{ name }}!\n{% try %}{% include 'recommendations' %}{% rescue err %}<!-- {{ err.type }} in {{ err.template_name }} -->{% endtry %}\nBye"
+	tmpl := parseTemplate(t, env, source)
+
+	assigns := map[string]interface{}{
+		"name":     "Ann",
+		"products": []interface{}{map[string]interface{}{"title": "Tea"}},
+	}
+	got := tmpl.RenderBang(assigns, &liquid.RenderOptions{StrictFilters: true})
+	if want := "Hi Ann!\n<!-- UndefinedFilter in recommendations -->\nBye"; got != want {
+		t.Errorf("RenderBang() = %q, want %q", got, want)
+	}
+	if errors := tmpl.Errors(); len(errors) != 1 || !strings.Contains(errors[0].Error(), "undefined filter shout") {
+		t.Errorf("Errors() = %v", errors)
+	}
+}
+
+func TestTryTagStaticAnalysis(t *testing.T) {
+	env := newTagEnvironment(NewStubFileSystem(recommendationPartials), tags.RegisterTryTag)
+	tmpl := parseTemplate(t, env, `{% try %}{{ order.total }}{% rescue err %}{{ err.message }} {{ support.email }}{% endtry %}`)
+	if got, want := liquid.AnalyzeVariables(tmpl).Globals(), []string{"order.total", "support.email"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Globals() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,167 @@
+package integration
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Notifuse/liquidgo/liquid"
+	"github.com/Notifuse/liquidgo/liquid/tags"
+)
+
+func analyzeTemplate(t *testing.T, source string, partials map[string]string) *liquid.VariableAnalysis {
+	t.Helper()
+	env := liquid.NewEnvironment()
+	tags.RegisterStandardTags(env)
+	if partials != nil {
+		env.SetFileSystem(NewStubFileSystem(partials))
+	}
+	tmpl, err := liquid.ParseTemplate(source, &liquid.TemplateOptions{Environment: env, LineNumbers: true})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	return liquid.AnalyzeVariables(tmpl)
+}
+
+type analyzedReference struct {
+	name, path string
+	kind       liquid.VariableKind
+	global     bool
+	line       int
+	template   string
+}
+
+func assertReferences(t *testing.T, analysis *liquid.VariableAnalysis, want []analyzedReference) {
+	t.Helper()
+	got := make([]analyzedReference, 0, len(analysis.References))
+	for _, ref := range analysis.References {
+		got = append(got, analyzedReference{ref.Name, ref.Path, ref.Kind, ref.Global, ref.LineNumber, ref.Template})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("References =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestAnalyzeVariablesForLoopAlias(t *testing.T) {
+	analysis := analyzeTemplate(t, `{% for item in order.items limit: max %}
+{{ item.price }} {{ forloop.index }}
+{% else %}{{ item }}{% endfor %}`, nil)
+
+	assertReferences(t, analysis, []analyzedReference{
+		{"order.items", "order.items", liquid.GlobalVariable, true, 1, ""},
+		{"max", "max", liquid.GlobalVariable, true, 1, ""},
+		{"item.price", "order.items[].price", liquid.LoopVariable, true, 2, ""},
+		{"forloop.index", "forloop.index", liquid.LoopVariable, false, 2, ""},
+		{"item", "item", liquid.GlobalVariable, true, 3, ""},
+	})
+	want := []string{"item", "max", "order.items", "order.items[].price"}
+	if got := analysis.Globals(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Globals() = %v, want %v", got, want)
+	}
+}
+
+func TestAnalyzeVariablesNestedLoopUsesOuterCollection(t *testing.T) {
+	analysis := analyzeTemplate(t, `{% for link in menu.links %}{% for link in link.links %}{{ link.title }}{% endfor %}{% endfor %}`, nil)
+
+	assertReferences(t, analysis, []analyzedReference{
+		{"menu.links", "menu.links", liquid.GlobalVariable, true, 1, ""},
+		{"link.links", "menu.links[].links", liquid.LoopVariable, true, 1, ""},
+		{"link.title", "menu.links[].links[].title", liquid.LoopVariable, true, 1, ""},
+	})
+}
+
+func TestAnalyzeVariablesAssignAndCapture(t *testing.T) {
+	analysis := analyzeTemplate(t, `{% assign c = contact %}{% assign total = total | plus: 1 %}
+{% capture greeting %}Hi {{ c.first_name }}{% endcapture %}
+{% if total > 1 %}{{ greeting }}{% endif %}{% increment visits %}{{ visits }}`, nil)
+
+	assertReferences(t, analysis, []analyzedReference{
+		{"contact", "contact", liquid.GlobalVariable, true, 1, ""},
+		{"total", "total", liquid.GlobalVariable, true, 1, ""},
+		{"c.first_name", "contact.first_name", liquid.AssignedVariable, true, 2, ""},
+		{"total", "total", liquid.AssignedVariable, false, 3, ""},
+		{"greeting", "greeting", liquid.AssignedVariable, false, 3, ""},
+		{"visits", "visits", liquid.AssignedVariable, false, 3, ""},
+	})
+}
+
+func TestAnalyzeVariablesAssignInsideLoopIsVisibleAfterIt(t *testing.T) {
+	analysis := analyzeTemplate(t, `{% for p in products %}{% assign last = p.title %}{% endfor %}{{ last }}`, nil)
+
+	refs := analysis.OfKind(liquid.AssignedVariable)
+	if len(refs) != 1 || refs[0].Name != "last" {
+		t.Errorf("OfKind(AssignedVariable) = %+v, want the lookup of last", refs)
+	}
+}
+
+func TestAnalyzeVariablesCaseAndTablerow(t *testing.T) {
+	analysis := analyzeTemplate(t, `{% case shipping.method %}{% when fast %}{{ eta }}{% endcase %}{% tablerow row in rows cols: columns %}{{ row.name }}{{ tablerowloop.col }}{% endtablerow %}`, nil)
+
+	assertReferences(t, analysis, []analyzedReference{
+		{"shipping.method", "shipping.method", liquid.GlobalVariable, true, 1, ""},
+		{"fast", "fast", liquid.GlobalVariable, true, 1, ""},
+		{"eta", "eta", liquid.GlobalVariable, true, 1, ""},
+		{"rows", "rows", liquid.GlobalVariable, true, 1, ""},
+		{"columns", "columns", liquid.GlobalVariable, true, 1, ""},
+		{"row.name", "rows[].name", liquid.LoopVariable, true, 1, ""},
+		{"tablerowloop.col", "tablerowloop.col", liquid.LoopVariable, false, 1, ""},
+	})
+}
+
+func TestAnalyzeVariablesRenderScoping(t *testing.T) {
+	partials := map[string]string{
+		"card": "{{ product.title }} {{ size }} {{ forloop.index }}\n{% assign hidden = 1 %}{{ shop.name }}",
+	}
+	analysis := analyzeTemplate(t, `{% render 'card' for collection.products as product, size: image_size %}{{ hidden }}`, partials)
+
+	assertReferences(t, analysis, []analyzedReference{
+		{"collection.products", "collection.products", liquid.GlobalVariable, true, 1, ""},
+		{"image_size", "image_size", liquid.GlobalVariable, true, 1, ""},
+		{"product.title", "collection.products[].title", liquid.PartialVariable, true, 1, "card"},
+		{"size", "image_size", liquid.PartialVariable, true, 1, "card"},
+		{"forloop.index", "forloop.index", liquid.PartialVariable, false, 1, "card"},
+		{"shop.name", "shop.name", liquid.GlobalVariable, true, 2, "card"},
+		{"hidden", "hidden", liquid.GlobalVariable, true, 1, ""},
+	})
+	if len(analysis.Errors) != 0 {
+		t.Errorf("Errors = %v, want none", analysis.Errors)
+	}
+}
+
+func TestAnalyzeVariablesIncludeBleedThrough(t *testing.T) {
+	partials := map[string]string{
+		"header": "{% assign title = page.title %}{{ header.logo }}{{ cart.count }}",
+	}
+	analysis := analyzeTemplate(t, `{% include 'header' with settings %}{{ title }}`, partials)
+
+	assertReferences(t, analysis, []analyzedReference{
+		{"settings", "settings", liquid.GlobalVariable, true, 1, ""},
+		{"page.title", "page.title", liquid.GlobalVariable, true, 1, "header"},
+		{"header.logo", "settings.logo", liquid.PartialVariable, true, 1, "header"},
+		{"cart.count", "cart.count", liquid.GlobalVariable, true, 1, "header"},
+		{"title", "page.title", liquid.AssignedVariable, true, 1, ""},
+	})
+}
+
+func TestAnalyzeVariablesMissingPartialAndRecursion(t *testing.T) {
+	partials := map[string]string{
+		"tree": "{{ node.name }}{% render 'tree' for node.children as node %}",
+	}
+	analysis := analyzeTemplate(t, `{% render 'tree' with root as node %}{% render 'missing' %}`, partials)
+
+	assertReferences(t, analysis, []analyzedReference{
+		{"root", "root", liquid.GlobalVariable, true, 1, ""},
+		{"node.name", "root.name", liquid.PartialVariable, true, 1, "tree"},
+		{"node.children", "root.children", liquid.PartialVariable, true, 1, "tree"},
+	})
+	if len(analysis.Errors) != 1 {
+		t.Errorf("Errors = %v, want the missing partial", analysis.Errors)
+	}
+}
+
+func TestAnalyzeVariablesWithoutFileSystem(t *testing.T) {
+	analysis := analyzeTemplate(t, `{% render 'card' with product %}`, nil)
+
+	assertReferences(t, analysis, []analyzedReference{
+		{"product", "product", liquid.GlobalVariable, true, 1, ""},
+	})
+}
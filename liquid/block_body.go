@@ -39,6 +39,9 @@ func (bb *BlockBody) Nodelist() []interface{} {
 // Parse parses tokens into the block body.
 func (bb *BlockBody) Parse(tokenizer *Tokenizer, parseContext ParseContextInterface, unknownTagHandler func(string, string) bool) error {
 	parseContext.SetLineNumber(tokenizer.LineNumber())
+	if recovery := recoveryOf(parseContext); recovery != nil {
+		unknownTagHandler = recovery.handler(tokenizer, unknownTagHandler)
+	}
 
 	if tokenizer.ForLiquidTag() {
 		return bb.parseForLiquidTag(tokenizer, parseContext, unknownTagHandler)
@@ -47,6 +50,7 @@ func (bb *BlockBody) Parse(tokenizer *Tokenizer, parseContext ParseContextInterf
 }
 
 func (bb *BlockBody) parseForLiquidTag(tokenizer *Tokenizer, parseContext ParseContextInterface, unknownTagHandler func(string, string) bool) error {
+	recovery := recoveryOf(parseContext)
 	for {
 		token := tokenizer.Shift()
 		if token == "" {
@@ -56,6 +60,8 @@ func (bb *BlockBody) parseForLiquidTag(tokenizer *Tokenizer, parseContext ParseC
 		if token == "" || blockBodyWhitespaceOrNothing.MatchString(token) {
 			continue
 		}
+		span := tokenizer.Span()
+		setParseSpan(parseContext, span)
 
 		matches := blockBodyLiquidTagToken.FindStringSubmatch(token)
 		if len(matches) == 0 {
@@ -76,6 +82,7 @@ func (bb *BlockBody) parseForLiquidTag(tokenizer *Tokenizer, parseContext ParseC
 				lineNum := *parseContext.LineNumber() - 1
 				parseContext.SetLineNumber(&lineNum)
 			}
+			setParseSpan(parseContext, markupSpan(span, strings.TrimLeft(token, " \t\r"), markup))
 			bb.parseLiquidTag(markup, parseContext)
 			continue
 		}
@@ -100,6 +107,7 @@ func (bb *BlockBody) parseForLiquidTag(tokenizer *Tokenizer, parseContext ParseC
 		// Create tag using constructor if available
 		// TagConstructor is defined in tags package, so we use reflection to call it
 		var tag interface{}
+		invalid := false
 
 		// Try to call tagClass as a function using reflection
 		tagClassValue := reflect.ValueOf(tagClass)
@@ -118,7 +126,10 @@ func (bb *BlockBody) parseForLiquidTag(tokenizer *Tokenizer, parseContext ParseC
 					defer func() {
 						if r := recover(); r != nil {
 							if e, ok := r.(error); ok {
-								if parseContext.ErrorMode() == "warn" {
+								if recovery != nil {
+									recovery.add(CodeInvalidTag, DiagnosticError, e, span)
+									invalid = true
+								} else if parseContext.ErrorMode() == "warn" {
 									parseContext.AddWarning(e)
 									tag = nil
 								} else {
@@ -134,7 +145,10 @@ func (bb *BlockBody) parseForLiquidTag(tokenizer *Tokenizer, parseContext ParseC
 						// Check for error
 						if !results[1].IsNil() {
 							if err, ok := results[1].Interface().(error); ok {
-								if parseContext.ErrorMode() == "warn" {
+								if recovery != nil {
+									recovery.add(CodeInvalidTag, DiagnosticError, err, span)
+									invalid = true
+								} else if parseContext.ErrorMode() == "warn" {
 									parseContext.AddWarning(err)
 									tag = nil
 								} else {
@@ -149,6 +163,14 @@ func (bb *BlockBody) parseForLiquidTag(tokenizer *Tokenizer, parseContext ParseC
 			}
 		}
 
+		// If tag creation failed in ParseAll, parse the body of a block tag
+		if invalid {
+			if !hasEndTag(tokenizer, tagName) {
+				continue
+			}
+			tag = &invalidBlock{NewBlock(tagName, markup, parseContext)}
+		}
+
 		// If tag creation failed in warn mode, skip it (liquid tags are just lines of code)
 		if tag == nil && parseContext.ErrorMode() == "warn" {
 			continue
@@ -161,13 +183,26 @@ func (bb *BlockBody) parseForLiquidTag(tokenizer *Tokenizer, parseContext ParseC
 
 		// Parse the tag if it has a Parse method
 		if parseable, ok := tag.(interface{ Parse(*Tokenizer) error }); ok {
-			err := parseable.Parse(tokenizer)
+			var err error
+			if recovery != nil {
+				err = recovery.parseTag(tagName, parseable, tokenizer, span)
+			} else {
+				err = parseable.Parse(tokenizer)
+			}
+			// Block tags span up to their end tag
+			if spanned, ok := tag.(interface{ extendSpan(Position) }); ok {
+				spanned.extendSpan(tokenizer.Span().End)
+			}
 			if err != nil {
-				if parseContext.ErrorMode() == "warn" {
+				switch {
+				case recovery != nil:
+					recovery.addTagError(err, tokenizer, span)
+				case parseContext.ErrorMode() == "warn":
 					parseContext.AddWarning(err)
 					continue
+				default:
+					return err
 				}
-				return err
 			}
 		}
 
@@ -185,11 +220,20 @@ func (bb *BlockBody) parseForLiquidTag(tokenizer *Tokenizer, parseContext ParseC
 }
 
 func (bb *BlockBody) parseForDocument(tokenizer *Tokenizer, parseContext ParseContextInterface, unknownTagHandler func(string, string) bool) error {
+	if tokenizer.trace != nil {
+		tokenizer.trace.depth++
+		defer func() { tokenizer.trace.depth-- }()
+	}
+
+	recovery := recoveryOf(parseContext)
 	for {
 		token := tokenizer.Shift()
 		if token == "" {
 			break
 		}
+		tokenizer.traceToken()
+		span := tokenizer.Span()
+		setParseSpan(parseContext, span)
 
 		if token == "" {
 			continue
@@ -223,6 +267,7 @@ func (bb *BlockBody) parseForDocument(tokenizer *Tokenizer, parseContext ParseCo
 
 			if tagName == "liquid" {
 				// Handle liquid tag specially
+				setParseSpan(parseContext, markupSpan(span, token, markup))
 				bb.parseLiquidTag(markup, parseContext)
 				continue
 			}
@@ -247,6 +292,7 @@ func (bb *BlockBody) parseForDocument(tokenizer *Tokenizer, parseContext ParseCo
 			// Create tag using constructor if available
 			// TagConstructor is defined in tags package, so we use reflection to call it
 			var tag interface{}
+			invalid := false
 
 			// Try to call tagClass as a function using reflection
 			tagClassValue := reflect.ValueOf(tagClass)
@@ -265,7 +311,10 @@ func (bb *BlockBody) parseForDocument(tokenizer *Tokenizer, parseContext ParseCo
 						defer func() {
 							if r := recover(); r != nil {
 								if e, ok := r.(error); ok {
-									if parseContext.ErrorMode() == "warn" {
+									if recovery != nil {
+										recovery.add(CodeInvalidTag, DiagnosticError, e, span)
+										invalid = true
+									} else if parseContext.ErrorMode() == "warn" {
 										parseContext.AddWarning(e)
 										tag = nil
 									} else {
@@ -281,7 +330,10 @@ func (bb *BlockBody) parseForDocument(tokenizer *Tokenizer, parseContext ParseCo
 							// Check for error
 							if !results[1].IsNil() {
 								if err, ok := results[1].Interface().(error); ok {
-									if parseContext.ErrorMode() == "warn" {
+									if recovery != nil {
+										recovery.add(CodeInvalidTag, DiagnosticError, err, span)
+										invalid = true
+									} else if parseContext.ErrorMode() == "warn" {
 										parseContext.AddWarning(err)
 										tag = nil
 									} else {
@@ -296,6 +348,14 @@ func (bb *BlockBody) parseForDocument(tokenizer *Tokenizer, parseContext ParseCo
 				}
 			}
 
+			// If tag creation failed in ParseAll, parse the body of a block tag
+			if invalid {
+				if !hasEndTag(tokenizer, tagName) {
+					continue
+				}
+				tag = &invalidBlock{NewBlock(tagName, markup, parseContext)}
+			}
+
 			// If tag creation failed in warn mode, treat as text
 			if tag == nil && parseContext.ErrorMode() == "warn" {
 				bb.nodelist = append(bb.nodelist, token)
@@ -309,15 +369,28 @@ func (bb *BlockBody) parseForDocument(tokenizer *Tokenizer, parseContext ParseCo
 
 			// Parse the tag if it has a Parse method
 			if parseable, ok := tag.(interface{ Parse(*Tokenizer) error }); ok {
-				err := parseable.Parse(tokenizer)
+				var err error
+				if recovery != nil {
+					err = recovery.parseTag(tagName, parseable, tokenizer, span)
+				} else {
+					err = parseable.Parse(tokenizer)
+				}
+				// Block tags span up to their end tag
+				if spanned, ok := tag.(interface{ extendSpan(Position) }); ok {
+					spanned.extendSpan(tokenizer.Span().End)
+				}
 				if err != nil {
-					if parseContext.ErrorMode() == "warn" {
+					switch {
+					case recovery != nil:
+						recovery.addTagError(err, tokenizer, span)
+					case parseContext.ErrorMode() == "warn":
 						parseContext.AddWarning(err)
 						// If parsing failed, treat as text
 						bb.nodelist = append(bb.nodelist, token)
 						continue
+					default:
+						return err
 					}
-					return err
 				}
 			}
 
@@ -329,9 +402,10 @@ func (bb *BlockBody) parseForDocument(tokenizer *Tokenizer, parseContext ParseCo
 			bb.nodelist = append(bb.nodelist, tag)
 		} else if strings.HasPrefix(token, blockBodyVARSTART) {
 			bb.whitespaceHandler(token, parseContext)
-			variable := bb.createVariable(token, parseContext)
-			bb.nodelist = append(bb.nodelist, variable)
-			bb.blank = false
+			if variable := bb.createVariable(token, parseContext); variable != nil {
+				bb.nodelist = append(bb.nodelist, variable)
+				bb.blank = false
+			}
 		} else {
 			if parseContext.TrimWhitespace() {
 				token = strings.TrimLeft(token, " \t\n\r")
@@ -399,6 +473,10 @@ func (bb *BlockBody) createVariable(token string, parseContext ParseContextInter
 		}()
 
 		if err != nil {
+			if recovery := recoveryOf(parseContext); recovery != nil {
+				recovery.add(CodeInvalidOutput, DiagnosticError, err, recovery.current())
+				return nil
+			}
 			if parseContext.ErrorMode() == "warn" {
 				parseContext.AddWarning(err)
 				return token
@@ -409,8 +487,12 @@ func (bb *BlockBody) createVariable(token string, parseContext ParseContextInter
 	}
 
 	// Missing variable terminator - raise error
-	raiseMissingVariableTerminator(token, parseContext)
-	return nil // Will never be reached, but needed for type checking
+	err := missingVariableTerminator(token, parseContext)
+	if recovery := recoveryOf(parseContext); recovery != nil {
+		recovery.add(CodeUnterminatedOutput, DiagnosticError, err, recovery.current())
+		return nil
+	}
+	panic(err)
 }
 
 // Blank returns true if the block body is blank.
@@ -420,13 +502,13 @@ func (bb *BlockBody) Blank() bool {
 
 // Render renders the block body.
 func (bb *BlockBody) Render(context TagContext) string {
-	output := ""
-	bb.RenderToOutputBuffer(context, &output)
-	return output
+	output := NewOutputBuffer()
+	bb.RenderToOutputBuffer(context, output)
+	return output.String()
 }
 
 // RenderToOutputBuffer renders the block body to the output buffer.
-func (bb *BlockBody) RenderToOutputBuffer(context TagContext, output *string) {
+func (bb *BlockBody) RenderToOutputBuffer(context TagContext, output *OutputBuffer) {
 	ctx, hasProfiler := context.(*Context)
 	profiler := hasProfiler && ctx.Profiler() != nil
 
@@ -438,12 +520,27 @@ func (bb *BlockBody) RenderToOutputBuffer(context TagContext, output *string) {
 		}
 	}
 
+	// The node rendering this body is restored after each child, so that its
+	// own errors get its span
+	var parent interface{}
+	if ctx != nil {
+		parent = ctx.node
+	}
+
 	for _, node := range bb.nodelist {
+		if _, isString := node.(string); !isString && ctx != nil {
+			ctx.node = node
+			// Stop before the next variable or tag once the render's context.Context is done
+			if ctx.done != nil {
+				ctx.CheckCanceled(nodeLineNumber(node))
+			}
+		}
+
 		// Optimization: Use type switches instead of reflection for better performance
 		switch n := node.(type) {
 		case string:
 			// Raw strings are not profiled
-			*output += n
+			output.WriteString(n)
 
 		case *Variable:
 			// Handle variables
@@ -456,6 +553,9 @@ func (bb *BlockBody) RenderToOutputBuffer(context TagContext, output *string) {
 			} else {
 				n.RenderToOutputBuffer(context, output)
 			}
+			if ctx != nil {
+				ctx.node = parent
+			}
 			// Check for interrupts
 			if ctx != nil && ctx.Interrupt() {
 				return
@@ -465,6 +565,9 @@ func (bb *BlockBody) RenderToOutputBuffer(context TagContext, output *string) {
 			// For other node types, use interface-based dispatch
 			// This is much faster than reflection and handles all tag types
 			bb.renderNodeOptimized(node, context, output, profiler, ctx)
+			if ctx != nil {
+				ctx.node = parent
+			}
 
 			// Check for interrupts
 			if ctx != nil && ctx.Interrupt() {
@@ -476,16 +579,24 @@ func (bb *BlockBody) RenderToOutputBuffer(context TagContext, output *string) {
 		if ctx != nil {
 			rl := ctx.ResourceLimits()
 			if rl != nil {
-				rl.IncrementWriteScore(*output)
+				rl.IncrementWriteScore(output.Len())
 			}
 		}
 	}
 }
 
+// nodeLineNumber returns the line number of a node, or nil if it has none.
+func nodeLineNumber(node interface{}) *int {
+	if n, ok := node.(interface{ LineNumber() *int }); ok {
+		return n.LineNumber()
+	}
+	return nil
+}
+
 // renderNodeOptimized handles rendering of non-string, non-variable nodes with minimal reflection.
 // Optimization: This reduces reflection usage by 90% compared to the old implementation.
 // Uses method override detection to handle tags that only override Render() vs RenderToOutputBuffer().
-func (bb *BlockBody) renderNodeOptimized(node interface{}, context TagContext, output *string, profiler bool, ctx *Context) {
+func (bb *BlockBody) renderNodeOptimized(node interface{}, context TagContext, output *OutputBuffer, profiler bool, ctx *Context) {
 	// Get metadata for profiling if needed
 	var code string
 	var lineNumber *int
@@ -501,7 +612,7 @@ func (bb *BlockBody) renderNodeOptimized(node interface{}, context TagContext, o
 
 	// Check if node implements RenderToOutputBuffer
 	type Renderable interface {
-		RenderToOutputBuffer(TagContext, *string)
+		RenderToOutputBuffer(TagContext, *OutputBuffer)
 	}
 
 	if renderable, ok := node.(Renderable); ok {
@@ -588,10 +699,10 @@ func (bb *BlockBody) renderNodeOptimized(node interface{}, context TagContext, o
 						if renderResult != "" {
 							if profiler {
 								ctx.Profiler().ProfileNode(ctx.TemplateName(), code, lineNumber, func() {
-									*output += renderResult
+									output.WriteString(renderResult)
 								})
 							} else {
-								*output += renderResult
+								output.WriteString(renderResult)
 							}
 							return
 						}
@@ -637,8 +748,7 @@ func (bb *BlockBody) parseLiquidTag(markup string, parseContext ParseContextInte
 	lineNumber := parseContext.LineNumber()
 	liquidTagTokenizer := parseContext.NewTokenizer(markup, lineNumber != nil, lineNumber, true)
 
-	// Recursively parse using parseForLiquidTag
-	if err := bb.parseForLiquidTag(liquidTagTokenizer, parseContext, func(endTagName, _endTagMarkup string) bool {
+	unknownTagHandler := func(endTagName, _endTagMarkup string) bool {
 		if endTagName != "" {
 			// Unknown tag in liquid tag - raise error
 			// This would call Block.raise_unknown_tag in Ruby
@@ -646,13 +756,21 @@ func (bb *BlockBody) parseLiquidTag(markup string, parseContext ParseContextInte
 			panic(NewSyntaxError("Unknown tag '" + endTagName + "' in liquid tag"))
 		}
 		return true
-	}); err != nil {
+	}
+	// The lines of the markup are located in the template from its span
+	liquidTagTokenizer.base = parseSpan(parseContext).Start
+	if recovery := recoveryOf(parseContext); recovery != nil {
+		unknownTagHandler = recovery.handler(liquidTagTokenizer, unknownTagHandler)
+	}
+
+	// Recursively parse using parseForLiquidTag
+	if err := bb.parseForLiquidTag(liquidTagTokenizer, parseContext, unknownTagHandler); err != nil {
 		panic(err)
 	}
 }
 
-// raiseMissingVariableTerminator raises an error for missing variable terminator.
-func raiseMissingVariableTerminator(token string, parseContext ParseContextInterface) {
+// missingVariableTerminator returns the error for a missing variable terminator.
+func missingVariableTerminator(token string, parseContext ParseContextInterface) *SyntaxError {
 	var locale *I18n
 	var msg string
 
@@ -677,5 +795,6 @@ func raiseMissingVariableTerminator(token string, parseContext ParseContextInter
 	if parseContext.LineNumber() != nil {
 		err.Err.LineNumber = parseContext.LineNumber()
 	}
-	panic(err)
+	setErrorSpan(err.Err, parseSpan(parseContext))
+	return err
 }
@@ -0,0 +1,688 @@
+package liquid
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+)
+
+// CompiledVersion is the version of the format written by Template.MarshalBinary.
+// LoadCompiled rejects data written with any other version, so bump it whenever
+// the encoding of a node changes.
+const CompiledVersion = 3
+
+// compiledMagic starts every compiled template.
+const compiledMagic = "LQGC"
+
+// maxCompiledDepth bounds the nesting of decoded values so that corrupt data
+// cannot exhaust the stack.
+const maxCompiledDepth = 1000
+
+// Value kinds used by NodeEncoder.Encode.
+const (
+	compiledNil byte = iota
+	compiledFalse
+	compiledTrue
+	compiledInt
+	compiledFloat
+	compiledString
+	compiledVariableLookup
+	compiledRangeLookup
+	compiledRange
+	compiledMethodLiteral
+	compiledVariable
+	compiledCondition
+	compiledElseCondition
+	compiledArray
+	compiledMap
+	compiledBlockBody
+	compiledTag
+	compiledArrayLiteral
+	compiledHashLiteral
+	compiledOperation
+)
+
+// newCompiledTemplateError creates a CompiledTemplateError with a formatted message.
+func newCompiledTemplateError(format string, args ...interface{}) *CompiledTemplateError {
+	return NewCompiledTemplateError(fmt.Sprintf(format, args...))
+}
+
+// MarshalableNode is implemented by tags that can be stored in compiled templates.
+// A tag registered with Environment.RegisterTag opts into serialization by
+// implementing MarshalNode and registering the matching TagDecoder with
+// Environment.RegisterTagDecoder.
+type MarshalableNode interface {
+	MarshalNode(enc *NodeEncoder)
+}
+
+// TagDecoder rebuilds a tag from the data its MarshalNode method wrote.
+type TagDecoder func(dec *NodeDecoder) (interface{}, error)
+
+// NodeEncoder writes parse tree nodes in the compiled template format.
+// Errors are sticky: once a write fails, later writes are ignored and Err
+// reports the first error.
+type NodeEncoder struct {
+	buf        []byte
+	err        error
+	skipMarkup bool // Leave out tag and variable markup and spans, for Format
+}
+
+// Err returns the first error encountered while encoding, if any.
+func (enc *NodeEncoder) Err() error {
+	return enc.err
+}
+
+// Fail records err as the encoding error unless one was already recorded.
+func (enc *NodeEncoder) Fail(err error) {
+	if enc.err == nil {
+		enc.err = err
+	}
+}
+
+// WriteString writes a string.
+func (enc *NodeEncoder) WriteString(s string) {
+	enc.buf = binary.AppendUvarint(enc.buf, uint64(len(s)))
+	enc.buf = append(enc.buf, s...)
+}
+
+// WriteInt writes an integer.
+func (enc *NodeEncoder) WriteInt(i int) {
+	enc.buf = binary.AppendVarint(enc.buf, int64(i))
+}
+
+// WriteBool writes a boolean.
+func (enc *NodeEncoder) WriteBool(b bool) {
+	if b {
+		enc.buf = append(enc.buf, 1)
+	} else {
+		enc.buf = append(enc.buf, 0)
+	}
+}
+
+// Encode writes a parse tree value: an expression (literal, VariableLookup,
+// RangeLookup, Range, MethodLiteral, ArrayLiteral, HashLiteral, Operation), a
+// Variable, a Condition, a BlockBody, a tag implementing MarshalableNode, or an
+// array or map of those.
+func (enc *NodeEncoder) Encode(v interface{}) {
+	if enc.err != nil {
+		return
+	}
+	// Typed nil pointers (e.g. a missing else block) encode like nil
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		v = nil
+	}
+
+	switch n := v.(type) {
+	case nil:
+		enc.buf = append(enc.buf, compiledNil)
+	case bool:
+		if n {
+			enc.buf = append(enc.buf, compiledTrue)
+		} else {
+			enc.buf = append(enc.buf, compiledFalse)
+		}
+	case int:
+		enc.buf = append(enc.buf, compiledInt)
+		enc.WriteInt(n)
+	case float64:
+		enc.buf = append(enc.buf, compiledFloat)
+		enc.buf = binary.LittleEndian.AppendUint64(enc.buf, math.Float64bits(n))
+	case string:
+		enc.buf = append(enc.buf, compiledString)
+		enc.WriteString(n)
+	case *VariableLookup:
+		enc.buf = append(enc.buf, compiledVariableLookup)
+		enc.Encode(n.name)
+		enc.Encode(n.lookups)
+		enc.WriteInt(int(n.commandFlags))
+	case *RangeLookup:
+		enc.buf = append(enc.buf, compiledRangeLookup)
+		enc.Encode(n.startObj)
+		enc.Encode(n.endObj)
+	case *Range:
+		enc.buf = append(enc.buf, compiledRange)
+		enc.WriteInt(n.Start)
+		enc.WriteInt(n.End)
+	case *MethodLiteral:
+		enc.buf = append(enc.buf, compiledMethodLiteral)
+		enc.WriteString(n.MethodName)
+		enc.WriteString(n.ToString)
+	case *ArrayLiteral:
+		enc.buf = append(enc.buf, compiledArrayLiteral)
+		enc.Encode(n.elements)
+	case *HashLiteral:
+		enc.buf = append(enc.buf, compiledHashLiteral)
+		enc.WriteInt(len(n.keys))
+		for i, key := range n.keys {
+			enc.WriteString(key)
+			enc.Encode(n.values[i])
+		}
+	case *Operation:
+		enc.buf = append(enc.buf, compiledOperation)
+		enc.WriteString(n.operator)
+		enc.Encode(n.operands)
+	case *Variable:
+		enc.buf = append(enc.buf, compiledVariable)
+		enc.Encode(n.name)
+		enc.writeMarkup(n.markup)
+		enc.writeLineNumber(n.lineNumber)
+		enc.writeSpan(n.span)
+		enc.WriteInt(len(n.filters))
+		for _, filter := range n.filters {
+			enc.Encode(filter)
+		}
+	case *Condition:
+		enc.buf = append(enc.buf, compiledCondition)
+		enc.encodeCondition(n)
+	case *ElseCondition:
+		enc.buf = append(enc.buf, compiledElseCondition)
+		enc.encodeCondition(n.Condition)
+	case []interface{}:
+		enc.buf = append(enc.buf, compiledArray)
+		enc.WriteInt(len(n))
+		for _, item := range n {
+			enc.Encode(item)
+		}
+	case map[string]interface{}:
+		enc.buf = append(enc.buf, compiledMap)
+		enc.WriteInt(len(n))
+		// Sort keys so the same template always compiles to the same bytes
+		keys := make([]string, 0, len(n))
+		for key := range n {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			enc.WriteString(key)
+			enc.Encode(n[key])
+		}
+	case *BlockBody:
+		enc.buf = append(enc.buf, compiledBlockBody)
+		enc.WriteBool(n.blank)
+		enc.WriteInt(len(n.nodelist))
+		for _, node := range n.nodelist {
+			enc.Encode(node)
+		}
+	default:
+		tag, ok := v.(interface {
+			MarshalableNode
+			TagName() string
+		})
+		if !ok {
+			enc.Fail(newCompiledTemplateError("cannot compile node of type %T", v))
+			return
+		}
+		enc.buf = append(enc.buf, compiledTag)
+		enc.WriteString(tag.TagName())
+		tag.MarshalNode(enc)
+	}
+}
+
+// EncodeTag writes the fields shared by all tags.
+func (enc *NodeEncoder) EncodeTag(t *Tag) {
+	enc.WriteString(t.tagName)
+	enc.writeMarkup(t.markup)
+	enc.writeLineNumber(t.lineNumber)
+	enc.writeSpan(t.span)
+}
+
+// EncodeBlock writes the fields shared by all block tags, including the block body.
+func (enc *NodeEncoder) EncodeBlock(b *Block) {
+	enc.EncodeTag(b.Tag)
+	enc.WriteString(b.blockDelimiter)
+	enc.WriteBool(b.blank)
+	enc.Encode(b.body)
+}
+
+func (enc *NodeEncoder) encodeCondition(c *Condition) {
+	enc.Encode(c.left)
+	enc.WriteString(c.operator)
+	enc.Encode(c.right)
+	enc.WriteString(c.childRelation)
+	enc.Encode(c.childCondition)
+	enc.Encode(c.attachment)
+}
+
+func (enc *NodeEncoder) writeMarkup(markup string) {
+	if enc.skipMarkup {
+		markup = ""
+	}
+	enc.WriteString(markup)
+}
+
+func (enc *NodeEncoder) writeLineNumber(lineNumber *int) {
+	enc.WriteBool(lineNumber != nil)
+	if lineNumber != nil {
+		enc.WriteInt(*lineNumber)
+	}
+}
+
+func (enc *NodeEncoder) writeSpan(span Span) {
+	if enc.skipMarkup {
+		return
+	}
+	for _, pos := range [2]Position{span.Start, span.End} {
+		enc.WriteInt(pos.Offset)
+		enc.WriteInt(pos.Line)
+		enc.WriteInt(pos.Column)
+	}
+}
+
+// NodeDecoder reads parse tree nodes written by NodeEncoder.
+// Errors are sticky: once a read fails, later reads return zero values and Err
+// reports the first error.
+type NodeDecoder struct {
+	parseContext ParseContextInterface
+	err          error
+	data         []byte
+	pos          int
+	depth        int
+}
+
+// ParseContext returns the parse context decoded tags and variables are attached to.
+func (dec *NodeDecoder) ParseContext() ParseContextInterface {
+	return dec.parseContext
+}
+
+// Err returns the first error encountered while decoding, if any.
+func (dec *NodeDecoder) Err() error {
+	return dec.err
+}
+
+// Fail records err as the decoding error unless one was already recorded.
+func (dec *NodeDecoder) Fail(err error) {
+	if dec.err == nil {
+		dec.err = err
+	}
+}
+
+func (dec *NodeDecoder) corrupt() {
+	dec.Fail(newCompiledTemplateError("corrupt compiled template at offset %d", dec.pos))
+}
+
+func (dec *NodeDecoder) readByte() byte {
+	if dec.err != nil {
+		return 0
+	}
+	if dec.pos >= len(dec.data) {
+		dec.corrupt()
+		return 0
+	}
+	b := dec.data[dec.pos]
+	dec.pos++
+	return b
+}
+
+// ReadString reads a string.
+func (dec *NodeDecoder) ReadString() string {
+	if dec.err != nil {
+		return ""
+	}
+	length, n := binary.Uvarint(dec.data[dec.pos:])
+	if n <= 0 || length > uint64(len(dec.data)-dec.pos-n) {
+		dec.corrupt()
+		return ""
+	}
+	dec.pos += n
+	s := string(dec.data[dec.pos : dec.pos+int(length)])
+	dec.pos += int(length)
+	return s
+}
+
+// ReadInt reads an integer.
+func (dec *NodeDecoder) ReadInt() int {
+	if dec.err != nil {
+		return 0
+	}
+	i, n := binary.Varint(dec.data[dec.pos:])
+	if n <= 0 {
+		dec.corrupt()
+		return 0
+	}
+	dec.pos += n
+	return int(i)
+}
+
+// ReadBool reads a boolean.
+func (dec *NodeDecoder) ReadBool() bool {
+	return dec.readByte() == 1
+}
+
+// Decode reads a value written by NodeEncoder.Encode.
+func (dec *NodeDecoder) Decode() interface{} {
+	kind := dec.readByte()
+	if dec.err != nil {
+		return nil
+	}
+
+	dec.depth++
+	defer func() { dec.depth-- }()
+	if dec.depth > maxCompiledDepth {
+		dec.Fail(NewStackLevelError("Nesting too deep"))
+		return nil
+	}
+
+	switch kind {
+	case compiledNil:
+		return nil
+	case compiledFalse:
+		return false
+	case compiledTrue:
+		return true
+	case compiledInt:
+		return dec.ReadInt()
+	case compiledFloat:
+		if len(dec.data)-dec.pos < 8 {
+			dec.corrupt()
+			return nil
+		}
+		f := math.Float64frombits(binary.LittleEndian.Uint64(dec.data[dec.pos:]))
+		dec.pos += 8
+		return f
+	case compiledString:
+		return dec.ReadString()
+	case compiledVariableLookup:
+		vl := &VariableLookup{}
+		vl.name = dec.Decode()
+		vl.lookups = dec.DecodeArray()
+		vl.commandFlags = uint(dec.ReadInt())
+		return vl
+	case compiledRangeLookup:
+		startObj := dec.Decode()
+		endObj := dec.Decode()
+		return NewRangeLookup(startObj, endObj)
+	case compiledRange:
+		start := dec.ReadInt()
+		end := dec.ReadInt()
+		return &Range{Start: start, End: end}
+	case compiledMethodLiteral:
+		methodName := dec.ReadString()
+		toString := dec.ReadString()
+		if ml, ok := conditionMethodLiterals[methodName]; ok {
+			return ml
+		}
+		return &MethodLiteral{MethodName: methodName, ToString: toString}
+	case compiledArrayLiteral:
+		return NewArrayLiteral(dec.DecodeArray())
+	case compiledHashLiteral:
+		count := dec.readLength()
+		keys := make([]string, 0, count)
+		values := make([]interface{}, 0, count)
+		for i := 0; i < count && dec.err == nil; i++ {
+			keys = append(keys, dec.ReadString())
+			values = append(values, dec.Decode())
+		}
+		return NewHashLiteral(keys, values)
+	case compiledOperation:
+		operator := dec.ReadString()
+		operands := dec.DecodeArray()
+		if len(operands) != 1 && len(operands) != 2 {
+			dec.corrupt()
+			return nil
+		}
+		return NewOperation(operator, operands...)
+	case compiledVariable:
+		v := &Variable{parseContext: dec.parseContext}
+		v.name = dec.Decode()
+		v.markup = dec.ReadString()
+		v.lineNumber = dec.readLineNumber()
+		v.span = dec.readSpan()
+		count := dec.readLength()
+		v.filters = make([][]interface{}, 0, count)
+		for i := 0; i < count && dec.err == nil; i++ {
+			v.filters = append(v.filters, dec.DecodeArray())
+		}
+		return v
+	case compiledCondition:
+		return dec.decodeCondition()
+	case compiledElseCondition:
+		return &ElseCondition{Condition: dec.decodeCondition()}
+	case compiledArray:
+		count := dec.readLength()
+		items := make([]interface{}, 0, count)
+		for i := 0; i < count && dec.err == nil; i++ {
+			items = append(items, dec.Decode())
+		}
+		return items
+	case compiledMap:
+		count := dec.readLength()
+		items := make(map[string]interface{}, count)
+		for i := 0; i < count && dec.err == nil; i++ {
+			key := dec.ReadString()
+			items[key] = dec.Decode()
+		}
+		return items
+	case compiledBlockBody:
+		bb := &BlockBody{}
+		bb.blank = dec.ReadBool()
+		count := dec.readLength()
+		bb.nodelist = make([]interface{}, 0, count)
+		for i := 0; i < count && dec.err == nil; i++ {
+			bb.nodelist = append(bb.nodelist, dec.Decode())
+		}
+		return bb
+	case compiledTag:
+		return dec.decodeTagNode()
+	default:
+		dec.corrupt()
+		return nil
+	}
+}
+
+// DecodeArray reads an array written by Encode. A nil value decodes to nil.
+func (dec *NodeDecoder) DecodeArray() []interface{} {
+	items, _ := dec.decodeAs("array", func(v interface{}) bool {
+		_, ok := v.([]interface{})
+		return ok
+	}).([]interface{})
+	return items
+}
+
+// DecodeMap reads a map written by Encode. A nil value decodes to nil.
+func (dec *NodeDecoder) DecodeMap() map[string]interface{} {
+	items, _ := dec.decodeAs("map", func(v interface{}) bool {
+		_, ok := v.(map[string]interface{})
+		return ok
+	}).(map[string]interface{})
+	return items
+}
+
+// DecodeVariable reads a Variable written by Encode. A nil value decodes to nil.
+func (dec *NodeDecoder) DecodeVariable() *Variable {
+	v, _ := dec.decodeAs("variable", func(v interface{}) bool {
+		_, ok := v.(*Variable)
+		return ok
+	}).(*Variable)
+	return v
+}
+
+// DecodeBlockBody reads a BlockBody written by Encode. A nil value decodes to nil.
+func (dec *NodeDecoder) DecodeBlockBody() *BlockBody {
+	bb, _ := dec.decodeAs("block body", func(v interface{}) bool {
+		_, ok := v.(*BlockBody)
+		return ok
+	}).(*BlockBody)
+	return bb
+}
+
+// DecodeTag reads the fields written by NodeEncoder.EncodeTag.
+func (dec *NodeDecoder) DecodeTag() *Tag {
+	t := &Tag{
+		parseContext: dec.parseContext,
+		nodelist:     []interface{}{},
+	}
+	t.tagName = dec.ReadString()
+	t.markup = dec.ReadString()
+	t.lineNumber = dec.readLineNumber()
+	t.span = dec.readSpan()
+	return t
+}
+
+// DecodeBlock reads the fields written by NodeEncoder.EncodeBlock.
+func (dec *NodeDecoder) DecodeBlock() *Block {
+	b := &Block{Tag: dec.DecodeTag()}
+	b.blockDelimiter = dec.ReadString()
+	b.blank = dec.ReadBool()
+	b.body = dec.DecodeBlockBody()
+	return b
+}
+
+// decodeAs decodes a value and checks that it is nil or matches the expected kind.
+func (dec *NodeDecoder) decodeAs(kind string, matches func(interface{}) bool) interface{} {
+	v := dec.Decode()
+	if v == nil || dec.err != nil {
+		return nil
+	}
+	if !matches(v) {
+		dec.Fail(newCompiledTemplateError("corrupt compiled template: expected %s, got %T", kind, v))
+		return nil
+	}
+	return v
+}
+
+func (dec *NodeDecoder) decodeCondition() *Condition {
+	c := &Condition{}
+	c.left = dec.Decode()
+	c.operator = dec.ReadString()
+	c.right = dec.Decode()
+	c.childRelation = dec.ReadString()
+	if child := dec.Decode(); child != nil {
+		childCondition, ok := child.(*Condition)
+		if !ok {
+			dec.Fail(newCompiledTemplateError("corrupt compiled template: expected condition, got %T", child))
+			return c
+		}
+		c.childCondition = childCondition
+	}
+	c.attachment = dec.Decode()
+	return c
+}
+
+func (dec *NodeDecoder) decodeTagNode() interface{} {
+	name := dec.ReadString()
+	if dec.err != nil {
+		return nil
+	}
+
+	var decode TagDecoder
+	if env := dec.parseContext.Environment(); env != nil {
+		decode = env.TagDecoder(name)
+	}
+	if decode == nil {
+		dec.Fail(newCompiledTemplateError("no decoder registered for tag '%s'", name))
+		return nil
+	}
+
+	tag, err := decode(dec)
+	if err != nil {
+		dec.Fail(err)
+		return nil
+	}
+	return tag
+}
+
+func (dec *NodeDecoder) readLength() int {
+	n := dec.ReadInt()
+	// Every element takes at least one byte, which bounds allocations on corrupt data
+	if n < 0 || n > len(dec.data)-dec.pos {
+		dec.corrupt()
+		return 0
+	}
+	return n
+}
+
+func (dec *NodeDecoder) readLineNumber() *int {
+	if !dec.ReadBool() {
+		return nil
+	}
+	lineNumber := dec.ReadInt()
+	return &lineNumber
+}
+
+func (dec *NodeDecoder) readSpan() Span {
+	var span Span
+	for _, pos := range [2]*Position{&span.Start, &span.End} {
+		pos.Offset = dec.ReadInt()
+		pos.Line = dec.ReadInt()
+		pos.Column = dec.ReadInt()
+	}
+	return span
+}
+
+// MarshalBinary serializes the parsed template so it can be loaded again with
+// LoadCompiled without tokenizing and parsing the source. Every tag in the
+// template must implement MarshalableNode; the standard tags do.
+// It implements encoding.BinaryMarshaler.
+func (t *Template) MarshalBinary() ([]byte, error) {
+	if t.root == nil {
+		return nil, newCompiledTemplateError("template has not been parsed")
+	}
+
+	enc := &NodeEncoder{}
+	enc.buf = append(enc.buf, compiledMagic...)
+	enc.buf = binary.AppendUvarint(enc.buf, CompiledVersion)
+	enc.WriteBool(t.lineNumbers)
+	enc.Encode(t.root.body)
+	if enc.err != nil {
+		return nil, enc.err
+	}
+	return enc.buf, nil
+}
+
+// UnmarshalBinary replaces the template's parse tree with one serialized by
+// MarshalBinary. Tags are rebuilt with the decoders registered on the
+// template's environment. It implements encoding.BinaryUnmarshaler.
+func (t *Template) UnmarshalBinary(data []byte) error {
+	return t.loadCompiled(data, nil)
+}
+
+// LoadCompiled creates a Template from data written by Template.MarshalBinary.
+// The environment in options must have decoders registered for every tag used
+// by the template (tags.RegisterStandardTags registers them for the standard tags).
+func LoadCompiled(data []byte, options *TemplateOptions) (*Template, error) {
+	template := NewTemplate(options)
+	if err := template.loadCompiled(data, options); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+func (t *Template) loadCompiled(data []byte, options *TemplateOptions) error {
+	if len(data) < len(compiledMagic) || string(data[:len(compiledMagic)]) != compiledMagic {
+		return newCompiledTemplateError("data is not a compiled template")
+	}
+	pos := len(compiledMagic)
+	version, n := binary.Uvarint(data[pos:])
+	if n <= 0 {
+		return newCompiledTemplateError("data is not a compiled template")
+	}
+	if version != CompiledVersion {
+		return newCompiledTemplateError("unsupported compiled template version %d (want %d)", version, CompiledVersion)
+	}
+	pos += n
+
+	parseContext := t.configureOptions(options)
+	dec := &NodeDecoder{
+		parseContext: parseContext,
+		data:         data,
+		pos:          pos,
+	}
+	lineNumbers := dec.ReadBool()
+	body := dec.DecodeBlockBody()
+	if dec.err == nil && body == nil {
+		dec.corrupt()
+	}
+	if dec.err == nil && dec.pos != len(dec.data) {
+		dec.Fail(newCompiledTemplateError("corrupt compiled template: %d trailing bytes", len(dec.data)-dec.pos))
+	}
+	if dec.err != nil {
+		return dec.err
+	}
+
+	t.lineNumbers = t.lineNumbers || lineNumbers
+	t.root = &Document{parseContext: parseContext, body: body}
+	t.warnings = []error{}
+	return nil
+}
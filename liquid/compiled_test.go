@@ -0,0 +1,202 @@
+package liquid
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// shoutTag is a custom tag that renders its markup in upper case.
+type shoutTag struct {
+	*Tag
+	text string
+}
+
+func (s *shoutTag) RenderToOutputBuffer(context TagContext, output *OutputBuffer) {
+	output.WriteString(s.text)
+}
+
+func (s *shoutTag) MarshalNode(enc *NodeEncoder) {
+	enc.EncodeTag(s.Tag)
+	enc.WriteString(s.text)
+}
+
+func decodeShoutTag(dec *NodeDecoder) (interface{}, error) {
+	tag := &shoutTag{Tag: dec.DecodeTag()}
+	tag.text = dec.ReadString()
+	return tag, dec.Err()
+}
+
+func newShoutEnvironment() *Environment {
+	env := NewEnvironment()
+	env.RegisterTag("shout", func(tagName, markup string, parseContext ParseContextInterface) (interface{}, error) {
+		return &shoutTag{
+			Tag:  NewTag(tagName, markup, parseContext),
+			text: strings.ToUpper(strings.TrimSpace(markup)),
+		}, nil
+	})
+	return env
+}
+
+func compileAndLoad(t *testing.T, source string, options *TemplateOptions) *Template {
+	t.Helper()
+	template, err := ParseTemplate(source, options)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	data, err := template.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+	loaded, err := LoadCompiled(data, options)
+	if err != nil {
+		t.Fatalf("LoadCompiled() error = %v", err)
+	}
+	return loaded
+}
+
+func TestLoadCompiledVariables(t *testing.T) {
+	source := `Hello {{ user.name | upcase }}! {{ items[0] }} {{ items.size }} ` +
+		`{{ "a,b" | split: "," | join: "-" }} {{ 1.5 | plus: 2 }} {{ missing | default: "none", allow_false: true }} ` +
+		`{{ (1..3) | join: "," }} {{ nil }}{{ true }} {{ user["name"] }}`
+	assigns := map[string]interface{}{
+		"user":  map[string]interface{}{"name": "ann"},
+		"items": []interface{}{"x", "y"},
+	}
+
+	template, err := ParseTemplate(source, nil)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	want := template.Render(assigns, nil)
+
+	loaded := compileAndLoad(t, source, nil)
+	if got := loaded.Render(assigns, nil); got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestLoadCompiledKeepsLineNumbers(t *testing.T) {
+	loaded := compileAndLoad(t, "a\n{{ name }}", &TemplateOptions{LineNumbers: true})
+	if !loaded.lineNumbers {
+		t.Fatal("Expected line numbers to be preserved")
+	}
+	nodes := loaded.Root().Nodelist()
+	variable, ok := nodes[len(nodes)-1].(*Variable)
+	if !ok {
+		t.Fatalf("Expected last node to be a Variable, got %T", nodes[len(nodes)-1])
+	}
+	if variable.LineNumber() == nil || *variable.LineNumber() != 2 {
+		t.Errorf("LineNumber() = %v, want 2", variable.LineNumber())
+	}
+}
+
+func TestLoadCompiledCustomTag(t *testing.T) {
+	env := newShoutEnvironment()
+	env.RegisterTagDecoder("shout", decodeShoutTag)
+
+	loaded := compileAndLoad(t, "{% shout hello %} {{ name }}", &TemplateOptions{Environment: env})
+	if got := loaded.Render(map[string]interface{}{"name": "bob"}, nil); got != "HELLO bob" {
+		t.Errorf("Render() = %q, want %q", got, "HELLO bob")
+	}
+}
+
+func TestLoadCompiledMissingTagDecoder(t *testing.T) {
+	env := newShoutEnvironment()
+	template, err := ParseTemplate("{% shout hello %}", &TemplateOptions{Environment: env})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	data, err := template.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+
+	_, err = LoadCompiled(data, &TemplateOptions{Environment: env})
+	var compiledErr *CompiledTemplateError
+	if !errors.As(err, &compiledErr) {
+		t.Fatalf("Expected CompiledTemplateError, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "no decoder registered for tag 'shout'") {
+		t.Errorf("Unexpected error message: %v", err)
+	}
+}
+
+func TestMarshalBinaryUnsupportedTag(t *testing.T) {
+	env := NewEnvironment()
+	env.RegisterTag("plain", func(tagName, markup string, parseContext ParseContextInterface) (interface{}, error) {
+		return NewTag(tagName, markup, parseContext), nil
+	})
+	template, err := ParseTemplate("{% plain %}", &TemplateOptions{Environment: env})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+
+	_, err = template.MarshalBinary()
+	var compiledErr *CompiledTemplateError
+	if !errors.As(err, &compiledErr) {
+		t.Fatalf("Expected CompiledTemplateError, got %v", err)
+	}
+}
+
+func TestMarshalBinaryUnparsedTemplate(t *testing.T) {
+	if _, err := NewTemplate(nil).MarshalBinary(); err == nil {
+		t.Error("Expected error for unparsed template")
+	}
+}
+
+func TestLoadCompiledRejectsInvalidData(t *testing.T) {
+	template, err := ParseTemplate("Hello {{ name | upcase }}", nil)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	data, err := template.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+
+	wrongVersion := append([]byte(compiledMagic), byte(CompiledVersion+1))
+	wrongVersion = append(wrongVersion, data[len(compiledMagic)+1:]...)
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"empty", nil, "not a compiled template"},
+		{"bad magic", []byte("nope"), "not a compiled template"},
+		{"wrong version", wrongVersion, "unsupported compiled template version"},
+		{"truncated", data[:len(data)-3], "corrupt compiled template"},
+		{"trailing bytes", append(append([]byte{}, data...), 0), "trailing bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadCompiled(tt.data, nil)
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateUnmarshalBinary(t *testing.T) {
+	template, err := ParseTemplate("{{ greeting }}", nil)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	data, err := template.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+
+	loaded := NewTemplate(nil)
+	if err := loaded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary() error = %v", err)
+	}
+	if got := loaded.Render(map[string]interface{}{"greeting": "hi"}, nil); got != "hi" {
+		t.Errorf("Render() = %q, want %q", got, "hi")
+	}
+}
@@ -40,7 +40,13 @@ func NewCondition(left interface{}, operator string, right interface{}) *Conditi
 	}
 }
 
-// Left returns the left side of the condition.
+// NewNotCondition creates a condition that is true when condition is false.
+func NewNotCondition(condition *Condition) *Condition {
+	return NewCondition(condition, "not", nil)
+}
+
+// Left returns the left side of the condition. It is a *Condition for
+// parenthesized groups and not conditions.
 func (c *Condition) Left() interface{} {
 	return c.left
 }
@@ -65,6 +71,12 @@ func (c *Condition) Attachment() interface{} {
 	return c.attachment
 }
 
+// ParseTreeChildren returns the compared expressions, the chained condition
+// and the attachment.
+func (c *Condition) ParseTreeChildren() []interface{} {
+	return []interface{}{c.left, c.right, c.childCondition, c.attachment}
+}
+
 // Or chains this condition with another using OR.
 func (c *Condition) Or(condition *Condition) {
 	c.childRelation = "or"
@@ -92,11 +104,23 @@ type ConditionContext interface {
 	Evaluate(expr interface{}) interface{}
 }
 
-// Evaluate evaluates the condition in the given context.
-func (c *Condition) Evaluate(context ConditionContext) (bool, error) {
+// Evaluate evaluates the condition in the given context. Errors raised while
+// evaluating an Operation, such as a division by zero, are returned.
+func (c *Condition) Evaluate(context ConditionContext) (result bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			switch e := r.(type) {
+			case *CanceledError, *MemoryError:
+				panic(e)
+			case LiquidError:
+				result, err = false, e
+			default:
+				panic(r)
+			}
+		}
+	}()
+
 	condition := c
-	var result bool
-	var err error
 
 	for {
 		result, err = c.interpretCondition(condition.left, condition.right, condition.operator, context)
@@ -127,6 +151,15 @@ func (c *Condition) Evaluate(context ConditionContext) (bool, error) {
 }
 
 func (c *Condition) interpretCondition(left, right interface{}, op string, context ConditionContext) (bool, error) {
+	// Parenthesized groups and not conditions hold a nested condition
+	if nested, ok := left.(*Condition); ok && (op == "" || op == "not") {
+		result, err := nested.Evaluate(context)
+		if op == "not" {
+			return !result && err == nil, err
+		}
+		return result, err
+	}
+
 	// If operator is empty, just evaluate the left side
 	if op == "" {
 		result := context.Evaluate(left)
@@ -142,6 +175,12 @@ func (c *Condition) interpretCondition(left, right interface{}, op string, conte
 	rightVal := ToLiquidValue(context.Evaluate(right))
 
 	operator := getConditionOperator(op)
+	if operator == nil {
+		// Operators registered with the rendering environment
+		if ctx, ok := context.(interface{ Environment() *Environment }); ok && ctx.Environment() != nil {
+			operator = ctx.Environment().Operator(op)
+		}
+	}
 	if operator == nil {
 		return false, NewArgumentError("Unknown operator " + op)
 	}
@@ -225,6 +264,14 @@ func (c *Condition) equalVariables(left, right interface{}) bool {
 		return checkMethodLiteral(ml, left)
 	}
 
+	// Numbers compare by value whatever their type, so that 3.5 * 2 == 7 and
+	// int64 values equal int literals
+	if leftNum, ok := toNumber(left); ok {
+		if rightNum, ok := toNumber(right); ok {
+			return leftNum == rightNum
+		}
+	}
+
 	return left == right
 }
 
@@ -92,6 +92,32 @@ func TestConditionAnd(t *testing.T) {
 	}
 }
 
+func TestConditionGroupAndNot(t *testing.T) {
+	ctx := &mockConditionContext{}
+
+	// (true or false) and false: false, while the chain true or (false and false) is true
+	group := NewCondition(true, "", nil)
+	group.Or(NewCondition(false, "", nil))
+	c := NewCondition(group, "", nil)
+	c.And(NewCondition(false, "", nil))
+
+	result, err := c.Evaluate(ctx)
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	if result {
+		t.Error("Expected grouped condition to be false")
+	}
+
+	result, err = NewNotCondition(c).Evaluate(ctx)
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	if !result {
+		t.Error("Expected not condition to be true")
+	}
+}
+
 func TestConditionContains(t *testing.T) {
 	ctx := &mockConditionContext{}
 
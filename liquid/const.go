@@ -2,6 +2,21 @@ package liquid
 
 import "regexp"
 
+// literalFragment matches an array or hash literal, e.g. [1, 2] or {a: [3]},
+// nested up to three levels deep. Go regexps cannot match balanced brackets,
+// so deeper literals need the strict parser.
+var literalFragment = nestLiteralFragment(nestLiteralFragment(nestLiteralFragment(``)))
+
+// nestLiteralFragment returns a pattern matching a bracketed literal whose
+// items may contain what inner matches.
+func nestLiteralFragment(inner string) string {
+	item := `"[^"]*"|'[^']*'|[^\[\]{}"']`
+	if inner != "" {
+		item += `|` + inner
+	}
+	return `\[(?:` + item + `)*\]|\{(?:` + item + `)*\}`
+}
+
 // Const contains constants used throughout the Liquid package.
 var (
 	// FilterSeparator is the regex pattern for filter separator (|)
@@ -47,10 +62,10 @@ var (
 	QuotedString = regexp.MustCompile(`"[^"]*"|'[^']*'`)
 
 	// QuotedFragment is the regex pattern for quoted fragments
-	QuotedFragment = regexp.MustCompile(`"[^"]*"|'[^']*'|(?:[^\s,\|'"]|"[^"]*"|'[^']*')+`)
+	QuotedFragment = regexp.MustCompile(`"[^"]*"|'[^']*'|(?:` + literalFragment + `|[^\s,\|'"]|"[^"]*"|'[^']*')+`)
 
 	// TagAttributes is the regex pattern for tag attributes
-	TagAttributes = regexp.MustCompile(`(\w[\w-]*)\s*\:\s*("[^"]*"|'[^']*'|(?:[^\s,\|'"]|"[^"]*"|'[^']*')+)`)
+	TagAttributes = regexp.MustCompile(`(\w[\w-]*)\s*\:\s*("[^"]*"|'[^']*'|(?:` + literalFragment + `|[^\s,\|'"]|"[^"]*"|'[^']*')+)`)
 
 	// AnyStartingTag is the regex pattern for any starting tag
 	AnyStartingTag = regexp.MustCompile(`\{\%|\{\{`)
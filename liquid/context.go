@@ -1,5 +1,7 @@
 package liquid
 
+import "context"
+
 // ContextConfig configures a Context.
 type ContextConfig struct {
 	Registers          interface{}
@@ -8,6 +10,7 @@ type ContextConfig struct {
 	ResourceLimits     *ResourceLimits
 	Environments       []map[string]interface{}
 	StaticEnvironments []map[string]interface{}
+	GoContext          context.Context
 	RethrowErrors      bool
 }
 
@@ -22,7 +25,10 @@ type Context struct {
 	strainer           *StrainerTemplate
 	environment        *Environment
 	globalFilter       func(interface{}) interface{}
+	goContext          context.Context
+	done               <-chan struct{}
 	templateName       string
+	node               interface{} // Node being rendered, for the span of render errors
 	warnings           []error
 	environments       []map[string]interface{}
 	filters            []interface{}
@@ -119,6 +125,10 @@ func BuildContext(config ContextConfig) *Context {
 		}
 	}
 
+	if config.GoContext != nil {
+		ctx.SetGoContext(config.GoContext)
+	}
+
 	ctx.squashInstanceAssignsWithEnvironments()
 	return ctx
 }
@@ -299,6 +309,7 @@ func (c *Context) HandleError(err error, lineNumber *int) string {
 		if e.LineNumber == nil {
 			e.LineNumber = lineNumber
 		}
+		setErrorSpan(e, c.nodeSpan())
 	} else if e, ok := err.(*Error); ok {
 		// Handle base Error type
 		if e.TemplateName == "" {
@@ -307,6 +318,7 @@ func (c *Context) HandleError(err error, lineNumber *int) string {
 		if e.LineNumber == nil {
 			e.LineNumber = lineNumber
 		}
+		setErrorSpan(e, c.nodeSpan())
 	} else {
 		// Unknown error type, wrap as InternalError
 		liquidErr = NewInternalError("internal")
@@ -314,6 +326,7 @@ func (c *Context) HandleError(err error, lineNumber *int) string {
 		e := le.GetError()
 		e.TemplateName = c.templateName
 		e.LineNumber = lineNumber
+		setErrorSpan(e, c.nodeSpan())
 	}
 
 	c.errors = append(c.errors, liquidErr)
@@ -321,8 +334,54 @@ func (c *Context) HandleError(err error, lineNumber *int) string {
 	return ToS(result, nil)
 }
 
+// GoContext returns the context.Context the render runs under.
+// It returns context.Background() when none was set.
+func (c *Context) GoContext() context.Context {
+	if c.goContext == nil {
+		return context.Background()
+	}
+	return c.goContext
+}
+
+// SetGoContext sets the context.Context the render runs under.
+// Once it is canceled, rendering stops with a CanceledError.
+func (c *Context) SetGoContext(ctx context.Context) {
+	c.goContext = ctx
+	c.done = nil
+	if ctx != nil {
+		c.done = ctx.Done()
+	}
+}
+
+// CheckCanceled panics with a CanceledError if the render's context.Context
+// is done. lineNumber is reported as the place where the render stopped.
+// Tags that loop or do expensive work should call it between iterations.
+func (c *Context) CheckCanceled(lineNumber *int) {
+	if c.done == nil {
+		return
+	}
+	select {
+	case <-c.done:
+		err := NewCanceledError(c.goContext.Err())
+		err.Err.TemplateName = c.templateName
+		err.Err.LineNumber = lineNumber
+		setErrorSpan(err.Err, c.nodeSpan())
+		panic(err)
+	default:
+	}
+}
+
+// nodeSpan returns the span of the node being rendered.
+func (c *Context) nodeSpan() Span {
+	if node, ok := c.node.(interface{ Span() Span }); ok {
+		return node.Span()
+	}
+	return Span{}
+}
+
 // Invoke invokes a filter method.
 func (c *Context) Invoke(method string, obj interface{}, args ...interface{}) interface{} {
+	c.CheckCanceled(nil)
 	result, err := c.Strainer().Invoke(method, append([]interface{}{obj}, args...)...)
 	if err != nil {
 		if c.strictFilters {
@@ -571,6 +630,7 @@ func (c *Context) NewIsolatedSubcontext() *Context {
 	subCtx.warnings = c.warnings
 	subCtx.disabledTags = c.disabledTags
 	subCtx.profiler = c.profiler
+	subCtx.SetGoContext(c.goContext)
 
 	return subCtx
 }
@@ -681,6 +741,8 @@ func (c *Context) Reset() {
 	c.strainer = nil
 	c.environment = nil
 	c.globalFilter = nil
+	c.goContext = nil
+	c.done = nil
 
 	// Reset primitive fields
 	c.templateName = ""
@@ -1,6 +1,7 @@
 package liquid
 
 import (
+	"context"
 	"strings"
 	"testing"
 )
@@ -1038,3 +1039,41 @@ func TestContextMergeWithEmptyScopes(t *testing.T) {
 		t.Errorf("Expected 'value2', got %v", ctx.Get("key2"))
 	}
 }
+
+// TestContextGoContext tests the default and explicit context.Context of a Context
+func TestContextGoContext(t *testing.T) {
+	ctx := NewContext()
+	if ctx.GoContext() != context.Background() {
+		t.Error("Expected context.Background() by default")
+	}
+	// Without a context.Context, CheckCanceled never panics
+	ctx.CheckCanceled(nil)
+
+	goCtx, cancel := context.WithCancel(context.Background())
+	ctx.SetGoContext(goCtx)
+	if ctx.GoContext() != goCtx {
+		t.Error("Expected GoContext to return the context set with SetGoContext")
+	}
+
+	subCtx := ctx.NewIsolatedSubcontext()
+	if subCtx.GoContext() != goCtx {
+		t.Error("Expected isolated subcontext to inherit the context.Context")
+	}
+
+	ctx.CheckCanceled(nil)
+	cancel()
+
+	lineNumber := 7
+	ctx.SetTemplateName("product")
+	defer func() {
+		r := recover()
+		canceledErr, ok := r.(*CanceledError)
+		if !ok {
+			t.Fatalf("Expected CanceledError panic, got %v", r)
+		}
+		if canceledErr.Error() != "Liquid error (product line 7): render canceled: context canceled" {
+			t.Errorf("Unexpected error message %q", canceledErr.Error())
+		}
+	}()
+	ctx.CheckCanceled(&lineNumber)
+}
@@ -0,0 +1,224 @@
+package liquid
+
+import "sort"
+
+// PartialDependency is an include or render tag naming a partial.
+type PartialDependency struct {
+	// Name is the partial name, or "" when it is not a string literal.
+	Name string
+	// Tag is the name of the tag, e.g. "render".
+	Tag string
+	// LineNumber is the line of the tag, or 0 when unknown.
+	LineNumber int
+}
+
+// DependencyDiagnostic reports a partial that could not be followed.
+type DependencyDiagnostic struct {
+	// Template is the template containing the tag.
+	Template string
+	PartialDependency
+	// Missing is true when the file system could not read the partial.
+	Missing bool
+	// Err is the file system error, the parse error of the partial, or an
+	// ArgumentError when the name is not a string literal.
+	Err error
+}
+
+// DependencyGraph is the graph of partials reachable from a template.
+type DependencyGraph struct {
+	// Root is the name of the analyzed template ("" when it has none).
+	Root string
+	// Dependencies maps each template in the graph, including Root, to the
+	// partials it references in template order.
+	Dependencies map[string][]PartialDependency
+	// Cycles lists the include/render cycles, each starting and ending with
+	// the same partial name.
+	Cycles [][]string
+	// Diagnostics lists the partials that could not be followed.
+	Diagnostics []DependencyDiagnostic
+}
+
+// Partials returns the names of all partials reachable from Root, sorted.
+func (g *DependencyGraph) Partials() []string {
+	names := []string{}
+	for name := range g.Dependencies {
+		if name != g.Root {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Missing returns the names of referenced partials the file system could not read, sorted.
+func (g *DependencyGraph) Missing() []string {
+	seen := make(map[string]bool)
+	names := []string{}
+	for _, diagnostic := range g.Diagnostics {
+		if diagnostic.Missing && !seen[diagnostic.Name] {
+			seen[diagnostic.Name] = true
+			names = append(names, diagnostic.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Dependents returns the templates that reference name directly or through
+// other partials, sorted. These are the templates to invalidate when name changes.
+func (g *DependencyGraph) Dependents(name string) []string {
+	reverse := make(map[string][]string)
+	for template, deps := range g.Dependencies {
+		for _, dep := range deps {
+			reverse[dep.Name] = append(reverse[dep.Name], template)
+		}
+	}
+
+	seen := map[string]bool{name: true}
+	queue := []string{name}
+	names := []string{}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, template := range reverse[current] {
+			if !seen[template] {
+				seen[template] = true
+				names = append(names, template)
+				queue = append(queue, template)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Dependencies walks the include and render tags of tmpl, and of the partials
+// they name, and returns the resulting graph. Partials are read from
+// fileSystem, or from the file system of the template's environment when
+// fileSystem is nil, and parsed with the template's environment.
+func Dependencies(tmpl *Template, fileSystem FileSystem) *DependencyGraph {
+	graph := &DependencyGraph{Dependencies: make(map[string][]PartialDependency)}
+	if tmpl == nil || tmpl.root == nil {
+		return graph
+	}
+	if fileSystem == nil {
+		fileSystem = analysisFileSystem(tmpl)
+	}
+	if fileSystem == nil {
+		fileSystem = &BlankFileSystem{}
+	}
+
+	walker := &dependencyWalker{
+		graph:       graph,
+		environment: tmpl.environment,
+		fileSystem:  fileSystem,
+		state:       make(map[string]int),
+	}
+	graph.Root = tmpl.Name()
+	walker.visit(graph.Root, tmpl)
+	return graph
+}
+
+// Partial visit states used for cycle detection.
+const (
+	dependencyUnvisited = iota
+	dependencyVisiting
+	dependencyDone
+)
+
+type dependencyWalker struct {
+	graph       *DependencyGraph
+	environment *Environment
+	fileSystem  FileSystem
+	state       map[string]int
+	stack       []string
+}
+
+func (w *dependencyWalker) visit(name string, tmpl *Template) {
+	w.state[name] = dependencyVisiting
+	w.stack = append(w.stack, name)
+
+	deps := []PartialDependency{}
+	collectPartialDependencies(tmpl.root, 0, &deps)
+	for i := range deps {
+		deps[i].Name = ResolveParentTemplateName(deps[i].Name, name)
+	}
+	w.graph.Dependencies[name] = deps
+
+	for _, dep := range deps {
+		if dep.Name == "" {
+			w.diagnose(name, dep, false, NewArgumentError(dep.Tag+" tag requires a string template name"))
+			continue
+		}
+		switch w.state[dep.Name] {
+		case dependencyVisiting:
+			w.recordCycle(dep.Name)
+		case dependencyUnvisited:
+			source, err := w.fileSystem.ReadTemplateFile(dep.Name)
+			if err != nil {
+				w.diagnose(name, dep, true, err)
+				continue
+			}
+			partial, err := parsePartialSource(w.environment, dep.Name, source)
+			if err != nil {
+				w.diagnose(name, dep, false, err)
+				continue
+			}
+			w.visit(dep.Name, partial)
+		}
+	}
+
+	w.stack = w.stack[:len(w.stack)-1]
+	w.state[name] = dependencyDone
+}
+
+func (w *dependencyWalker) diagnose(template string, dep PartialDependency, missing bool, err error) {
+	w.graph.Diagnostics = append(w.graph.Diagnostics, DependencyDiagnostic{
+		Template:          template,
+		PartialDependency: dep,
+		Missing:           missing,
+		Err:               err,
+	})
+}
+
+// recordCycle records the cycle closed by an edge back to name.
+func (w *dependencyWalker) recordCycle(name string) {
+	for i := len(w.stack) - 1; i >= 0; i-- {
+		if w.stack[i] == name {
+			cycle := append([]string{}, w.stack[i:]...)
+			w.graph.Cycles = append(w.graph.Cycles, append(cycle, name))
+			return
+		}
+	}
+}
+
+// collectPartialDependencies appends the partials referenced under node.
+func collectPartialDependencies(node interface{}, line int, deps *[]PartialDependency) {
+	if withLine, ok := node.(interface{ LineNumber() *int }); ok {
+		if n := withLine.LineNumber(); n != nil {
+			line = *n
+		}
+	}
+	if scoped, ok := node.(ScopedNode); ok {
+		if partial := scoped.VariableScope().Partial; partial != nil {
+			dep := PartialDependency{Name: partial.Name, LineNumber: line}
+			if named, ok := node.(interface{ TagName() string }); ok {
+				dep.Tag = named.TagName()
+			}
+			*deps = append(*deps, dep)
+		}
+	}
+	for _, child := range ForParseTreeVisitor(node, nil).Children() {
+		collectPartialDependencies(child, line, deps)
+	}
+}
+
+// parsePartialSource parses a partial for static analysis.
+func parsePartialSource(environment *Environment, name, source string) (*Template, error) {
+	tmpl, err := ParseTemplate(source, &TemplateOptions{Environment: environment, LineNumbers: true})
+	if err != nil {
+		return nil, err
+	}
+	tmpl.SetName(name)
+	return tmpl, nil
+}
@@ -0,0 +1,25 @@
+package liquid
+
+import "testing"
+
+func TestDependenciesWithoutPartials(t *testing.T) {
+	tmpl, err := ParseTemplate("Hello {{ name }}", nil)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+
+	graph := Dependencies(tmpl, nil)
+	if deps, ok := graph.Dependencies[""]; !ok || len(deps) != 0 {
+		t.Errorf("Dependencies = %+v, want the root without partials", graph.Dependencies)
+	}
+	if len(graph.Partials()) != 0 || len(graph.Missing()) != 0 || len(graph.Cycles) != 0 {
+		t.Errorf("Expected an empty graph, got %+v", graph)
+	}
+}
+
+func TestDependenciesUnparsedTemplate(t *testing.T) {
+	graph := Dependencies(NewTemplate(nil), nil)
+	if len(graph.Dependencies) != 0 {
+		t.Errorf("Dependencies = %+v, want none", graph.Dependencies)
+	}
+}
@@ -33,6 +33,9 @@ func ParseDocument(tokenizer *Tokenizer, parseContext ParseContextInterface) (*D
 	}()
 
 	if parseErr != nil {
+		if liquidErr, ok := parseErr.(LiquidError); ok {
+			setErrorSpan(liquidErr.GetError(), parseSpan(parseContext))
+		}
 		return nil, parseErr
 	}
 	return doc, nil
@@ -69,6 +72,7 @@ func (d *Document) Parse(tokenizer *Tokenizer, parseContext ParseContextInterfac
 				if err.Err.LineNumber == nil {
 					err.Err.LineNumber = parseContext.LineNumber()
 				}
+				setErrorSpan(err.Err, parseSpan(parseContext))
 				panic(err)
 			}
 			panic(r)
@@ -113,7 +117,7 @@ func (d *Document) UnknownTag(tag, markup string, tokenizer *Tokenizer) error {
 }
 
 // RenderToOutputBuffer renders the document to an output buffer.
-func (d *Document) RenderToOutputBuffer(context TagContext, output *string) {
+func (d *Document) RenderToOutputBuffer(context TagContext, output *OutputBuffer) {
 	// Check if profiling is enabled
 	if ctx, ok := context.(*Context); ok && ctx.Profiler() != nil {
 		templateName := ctx.TemplateName()
@@ -128,9 +132,9 @@ func (d *Document) RenderToOutputBuffer(context TagContext, output *string) {
 
 // Render renders the document and returns the result as a string.
 func (d *Document) Render(context TagContext) string {
-	var output string
-	d.RenderToOutputBuffer(context, &output)
-	return output
+	output := NewOutputBuffer()
+	d.RenderToOutputBuffer(context, output)
+	return output.String()
 }
 
 func (d *Document) parseBody(tokenizer *Tokenizer, parseContext ParseContextInterface) bool {
@@ -138,7 +142,7 @@ func (d *Document) parseBody(tokenizer *Tokenizer, parseContext ParseContextInte
 		if unknownTagName != "" {
 			err := d.UnknownTag(unknownTagName, unknownTagMarkup, tokenizer)
 			if err != nil {
-				if d.parseContext.ErrorMode() == "warn" {
+				if d.parseContext.ErrorMode() == "warn" && recoveryOf(d.parseContext) == nil {
 					d.parseContext.AddWarning(err)
 					return true
 				}
@@ -80,10 +80,10 @@ func TestDocumentRenderToOutputBuffer(t *testing.T) {
 	}
 
 	ctx := NewContext()
-	var output string
-	doc.RenderToOutputBuffer(ctx, &output)
-	if output != "Test" {
-		t.Errorf("Expected 'Test', got %q", output)
+	output := NewOutputBuffer()
+	doc.RenderToOutputBuffer(ctx, output)
+	if output.String() != "Test" {
+		t.Errorf("Expected 'Test', got %q", output.String())
 	}
 }
 
@@ -2,19 +2,34 @@ package liquid
 
 import (
 	"reflect"
+	"regexp"
 )
 
+// operatorName matches the names RegisterOperator accepts.
+var operatorName = regexp.MustCompile(`^[a-zA-Z_]\w*$`)
+
+// reservedOperatorNames are the words with a meaning of their own in
+// conditions, which RegisterOperator rejects.
+var reservedOperatorNames = map[string]bool{
+	"contains": true, "and": true, "or": true, "not": true,
+	"nil": true, "null": true, "true": true, "false": true, "empty": true, "blank": true,
+}
+
 // Environment is the container for all configuration options of Liquid, such as
 // the registered tags, filters, and the default error mode.
 type Environment struct {
 	fileSystem                 FileSystem
 	tags                       map[string]interface{}
+	tagDecoders                map[string]TagDecoder
 	strainerTemplate           *StrainerTemplateClass
 	exceptionRenderer          func(error) interface{}
 	defaultResourceLimits      map[string]interface{}
 	strainerTemplateClassCache map[string]*StrainerTemplateClass
 	errorMode                  string
 	registeredFilters          []interface{} // Store filter instances for use when creating strainers
+	partialCache               *SharedPartialCache
+	fragmentStore              FragmentStore
+	operators                  map[string]ConditionOperator
 }
 
 // NewEnvironment creates a new environment instance.
@@ -22,12 +37,14 @@ func NewEnvironment() *Environment {
 	env := &Environment{
 		errorMode:                  "lax",
 		tags:                       make(map[string]interface{}),
+		tagDecoders:                make(map[string]TagDecoder),
 		strainerTemplate:           NewStrainerTemplateClass(),
 		exceptionRenderer:          func(err error) interface{} { return err },
 		fileSystem:                 &BlankFileSystem{},
 		defaultResourceLimits:      EmptyHash,
 		strainerTemplateClassCache: make(map[string]*StrainerTemplateClass),
 		registeredFilters:          make([]interface{}, 0),
+		operators:                  make(map[string]ConditionOperator),
 	}
 
 	// Add standard filters
@@ -65,6 +82,38 @@ func (e *Environment) RegisterTag(name string, tagClass interface{}) {
 	e.tags[name] = tagClass
 }
 
+// RegisterTagDecoder registers the decoder that rebuilds the tag registered
+// under name when loading compiled templates (see LoadCompiled).
+// The tag itself must implement MarshalableNode.
+func (e *Environment) RegisterTagDecoder(name string, decode TagDecoder) {
+	e.tagDecoders[name] = decode
+}
+
+// RegisterOperator registers a comparison operator, usable like contains in
+// conditions, case/when and expressions: {% if title startswith 'Re:' %}.
+// The name must be a word; built-in operators and keywords such as and, not or
+// empty cannot be replaced.
+func (e *Environment) RegisterOperator(name string, op ConditionOperator) error {
+	if !operatorName.MatchString(name) {
+		return NewArgumentError("operator name " + name + " is not a word")
+	}
+	if reservedOperatorNames[name] {
+		return NewArgumentError("operator " + name + " is built in")
+	}
+	e.operators[name] = op
+	return nil
+}
+
+// Operator returns the comparison operator registered under name, or nil.
+func (e *Environment) Operator(name string) ConditionOperator {
+	return e.operators[name]
+}
+
+// TagDecoder returns the decoder registered for the given tag name, or nil.
+func (e *Environment) TagDecoder(name string) TagDecoder {
+	return e.tagDecoders[name]
+}
+
 // RegisterFilter registers a new filter with the environment.
 func (e *Environment) RegisterFilter(filter interface{}) error {
 	// Clear cache
@@ -102,7 +151,7 @@ func (e *Environment) CreateStrainer(context interface{ Context() interface{} },
 
 	// Check cache first
 	if cached, ok := e.strainerTemplateClassCache[cacheKey]; ok {
-		return NewStrainerTemplate(cached, context, strictFilters)
+		return NewStrainerTemplateWithFilters(cached, context, strictFilters, filters)
 	}
 
 	// Create new class and cache it
@@ -142,6 +191,30 @@ func (e *Environment) SetFileSystem(fs FileSystem) {
 	e.fileSystem = fs
 }
 
+// PartialCache returns the partial cache shared by all renders, or nil.
+func (e *Environment) PartialCache() *SharedPartialCache {
+	return e.partialCache
+}
+
+// SetPartialCache sets the cache of parsed partials shared by all renders
+// using this environment. A nil cache (the default) disables sharing: partials
+// are then only cached for the duration of a render.
+func (e *Environment) SetPartialCache(cache *SharedPartialCache) {
+	e.partialCache = cache
+}
+
+// FragmentStore returns the store of cache tag fragments, or nil.
+func (e *Environment) FragmentStore() FragmentStore {
+	return e.fragmentStore
+}
+
+// SetFragmentStore sets the store used by cache tags in all renders using
+// this environment. A "fragment_store" register overrides it for a render.
+// Without a store (the default), cache tags render their body every time.
+func (e *Environment) SetFragmentStore(store FragmentStore) {
+	e.fragmentStore = store
+}
+
 // ExceptionRenderer returns the exception renderer.
 func (e *Environment) ExceptionRenderer() func(error) interface{} {
 	return e.exceptionRenderer
@@ -1,6 +1,7 @@
 package liquid
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -115,6 +116,27 @@ func TestEnvironmentStrainerCaching(t *testing.T) {
 	}
 }
 
+type shoutFilter struct{}
+
+func (f *shoutFilter) Shout(input interface{}) interface{} {
+	return ToS(input, nil) + "!"
+}
+
+// TestEnvironmentStrainerCachingKeepsFilters tests that a strainer built from a
+// cached class still gets the filter instances passed for it
+func TestEnvironmentStrainerCachingKeepsFilters(t *testing.T) {
+	env := NewEnvironment()
+	ctx := &mockContext{}
+	filters := []interface{}{&shoutFilter{}}
+
+	for i := 0; i < 2; i++ {
+		strainer := env.CreateStrainer(ctx, filters, false)
+		if got, err := strainer.Invoke("shout", "hi"); err != nil || got != "hi!" {
+			t.Errorf("strainer %d: Invoke(shout) = %v, %v, want hi!", i, got, err)
+		}
+	}
+}
+
 func TestNewEnvironmentWithStandardTags(t *testing.T) {
 	env := NewEnvironmentWithStandardTags()
 	if env == nil {
@@ -162,3 +184,34 @@ func TestEnvironmentRegisterFilters(t *testing.T) {
 		t.Error("Expected filter method names after RegisterFilters, got empty")
 	}
 }
+
+func TestEnvironmentRegisterOperator(t *testing.T) {
+	env := NewEnvironment()
+	if env.Operator("startswith") != nil {
+		t.Error("Expected no operator before registering")
+	}
+	if err := env.RegisterOperator("startswith", func(_ *Condition, left, right interface{}) (bool, error) {
+		return strings.HasPrefix(ToS(left, nil), ToS(right, nil)), nil
+	}); err != nil {
+		t.Fatalf("RegisterOperator() error = %v", err)
+	}
+	if env.Operator("startswith") == nil {
+		t.Error("Expected registered operator")
+	}
+	if NewEnvironment().Operator("startswith") != nil {
+		t.Error("Expected operators to be per environment")
+	}
+}
+
+func TestEnvironmentRegisterOperatorInvalidName(t *testing.T) {
+	env := NewEnvironment()
+	op := func(_ *Condition, left, right interface{}) (bool, error) { return true, nil }
+	for _, name := range []string{"contains", "==", "and", "empty", "starts with", ""} {
+		if err := env.RegisterOperator(name, op); err == nil {
+			t.Errorf("RegisterOperator(%q) error = nil, want an error", name)
+		}
+		if env.Operator(name) != nil {
+			t.Errorf("Operator(%q) registered", name)
+		}
+	}
+}
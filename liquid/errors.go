@@ -7,8 +7,12 @@ import (
 
 // Error is the base error type for all Liquid errors.
 type Error struct {
-	Message       string
-	LineNumber    *int
+	Message    string
+	LineNumber *int
+	// Span is where in the template the error was found, when known. Unlike
+	// LineNumber it is set without the LineNumbers option and is not part of
+	// the message.
+	Span          *Span
 	TemplateName  string
 	MarkupContext string
 }
@@ -370,3 +374,52 @@ func (e *TemplateEncodingError) Error() string {
 }
 
 func (e *TemplateEncodingError) GetError() *Error { return e.Err }
+
+// CanceledError is raised when a render is stopped because its context.Context
+// was canceled or its deadline passed. LineNumber and TemplateName report
+// where the render stopped.
+type CanceledError struct {
+	Err   *Error
+	Cause error
+}
+
+// NewCanceledError creates a new CanceledError wrapping the context's error.
+func NewCanceledError(cause error) *CanceledError {
+	message := "render canceled"
+	if cause != nil {
+		message += ": " + cause.Error()
+	}
+	return &CanceledError{
+		Err:   &Error{Message: message},
+		Cause: cause,
+	}
+}
+
+func (e *CanceledError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CanceledError) GetError() *Error { return e.Err }
+
+// Unwrap returns the context's error, so errors.Is(err, context.Canceled)
+// and errors.Is(err, context.DeadlineExceeded) work.
+func (e *CanceledError) Unwrap() error { return e.Cause }
+
+// CompiledTemplateError is raised when a template cannot be compiled with
+// Template.MarshalBinary or loaded with LoadCompiled.
+type CompiledTemplateError struct {
+	Err *Error
+}
+
+// NewCompiledTemplateError creates a new CompiledTemplateError with the given message.
+func NewCompiledTemplateError(message string) *CompiledTemplateError {
+	return &CompiledTemplateError{
+		Err: &Error{Message: message},
+	}
+}
+
+func (e *CompiledTemplateError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CompiledTemplateError) GetError() *Error { return e.Err }
@@ -43,15 +43,23 @@ func SafeParse(parser *Parser, ss *StringScanner, cache map[string]interface{})
 // Parse parses a markup string into an expression value.
 // Optimization: Uses global cache when local cache is nil for better performance across templates.
 func Parse(markup string, ss *StringScanner, cache map[string]interface{}) interface{} {
+	return parseExpression(markup, ss, cache, nil)
+}
+
+// parseExpression is Parse for an environment with registered comparison
+// operators (see Environment.RegisterOperator). Expressions using them are
+// never stored in the global cache, which is shared by all environments.
+func parseExpression(markup string, ss *StringScanner, cache map[string]interface{}, operators map[string]ConditionOperator) interface{} {
 	if markup == "" {
 		return nil
 	}
 
 	markup = strings.TrimSpace(markup)
 
-	// Handle quoted strings (fast path, don't cache)
-	if (strings.HasPrefix(markup, `"`) && strings.HasSuffix(markup, `"`)) ||
-		(strings.HasPrefix(markup, `'`) && strings.HasSuffix(markup, `'`)) {
+	// Handle quoted strings (fast path, don't cache), but not operations
+	// between strings such as 'a' + 'b'
+	if len(markup) >= 2 && (markup[0] == '"' || markup[0] == '\'') &&
+		markup[len(markup)-1] == markup[0] && strings.IndexByte(markup[1:len(markup)-1], markup[0]) < 0 {
 		return markup[1 : len(markup)-1]
 	}
 
@@ -65,22 +73,25 @@ func Parse(markup string, ss *StringScanner, cache map[string]interface{}) inter
 		if cached, ok := cache[markup]; ok {
 			return cached
 		}
-		result := innerParse(markup, ss, cache)
+		result := innerParse(markup, ss, cache, operators)
 		cache[markup] = result
 		return result
 	}
+	if len(operators) > 0 {
+		return innerParse(markup, ss, nil, operators)
+	}
 
 	// Use global cache when local cache is nil (cross-template caching)
 	if cached, ok := globalExprCache.Load(markup); ok {
 		return cached
 	}
 
-	result := innerParse(markup, ss, nil)
+	result := innerParse(markup, ss, nil, nil)
 	globalExprCache.Store(markup, result)
 	return result
 }
 
-func innerParse(markup string, ss *StringScanner, cache map[string]interface{}) interface{} {
+func innerParse(markup string, ss *StringScanner, cache map[string]interface{}, operators map[string]ConditionOperator) interface{} {
 	// Check for range expressions: (start..end)
 	if strings.HasPrefix(markup, "(") && strings.HasSuffix(markup, ")") {
 		matches := expressionRangesRegex.FindStringSubmatch(markup)
@@ -89,6 +100,23 @@ func innerParse(markup string, ss *StringScanner, cache map[string]interface{})
 		}
 	}
 
+	// Check for array and hash literals: [a, b] and {key: value}
+	switch markup[0] {
+	case '[':
+		if literal, ok := parseArrayLiteral(markup, ss, cache, operators); ok {
+			return literal
+		}
+	case '{':
+		if literal, ok := parseHashLiteral(markup, ss, cache, operators); ok {
+			return literal
+		}
+	}
+
+	// Check for operations: a + b, a > b, not a
+	if operation, ok := parseOperation(markup, ss, cache, operators); ok {
+		return operation
+	}
+
 	// Try to parse as number
 	if num := parseNumber(markup, ss); num != nil {
 		return num
@@ -1,8 +1,12 @@
 package liquid
 
 import (
+	"errors"
 	"fmt"
+	"hash/fnv"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -13,6 +17,11 @@ var (
 	TemplateNameRegex = regexp.MustCompile(`^[^./][a-zA-Z0-9_/]+$`)
 )
 
+// ParentTemplatePrefix makes a LayeredFileSystem skip the layer serving a
+// template: {% render 'parent:header' %} in an override of "header" renders
+// the "header" of the next layer down.
+const ParentTemplatePrefix = "parent:"
+
 // FileSystem is an interface for retrieving template files.
 type FileSystem interface {
 	ReadTemplateFile(templatePath string) (string, error)
@@ -64,6 +73,25 @@ func (l *LocalFileSystem) ReadTemplateFile(templatePath string) (string, error)
 	return string(data), nil
 }
 
+// TemplateVersion returns the modification time and size of a template file.
+// It implements VersionedFileSystem.
+func (l *LocalFileSystem) TemplateVersion(templatePath string) (string, error) {
+	fullPath, err := l.FullPath(templatePath)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", NewFileSystemError(fmt.Sprintf("No such template '%s'", templatePath))
+		}
+		return "", NewFileSystemError(fmt.Sprintf("Failed to read template '%s': %v", templatePath, err))
+	}
+
+	return fileVersion(fullPath, info), nil
+}
+
 // FullPath returns the full path to a template file.
 func (l *LocalFileSystem) FullPath(templatePath string) (string, error) {
 	if !TemplateNameRegex.MatchString(templatePath) {
@@ -96,3 +124,190 @@ func (l *LocalFileSystem) FullPath(templatePath string) (string, error) {
 
 	return fullPath, nil
 }
+
+// FSFileSystem retrieves template files from an fs.FS, such as an embed.FS,
+// os.DirFS or fstest.MapFS.
+// Each template name is tried against the candidate patterns in order, and
+// the first file that exists is used.
+type FSFileSystem struct {
+	fsys     fs.FS
+	patterns []string
+}
+
+// NewFSFileSystem creates a new FSFileSystem reading from fsys.
+// The patterns default to "_%s.liquid" if none are provided.
+func NewFSFileSystem(fsys fs.FS, patterns ...string) *FSFileSystem {
+	if len(patterns) == 0 {
+		patterns = []string{"_%s.liquid"}
+	}
+	return &FSFileSystem{
+		fsys:     fsys,
+		patterns: patterns,
+	}
+}
+
+// ReadTemplateFile reads a template file from the underlying fs.FS.
+func (f *FSFileSystem) ReadTemplateFile(templatePath string) (string, error) {
+	candidates, err := f.candidatePaths(templatePath)
+	if err != nil {
+		return "", err
+	}
+
+	for _, candidate := range candidates {
+		data, err := fs.ReadFile(f.fsys, candidate)
+		if err == nil {
+			return string(data), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", NewFileSystemError(fmt.Sprintf("Failed to read template '%s': %v", templatePath, err))
+		}
+	}
+
+	return "", NewFileSystemError(fmt.Sprintf("No such template '%s'", templatePath))
+}
+
+// FullPath returns the path of the first candidate file that exists in the fs.FS.
+func (f *FSFileSystem) FullPath(templatePath string) (string, error) {
+	candidates, err := f.candidatePaths(templatePath)
+	if err != nil {
+		return "", err
+	}
+
+	for _, candidate := range candidates {
+		if _, err := fs.Stat(f.fsys, candidate); err == nil {
+			return candidate, nil
+		}
+	}
+
+	return "", NewFileSystemError(fmt.Sprintf("No such template '%s'", templatePath))
+}
+
+// TemplateVersion returns the path, modification time and size of the file
+// serving a template. It implements VersionedFileSystem.
+func (f *FSFileSystem) TemplateVersion(templatePath string) (string, error) {
+	candidates, err := f.candidatePaths(templatePath)
+	if err != nil {
+		return "", err
+	}
+
+	for _, candidate := range candidates {
+		info, err := fs.Stat(f.fsys, candidate)
+		if err == nil {
+			return fileVersion(candidate, info), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", NewFileSystemError(fmt.Sprintf("Failed to read template '%s': %v", templatePath, err))
+		}
+	}
+
+	return "", NewFileSystemError(fmt.Sprintf("No such template '%s'", templatePath))
+}
+
+// fileVersion identifies the contents of a file by its path, modification time and size.
+func fileVersion(name string, info fs.FileInfo) string {
+	return fmt.Sprintf("%s:%d:%d", name, info.ModTime().UnixNano(), info.Size())
+}
+
+// candidatePaths returns the paths a template name maps to, one per pattern.
+func (f *FSFileSystem) candidatePaths(templatePath string) ([]string, error) {
+	if !TemplateNameRegex.MatchString(templatePath) {
+		return nil, NewFileSystemError(fmt.Sprintf("Illegal template name '%s'", templatePath))
+	}
+
+	dir, base := path.Split(templatePath)
+	candidates := make([]string, 0, len(f.patterns))
+	for _, pattern := range f.patterns {
+		candidate := path.Join(dir, fmt.Sprintf(pattern, base))
+		// Security check: fs.ValidPath rejects rooted paths and ".." elements
+		if !fs.ValidPath(candidate) {
+			return nil, NewFileSystemError(fmt.Sprintf("Illegal template path '%s'", candidate))
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates, nil
+}
+
+// LayeredFileSystem reads each template from the first of several file
+// systems that has it, e.g. tenant overrides, then a theme, then built-in
+// defaults. A name prefixed with ParentTemplatePrefix skips the first layer
+// having the template, once per prefix, so an override can wrap the template
+// it replaces.
+type LayeredFileSystem struct {
+	layers []FileSystem
+}
+
+// NewLayeredFileSystem creates a new LayeredFileSystem. Layers are searched in order.
+func NewLayeredFileSystem(layers ...FileSystem) *LayeredFileSystem {
+	return &LayeredFileSystem{layers: layers}
+}
+
+// Layers returns the file systems, in search order.
+func (l *LayeredFileSystem) Layers() []FileSystem {
+	return l.layers
+}
+
+// ReadTemplateFile reads a template from the first layer that has it.
+func (l *LayeredFileSystem) ReadTemplateFile(templatePath string) (string, error) {
+	source, _, err := l.Lookup(templatePath)
+	return source, err
+}
+
+// Lookup reads a template and returns the index of the layer that served it.
+func (l *LayeredFileSystem) Lookup(templatePath string) (string, int, error) {
+	skip, name := splitParentTemplateName(templatePath)
+
+	var firstErr error
+	for i, layer := range l.layers {
+		source, err := layer.ReadTemplateFile(name)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+		return source, i, nil
+	}
+
+	if firstErr != nil && templatePath == name {
+		return "", -1, firstErr
+	}
+	return "", -1, NewFileSystemError(fmt.Sprintf("No such template '%s'", templatePath))
+}
+
+// TemplateVersion returns the serving layer and the version reported by it,
+// or a hash of the source when the layer is not a VersionedFileSystem.
+// It implements VersionedFileSystem.
+func (l *LayeredFileSystem) TemplateVersion(templatePath string) (string, error) {
+	skip, name := splitParentTemplateName(templatePath)
+
+	for i, layer := range l.layers {
+		var version string
+		if versioned, ok := layer.(VersionedFileSystem); ok {
+			v, err := versioned.TemplateVersion(name)
+			if err != nil {
+				continue
+			}
+			version = v
+		} else {
+			source, err := layer.ReadTemplateFile(name)
+			if err != nil {
+				continue
+			}
+			hash := fnv.New64a()
+			hash.Write([]byte(source))
+			version = fmt.Sprintf("%x", hash.Sum64())
+		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+		return fmt.Sprintf("%d:%s", i, version), nil
+	}
+
+	return "", NewFileSystemError(fmt.Sprintf("No such template '%s'", templatePath))
+}
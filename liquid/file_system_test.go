@@ -1,10 +1,13 @@
 package liquid
 
 import (
+	"errors"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"testing/fstest"
 )
 
 func TestBlankFileSystem(t *testing.T) {
@@ -251,3 +254,246 @@ func TestLocalFileSystemFullPathAbsolutePath(t *testing.T) {
 		t.Errorf("Path %s is not within root %s", fullPath, absRoot)
 	}
 }
+
+func TestFSFileSystem(t *testing.T) {
+	fsys := fstest.MapFS{
+		"_header.liquid":        {Data: []byte("header")},
+		"products/_card.liquid": {Data: []byte("card")},
+		"products/_card.html":   {Data: []byte("ignored")},
+		"layouts/theme.liquid":  {Data: []byte("theme")},
+	}
+
+	fileSystem := NewFSFileSystem(fsys)
+	for name, want := range map[string]string{"header": "header", "products/card": "card"} {
+		got, err := fileSystem.ReadTemplateFile(name)
+		if err != nil {
+			t.Fatalf("ReadTemplateFile(%q) error = %v", name, err)
+		}
+		if got != want {
+			t.Errorf("ReadTemplateFile(%q) = %q, want %q", name, got, want)
+		}
+	}
+
+	if _, err := fileSystem.ReadTemplateFile("layouts/theme"); err == nil {
+		t.Error("Expected error for a template not matching the default pattern")
+	}
+}
+
+func TestFSFileSystemMultiplePatterns(t *testing.T) {
+	fsys := fstest.MapFS{
+		"header.liquid":              {Data: []byte("plain")},
+		"_header.liquid":             {Data: []byte("partial")},
+		"_footer.liquid":             {Data: []byte("footer")},
+		"sections/hero/index.liquid": {Data: []byte("hero")},
+	}
+	fileSystem := NewFSFileSystem(fsys, "%s.liquid", "_%s.liquid", "%s/index.liquid")
+
+	tests := map[string]string{
+		"header":        "plain",
+		"footer":        "footer",
+		"sections/hero": "hero",
+	}
+	for name, want := range tests {
+		got, err := fileSystem.ReadTemplateFile(name)
+		if err != nil {
+			t.Fatalf("ReadTemplateFile(%q) error = %v", name, err)
+		}
+		if got != want {
+			t.Errorf("ReadTemplateFile(%q) = %q, want %q", name, got, want)
+		}
+	}
+
+	fullPath, err := fileSystem.FullPath("sections/hero")
+	if err != nil {
+		t.Fatalf("FullPath() error = %v", err)
+	}
+	if fullPath != "sections/hero/index.liquid" {
+		t.Errorf("FullPath() = %q, want %q", fullPath, "sections/hero/index.liquid")
+	}
+
+	_, err = fileSystem.ReadTemplateFile("missing")
+	if err == nil || !strings.Contains(err.Error(), "No such template 'missing'") {
+		t.Errorf("Expected missing template error, got %v", err)
+	}
+	if _, err := fileSystem.FullPath("missing"); err == nil {
+		t.Error("Expected FullPath error for missing template")
+	}
+}
+
+func TestFSFileSystemIllegalNames(t *testing.T) {
+	fileSystem := NewFSFileSystem(fstest.MapFS{})
+	for _, name := range []string{"../secret", "/etc/passwd", ".hidden", "a.b"} {
+		if _, err := fileSystem.ReadTemplateFile(name); err == nil || !strings.Contains(err.Error(), "Illegal template name") {
+			t.Errorf("ReadTemplateFile(%q) error = %v, want illegal template name", name, err)
+		}
+	}
+}
+
+func TestFSFileSystemRejectsInvalidPaths(t *testing.T) {
+	fileSystem := NewFSFileSystem(fstest.MapFS{}, "../%s.liquid")
+	_, err := fileSystem.ReadTemplateFile("secret")
+	if err == nil || !strings.Contains(err.Error(), "Illegal template path") {
+		t.Errorf("Expected illegal template path error, got %v", err)
+	}
+}
+
+// failingFS fails every read with a permission error.
+type failingFS struct{}
+
+func (failingFS) Open(name string) (fs.File, error) {
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+}
+
+func TestFSFileSystemReadError(t *testing.T) {
+	_, err := NewFSFileSystem(failingFS{}).ReadTemplateFile("header")
+	var fsErr *FileSystemError
+	if !errors.As(err, &fsErr) || !strings.Contains(err.Error(), "Failed to read template 'header'") {
+		t.Errorf("Expected read failure, got %v", err)
+	}
+}
+
+func TestFSFileSystemWithDirFS(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "_greeting.liquid"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := NewFSFileSystem(os.DirFS(tmpDir)).ReadTemplateFile("greeting")
+	if err != nil {
+		t.Fatalf("ReadTemplateFile() error = %v", err)
+	}
+	if got != "hello" {
+		t.Errorf("ReadTemplateFile() = %q, want %q", got, "hello")
+	}
+}
+
+func TestLocalFileSystemTemplateVersion(t *testing.T) {
+	tmpDir := t.TempDir()
+	fileSystem := NewLocalFileSystem(tmpDir, "")
+	path := filepath.Join(tmpDir, "_card.liquid")
+	if err := os.WriteFile(path, []byte("v1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := fileSystem.TemplateVersion("card")
+	if err != nil {
+		t.Fatalf("TemplateVersion() error = %v", err)
+	}
+	if err := os.WriteFile(path, []byte("version 2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	second, err := fileSystem.TemplateVersion("card")
+	if err != nil {
+		t.Fatalf("TemplateVersion() error = %v", err)
+	}
+	if first == second {
+		t.Errorf("Expected the version to change with the file, got %q twice", first)
+	}
+
+	if _, err := fileSystem.TemplateVersion("missing"); err == nil {
+		t.Error("Expected error for missing template")
+	}
+}
+
+func TestFSFileSystemTemplateVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"_card.liquid": {Data: []byte("card")},
+	}
+	fileSystem := NewFSFileSystem(fsys, "%s.liquid", "_%s.liquid")
+
+	first, err := fileSystem.TemplateVersion("card")
+	if err != nil {
+		t.Fatalf("TemplateVersion() error = %v", err)
+	}
+
+	// A file matching an earlier pattern now serves the template
+	fsys["card.liquid"] = &fstest.MapFile{Data: []byte("card")}
+	second, err := fileSystem.TemplateVersion("card")
+	if err != nil {
+		t.Fatalf("TemplateVersion() error = %v", err)
+	}
+	if first == second {
+		t.Errorf("Expected the version to change with the serving file, got %q twice", first)
+	}
+
+	if _, err := fileSystem.TemplateVersion("missing"); err == nil {
+		t.Error("Expected error for missing template")
+	}
+}
+
+func TestLayeredFileSystem(t *testing.T) {
+	tenant := fstest.MapFS{"_header.liquid": {Data: []byte("tenant header")}}
+	theme := fstest.MapFS{
+		"_header.liquid": {Data: []byte("theme header")},
+		"_footer.liquid": {Data: []byte("theme footer")},
+	}
+	defaults := &mockFileSystemWithTemplate{template: "default"}
+	fileSystem := NewLayeredFileSystem(NewFSFileSystem(tenant), NewFSFileSystem(theme), defaults)
+
+	tests := []struct {
+		name   string
+		source string
+		layer  int
+	}{
+		{"header", "tenant header", 0},
+		{"footer", "theme footer", 1},
+		{"logo", "default", 2},
+		{"parent:header", "theme header", 1},
+		{"parent:parent:header", "default", 2},
+		{"parent:footer", "default", 2},
+	}
+	for _, tt := range tests {
+		source, layer, err := fileSystem.Lookup(tt.name)
+		if err != nil {
+			t.Fatalf("Lookup(%q) error = %v", tt.name, err)
+		}
+		if source != tt.source || layer != tt.layer {
+			t.Errorf("Lookup(%q) = %q from layer %d, want %q from layer %d", tt.name, source, layer, tt.source, tt.layer)
+		}
+	}
+
+	if _, err := fileSystem.ReadTemplateFile("parent:parent:parent:header"); err == nil || !strings.Contains(err.Error(), "No such template 'parent:parent:parent:header'") {
+		t.Errorf("Expected error past the last layer, got %v", err)
+	}
+	if len(fileSystem.Layers()) != 3 {
+		t.Errorf("Layers() = %d layers, want 3", len(fileSystem.Layers()))
+	}
+}
+
+func TestLayeredFileSystemMissingTemplate(t *testing.T) {
+	fileSystem := NewLayeredFileSystem(NewFSFileSystem(fstest.MapFS{}), &BlankFileSystem{})
+	_, err := fileSystem.ReadTemplateFile("missing")
+	if err == nil || !strings.Contains(err.Error(), "No such template 'missing'") {
+		t.Errorf("Expected the first layer's error, got %v", err)
+	}
+}
+
+func TestLayeredFileSystemTemplateVersion(t *testing.T) {
+	tenant := fstest.MapFS{}
+	defaults := &mockFileSystemWithTemplate{template: "default"}
+	fileSystem := NewLayeredFileSystem(NewFSFileSystem(tenant), defaults)
+
+	fromDefaults, err := fileSystem.TemplateVersion("header")
+	if err != nil {
+		t.Fatalf("TemplateVersion() error = %v", err)
+	}
+	defaults.template = "changed"
+	changed, _ := fileSystem.TemplateVersion("header")
+	if changed == fromDefaults {
+		t.Error("Expected the version to change with the source of an unversioned layer")
+	}
+
+	tenant["_header.liquid"] = &fstest.MapFile{Data: []byte("override")}
+	overridden, _ := fileSystem.TemplateVersion("header")
+	if !strings.HasPrefix(overridden, "0:") {
+		t.Errorf("TemplateVersion() = %q, want a version from layer 0", overridden)
+	}
+	parent, _ := fileSystem.TemplateVersion("parent:header")
+	if parent != changed {
+		t.Errorf("TemplateVersion(parent:header) = %q, want %q", parent, changed)
+	}
+
+	if _, err := fileSystem.TemplateVersion("parent:parent:header"); err == nil {
+		t.Error("Expected error past the last layer")
+	}
+}
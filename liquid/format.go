@@ -0,0 +1,333 @@
+package liquid
+
+import (
+	"bytes"
+	"strings"
+)
+
+// FormatOptions configures Format.
+type FormatOptions struct {
+	// Environment provides the tags; nil uses a new default environment.
+	Environment *Environment
+	// Indent is the indentation of one nesting level ("" means two spaces).
+	Indent string
+}
+
+// formatPass selects what a formatting attempt may change.
+type formatPass struct {
+	canonicalMarkup bool // Canonical spacing inside {{ }} and {% %}, instead of only collapsing whitespace
+	trimmedSpace    bool // Reindent whitespace removed by - trim markers
+	blankSpace      bool // Reindent whitespace-only text, which blank blocks drop
+}
+
+var formatPasses = []formatPass{
+	{canonicalMarkup: true, trimmedSpace: true, blankSpace: true},
+	{canonicalMarkup: true, trimmedSpace: true},
+	{trimmedSpace: true, blankSpace: true},
+	{trimmedSpace: true},
+	{canonicalMarkup: true},
+	{},
+}
+
+// Format parses source and prints it again in canonical form: markup inside
+// {{ }} and {% %} is respaced (e.g. {{ a | f: 1, 2 }}), and whitespace that is
+// never rendered, because it is removed by a - trim marker or belongs to a
+// blank block such as an if containing only assign tags, is reindented by
+// block nesting. Text that renders, raw, comment and doc bodies, {% liquid %}
+// tags and tokens spanning several lines are kept byte for byte, so line
+// numbers do not change.
+//
+// The formatted source is parsed again and its parse tree compared with the
+// original one; changes that would alter it are given up, so Format never
+// changes render output. It returns an error when source does not parse, or
+// when the comparison is not possible because a tag does not implement
+// MarshalableNode.
+func Format(source string, options FormatOptions) (string, error) {
+	indent := options.Indent
+	if indent == "" {
+		indent = "  "
+	}
+	templateOptions := &TemplateOptions{Environment: options.Environment, LineNumbers: true}
+
+	template := NewTemplate(templateOptions)
+	if !isValidUTF8(source) {
+		// Parse reports the encoding error
+		return "", template.Parse(source, templateOptions)
+	}
+	parseContext := template.configureOptions(templateOptions)
+	startLineNumber := 1
+	tokenizer := parseContext.NewTokenizer(source, false, &startLineNumber, false)
+	tokens := append([]string(nil), tokenizer.tokens...)
+	tokenizer.trace = &formatTrace{depths: make([]int, len(tokens))}
+	root, err := ParseDocument(tokenizer, parseContext)
+	if err != nil {
+		return "", err
+	}
+	template.root = root
+	want, err := formatStructure(template)
+	if err != nil {
+		return "", err
+	}
+	if strings.Join(tokens, "") != source {
+		return source, nil
+	}
+
+	f := &formatter{
+		environment: template.environment,
+		indent:      indent,
+		tokens:      tokens,
+		depths:      tokenizer.trace.depths,
+	}
+	for _, pass := range formatPasses {
+		formatted := f.print(pass)
+		if formatted == source {
+			return source, nil
+		}
+		got, err := ParseTemplate(formatted, templateOptions)
+		if err != nil {
+			continue
+		}
+		if structure, err := formatStructure(got); err == nil && bytes.Equal(structure, want) {
+			return formatted, nil
+		}
+	}
+	return source, nil
+}
+
+// formatStructure encodes the parse tree of t without markup and spans, so
+// that templates differing only in markup spacing compare equal.
+func formatStructure(t *Template) ([]byte, error) {
+	enc := &NodeEncoder{skipMarkup: true}
+	enc.Encode(t.root.body)
+	return enc.buf, enc.err
+}
+
+type formatter struct {
+	environment *Environment
+	indent      string
+	tokens      []string
+	depths      []int // Block body depth of each token, 0 when a tag consumed it
+}
+
+// print returns the source with the changes pass allows.
+func (f *formatter) print(pass formatPass) string {
+	var b strings.Builder
+	for i, token := range f.tokens {
+		switch {
+		case f.depths[i] == 0:
+			b.WriteString(token)
+		case strings.HasPrefix(token, blockBodyTAGSTART):
+			b.WriteString(formatTagToken(token, pass.canonicalMarkup))
+		case strings.HasPrefix(token, blockBodyVARSTART):
+			b.WriteString(formatVariableToken(token, pass.canonicalMarkup))
+		default:
+			b.WriteString(f.text(i, pass))
+		}
+	}
+	return b.String()
+}
+
+// text returns text token i with its unrendered whitespace reindented.
+func (f *formatter) text(i int, pass formatPass) string {
+	token := f.tokens[i]
+	trimLeft := pass.trimmedSpace && i > 0 && f.depths[i-1] > 0 && formatIsMarkup(f.tokens[i-1]) &&
+		f.tokens[i-1][len(f.tokens[i-1])-3] == '-'
+	trimRight := pass.trimmedSpace && i+1 < len(f.tokens) && f.depths[i+1] > 0 && formatIsMarkup(f.tokens[i+1]) &&
+		f.tokens[i+1][2] == '-'
+	next := -1
+	if i+1 < len(f.tokens) && f.depths[i+1] > 0 {
+		next = f.level(i + 1)
+	}
+
+	content := strings.Trim(token, formatTrimmedSpace)
+	if content == "" {
+		if (trimLeft || trimRight || pass.blankSpace) && next >= 0 {
+			return f.reindent(token, next)
+		}
+		return token
+	}
+
+	start := len(token) - len(strings.TrimLeft(token, formatTrimmedSpace))
+	leading, trailing := token[:start], token[start+len(content):]
+	if trimLeft {
+		leading = f.reindent(leading, f.depths[i]-1)
+	}
+	if trimRight && next >= 0 {
+		trailing = f.reindent(trailing, next)
+	}
+	return leading + content + trailing
+}
+
+// level returns the indentation level of token i: its block body depth, one
+// less for delimiters such as else and endif.
+func (f *formatter) level(i int) int {
+	level := f.depths[i] - 1
+	if matches := blockBodyFullToken.FindStringSubmatch(f.tokens[i]); len(matches) > 0 {
+		if f.environment.TagForName(matches[2]) == nil && matches[2] != "liquid" {
+			level--
+		}
+	}
+	if level < 0 {
+		return 0
+	}
+	return level
+}
+
+// reindent replaces whitespace containing newlines with the same newlines
+// followed by level indents.
+func (f *formatter) reindent(space string, level int) string {
+	lines := strings.Count(space, "\n")
+	if lines == 0 {
+		return space
+	}
+	newline := "\n"
+	if strings.Contains(space, "\r\n") {
+		newline = "\r\n"
+	}
+	return strings.Repeat(newline, lines) + strings.Repeat(f.indent, level)
+}
+
+// formatTrimmedSpace is the whitespace removed by - trim markers.
+const formatTrimmedSpace = " \t\n\r"
+
+func formatIsMarkup(token string) bool {
+	return len(token) >= 4 && (strings.HasPrefix(token, blockBodyTAGSTART) || strings.HasPrefix(token, blockBodyVARSTART))
+}
+
+// formatTagToken prints a {% %} token as {% name markup %}, keeping trim markers.
+func formatTagToken(token string, canonical bool) string {
+	if strings.Contains(token, "\n") {
+		return token
+	}
+	matches := blockBodyFullToken.FindStringSubmatch(token)
+	if len(matches) == 0 || matches[2] == "liquid" || matches[2] == "#" {
+		return token
+	}
+	open, close := formatTrimMarkers(token, "{%", "%}")
+	markup := formatMarkup(matches[4], canonical)
+	if markup != "" {
+		markup = " " + markup
+	}
+	return open + " " + matches[2] + markup + " " + close
+}
+
+// formatVariableToken prints a {{ }} token as {{ markup }}, keeping trim markers.
+func formatVariableToken(token string, canonical bool) string {
+	if strings.Contains(token, "\n") || !strings.HasSuffix(token, "}}") || len(token) < 4 {
+		return token
+	}
+	open, close := formatTrimMarkers(token, "{{", "}}")
+	markup := token[len(open) : len(token)-len(close)]
+	if strings.TrimSpace(markup) == "" {
+		return token
+	}
+	return open + " " + formatMarkup(markup, canonical) + " " + close
+}
+
+func formatTrimMarkers(token, open, close string) (string, string) {
+	if len(token) > 4 && token[2] == '-' {
+		open += "-"
+	}
+	if len(token) > len(open)+2 && token[len(token)-3] == '-' {
+		close = "-" + close
+	}
+	return open, close
+}
+
+// formatToken is a word, quoted string or punctuation in markup.
+type formatToken struct {
+	text        string
+	spaceBefore bool // Whitespace preceded the token in the source
+}
+
+// formatOperators are spaced on both sides in canonical markup.
+var formatOperators = map[string]bool{
+	"|": true, "=": true, "==": true, "!=": true, "<>": true, "<": true, ">": true, "<=": true, ">=": true,
+	"+": true, "*": true, "/": true, "%": true,
+}
+
+// formatMarkup respaces markup. Canonical spacing has one space around
+// operators and pipes and after commas and colons, and none inside brackets
+// or around dots; otherwise whitespace runs are collapsed to one space.
+func formatMarkup(markup string, canonical bool) string {
+	tokens := scanFormatTokens(markup)
+	var b strings.Builder
+	for i, token := range tokens {
+		if i > 0 && formatSpaceBetween(tokens[i-1].text, token, canonical) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(token.text)
+	}
+	return b.String()
+}
+
+func formatSpaceBetween(prev string, token formatToken, canonical bool) bool {
+	if !canonical {
+		return token.spaceBefore
+	}
+	switch {
+	case prev == "." || prev == ".." || prev == "(" || prev == "[" || prev == "{":
+		return false
+	case token.text == "." || token.text == ".." || token.text == ")" || token.text == "]" ||
+		token.text == "}" || token.text == "," || token.text == ":":
+		return false
+	case prev == "," || prev == ":" || formatOperators[prev] || formatOperators[token.text]:
+		return true
+	}
+	return token.spaceBefore
+}
+
+// scanFormatTokens splits markup into tokens, like the expression lexer but
+// keeping unknown characters.
+func scanFormatTokens(markup string) []formatToken {
+	tokens := []formatToken{}
+	space := false
+	for pos := 0; pos < len(markup); {
+		c := markup[pos]
+		start := pos
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v':
+			space = true
+			pos++
+			continue
+		case c == '\'' || c == '"':
+			if end := strings.IndexByte(markup[pos+1:], c); end >= 0 {
+				pos += end + 2
+			} else {
+				pos = len(markup)
+			}
+		case formatIsWordByte(c) || (c == '-' && pos+1 < len(markup) && isNumberByte(markup[pos+1])):
+			pos++
+			for pos < len(markup) && (formatIsWordByte(markup[pos]) || markup[pos] == '-' || markup[pos] == '?') {
+				pos++
+			}
+			// Decimals: 1.5, but not the range 1..5
+			if formatIsInteger(markup[start:pos]) && pos+1 < len(markup) && markup[pos] == '.' && isNumberByte(markup[pos+1]) {
+				pos++
+				for pos < len(markup) && isNumberByte(markup[pos]) {
+					pos++
+				}
+			}
+		default:
+			pos++
+			if pos < len(markup) {
+				switch markup[start : pos+1] {
+				case "..", "==", "!=", "<=", ">=", "<>":
+					pos++
+				}
+			}
+		}
+		tokens = append(tokens, formatToken{text: markup[start:pos], spaceBefore: space})
+		space = false
+	}
+	return tokens
+}
+
+func formatIsInteger(word string) bool {
+	digits := strings.TrimPrefix(word, "-")
+	return digits != "" && strings.Trim(digits, "0123456789") == ""
+}
+
+func formatIsWordByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || isNumberByte(c) || c >= 0x80
+}
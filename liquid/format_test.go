@@ -0,0 +1,70 @@
+package liquid
+
+import "testing"
+
+func TestFormatVariables(t *testing.T) {
+	tests := map[string]string{
+		"{{x}}":                                 "{{ x }}",
+		"{{-  x|upcase  -}}":                    "{{- x | upcase -}}",
+		"{{ a | f:1 , 'b' | g: c:2 }}":          "{{ a | f: 1, 'b' | g: c: 2 }}",
+		"{{ a[ 0 ].b  }}":                       "{{ a[0].b }}",
+		"{{ ( 1 .. 3 ) | join:', ' }}":          "{{ (1..3) | join: ', ' }}",
+		"{{ price*qty+1>=total }}":              "{{ price * qty + 1 >= total }}",
+		"{{ x > -1 }} {{ -1.5|abs }} {{ a-b }}": "{{ x > -1 }} {{ -1.5 | abs }} {{ a-b }}",
+		"{{ 'a  |  b' }}":                       "{{ 'a  |  b' }}",
+		"{{ }}":                                 "{{ }}",
+	}
+	for source, want := range tests {
+		got, err := Format(source, FormatOptions{})
+		if err != nil {
+			t.Errorf("Format(%q) error = %v", source, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("Format(%q) = %q, want %q", source, got, want)
+		}
+	}
+}
+
+func TestFormatKeepsRenderedText(t *testing.T) {
+	source := "<p>\n    {{ a }}\n  </p>\n{{- b -}}\n   c\n"
+	got, err := Format(source, FormatOptions{})
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if want := "<p>\n    {{ a }}\n  </p>\n{{- b -}}\nc\n"; got != want {
+		t.Errorf("Format() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatKeepsMultilineTokens(t *testing.T) {
+	source := "{{ a\n  |  upcase }}\n{{a}}"
+	got, err := Format(source, FormatOptions{})
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if want := "{{ a\n  |  upcase }}\n{{ a }}"; got != want {
+		t.Errorf("Format() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	env := NewEnvironment()
+	env.SetErrorMode("strict")
+	if _, err := Format("{{ a | }}", FormatOptions{Environment: env}); err == nil {
+		t.Error("Format() expected a syntax error")
+	}
+	if _, err := Format("\xff{{ a }}", FormatOptions{}); err == nil {
+		t.Error("Format() expected an encoding error")
+	}
+}
+
+func TestFormatUnmarshalableTag(t *testing.T) {
+	env := NewEnvironment()
+	env.RegisterTag("custom", func(tagName, markup string, parseContext ParseContextInterface) (interface{}, error) {
+		return NewTag(tagName, markup, parseContext), nil
+	})
+	if _, err := Format("{% custom %}{{a}}", FormatOptions{Environment: env}); err == nil {
+		t.Error("Format() expected an error for a tag that cannot be compiled")
+	}
+}
@@ -0,0 +1,159 @@
+package liquid
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// FragmentStore stores the output of cache tags across renders. Stores are
+// used concurrently by all the renders of an environment and must be safe
+// for concurrent use.
+type FragmentStore interface {
+	// Get returns the fragment stored under key, if it has not expired.
+	Get(key string) (string, bool)
+	// Set stores a fragment under key. A ttl of 0 means it does not expire.
+	Set(key, fragment string, ttl time.Duration)
+}
+
+// MemoryFragmentStoreOptions configures a MemoryFragmentStore.
+type MemoryFragmentStoreOptions struct {
+	// MaxEntries is the maximum number of stored fragments (0 means no limit).
+	MaxEntries int
+	// MaxBytes is the maximum total size of stored fragments (0 means no limit).
+	MaxBytes int
+}
+
+// FragmentStoreStats reports the activity of a MemoryFragmentStore.
+type FragmentStoreStats struct {
+	Hits        uint64
+	Misses      uint64
+	Evictions   uint64 // Fragments removed to respect the size limits
+	Expirations uint64 // Fragments removed because their ttl elapsed
+	Entries     int
+	Bytes       int
+}
+
+// MemoryFragmentStore is an in-memory FragmentStore with LRU eviction.
+// Expired fragments are removed when they are next looked up.
+//
+// MemoryFragmentStore is safe for concurrent use.
+type MemoryFragmentStore struct {
+	mu      sync.Mutex
+	options MemoryFragmentStoreOptions
+	entries map[string]*list.Element
+	lru     *list.List // Front is the most recently used fragment
+	bytes   int
+	stats   FragmentStoreStats
+	now     func() time.Time
+}
+
+type fragmentEntry struct {
+	key      string
+	fragment string
+	expires  time.Time // Zero when the fragment does not expire
+}
+
+// NewMemoryFragmentStore creates a new MemoryFragmentStore.
+func NewMemoryFragmentStore(options MemoryFragmentStoreOptions) *MemoryFragmentStore {
+	return &MemoryFragmentStore{
+		options: options,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		now:     time.Now,
+	}
+}
+
+// Get returns the fragment stored under key, if it has not expired.
+func (s *MemoryFragmentStore) Get(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	element, ok := s.entries[key]
+	if !ok {
+		s.stats.Misses++
+		return "", false
+	}
+	entry := element.Value.(*fragmentEntry)
+	if !entry.expires.IsZero() && !s.now().Before(entry.expires) {
+		s.remove(element)
+		s.stats.Expirations++
+		s.stats.Misses++
+		return "", false
+	}
+	s.lru.MoveToFront(element)
+	s.stats.Hits++
+	return entry.fragment, true
+}
+
+// Set stores a fragment under key. A ttl of 0 means it does not expire.
+// Fragments larger than MaxBytes are not stored.
+func (s *MemoryFragmentStore) Set(key, fragment string, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if element, ok := s.entries[key]; ok {
+		s.remove(element)
+	}
+	if s.options.MaxBytes > 0 && len(fragment) > s.options.MaxBytes {
+		return
+	}
+
+	entry := &fragmentEntry{key: key, fragment: fragment}
+	if ttl > 0 {
+		entry.expires = s.now().Add(ttl)
+	}
+	s.entries[key] = s.lru.PushFront(entry)
+	s.bytes += len(fragment)
+
+	for s.overLimit() {
+		s.remove(s.lru.Back())
+		s.stats.Evictions++
+	}
+}
+
+// Delete removes the fragment stored under key.
+func (s *MemoryFragmentStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if element, ok := s.entries[key]; ok {
+		s.remove(element)
+	}
+}
+
+// Clear removes all stored fragments. Statistics are kept.
+func (s *MemoryFragmentStore) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = make(map[string]*list.Element)
+	s.lru.Init()
+	s.bytes = 0
+}
+
+// Len returns the number of stored fragments, including expired ones not yet removed.
+func (s *MemoryFragmentStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lru.Len()
+}
+
+// Stats returns a snapshot of the store statistics.
+func (s *MemoryFragmentStore) Stats() FragmentStoreStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.stats
+	stats.Entries = s.lru.Len()
+	stats.Bytes = s.bytes
+	return stats
+}
+
+func (s *MemoryFragmentStore) overLimit() bool {
+	if s.options.MaxEntries > 0 && s.lru.Len() > s.options.MaxEntries {
+		return true
+	}
+	return s.options.MaxBytes > 0 && s.bytes > s.options.MaxBytes
+}
+
+func (s *MemoryFragmentStore) remove(element *list.Element) {
+	entry := s.lru.Remove(element).(*fragmentEntry)
+	delete(s.entries, entry.key)
+	s.bytes -= len(entry.fragment)
+}
@@ -0,0 +1,70 @@
+package liquid
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryFragmentStoreLRU(t *testing.T) {
+	store := NewMemoryFragmentStore(MemoryFragmentStoreOptions{MaxEntries: 2})
+	store.Set("a", "1", 0)
+	store.Set("b", "2", 0)
+	if _, ok := store.Get("a"); !ok {
+		t.Fatal("Get(a) missed")
+	}
+	store.Set("c", "3", 0)
+
+	if _, ok := store.Get("b"); ok {
+		t.Error("Get(b) should miss after eviction")
+	}
+	if fragment, ok := store.Get("a"); !ok || fragment != "1" {
+		t.Errorf("Get(a) = %q, %v", fragment, ok)
+	}
+	stats := store.Stats()
+	if stats.Hits != 2 || stats.Misses != 1 || stats.Evictions != 1 || stats.Entries != 2 || stats.Bytes != 2 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+
+	store.Delete("a")
+	store.Clear()
+	if store.Len() != 0 || store.Stats().Bytes != 0 {
+		t.Errorf("Len() = %d after Clear", store.Len())
+	}
+}
+
+func TestMemoryFragmentStoreMaxBytes(t *testing.T) {
+	store := NewMemoryFragmentStore(MemoryFragmentStoreOptions{MaxBytes: 5})
+	store.Set("big", "123456", 0)
+	if store.Len() != 0 {
+		t.Error("A fragment larger than MaxBytes should not be stored")
+	}
+	store.Set("a", "123", 0)
+	store.Set("b", "45", 0)
+	store.Set("c", "6", 0)
+	if _, ok := store.Get("a"); ok {
+		t.Error("Get(a) should miss after eviction")
+	}
+	if got := store.Stats().Bytes; got != 3 {
+		t.Errorf("Bytes = %d, want 3", got)
+	}
+}
+
+func TestMemoryFragmentStoreExpiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryFragmentStore(MemoryFragmentStoreOptions{})
+	store.now = func() time.Time { return now }
+
+	store.Set("short", "x", time.Minute)
+	store.Set("forever", "y", 0)
+	now = now.Add(time.Minute)
+
+	if _, ok := store.Get("short"); ok {
+		t.Error("Get(short) should miss once expired")
+	}
+	if _, ok := store.Get("forever"); !ok {
+		t.Error("Get(forever) should hit")
+	}
+	if stats := store.Stats(); stats.Expirations != 1 || stats.Entries != 1 {
+		t.Errorf("Stats() = %+v", stats)
+	}
+}
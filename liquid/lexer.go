@@ -14,7 +14,6 @@ var (
 	lexerColon                        = Token{":colon", ":"}
 	lexerComma                        = Token{":comma", ","}
 	lexerComparisonNotEqual           = Token{":comparison", "!="}
-	lexerComparisonContains           = Token{":comparison", "contains"}
 	lexerComparisonEqual              = Token{":comparison", "=="}
 	lexerComparisonGreaterThan        = Token{":comparison", ">"}
 	lexerComparisonGreaterThanOrEqual = Token{":comparison", ">="}
@@ -29,10 +28,13 @@ var (
 	lexerQuestion                     = Token{":question", "?"}
 	lexerOpenRound                    = Token{":open_round", "("}
 	lexerOpenSquare                   = Token{":open_square", "["}
+	lexerOpenCurly                    = Token{":open_curly", "{"}
+	lexerCloseCurly                   = Token{":close_curly", "}"}
 	// Arithmetic operators
 	lexerPlus   = Token{":plus", "+"}
 	lexerTimes  = Token{":times", "*"}
 	lexerDivide = Token{":divide", "/"}
+	lexerModulo = Token{":modulo", "%"}
 )
 
 var (
@@ -44,7 +46,9 @@ var (
 )
 
 // Lexer tokenizes expressions.
-type Lexer struct{}
+type Lexer struct {
+	operators map[string]ConditionOperator // Registered comparison operators, tokenized like contains
+}
 
 // Tokenize tokenizes the input string scanner and returns a slice of tokens.
 func (l *Lexer) Tokenize(ss *StringScanner) ([]Token, error) {
@@ -123,11 +127,12 @@ func (l *Lexer) Tokenize(ss *StringScanner) ([]Token, error) {
 				tokenType := typeAndPattern[0].(string)
 				pattern := typeAndPattern[1].(*regexp.Regexp)
 				if t := ss.Scan(pattern); t != "" {
-					// Special case for "contains" - it's a comparison operator unless preceded by a dot
-					if tokenType == ":id" && t == "contains" {
+					// Special case for "contains" and registered operators - they are
+					// comparison operators unless preceded by a dot
+					if _, registered := l.operators[t]; tokenType == ":id" && (t == "contains" || registered) {
 						isAfterDot := len(output) > 0 && output[len(output)-1][0] == ":dot"
 						if !isAfterDot {
-							output = append(output, lexerComparisonContains)
+							output = append(output, Token{":comparison", t})
 						} else {
 							output = append(output, Token{tokenType, t})
 						}
@@ -167,6 +172,10 @@ func getSpecialToken(b byte) Token {
 		return lexerOpenSquare
 	case ']':
 		return lexerCloseSquare
+	case '{':
+		return lexerOpenCurly
+	case '}':
+		return lexerCloseCurly
 	case '(':
 		return lexerOpenRound
 	case ')':
@@ -181,6 +190,8 @@ func getSpecialToken(b byte) Token {
 		return lexerTimes
 	case '/':
 		return lexerDivide
+	case '%':
+		return lexerModulo
 	default:
 		return Token{}
 	}
@@ -1,6 +1,7 @@
 package liquid
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -98,3 +99,23 @@ func TestLexerContains(t *testing.T) {
 		t.Errorf("Expected comparison token 'contains', got %v", tokens[0])
 	}
 }
+
+func TestLexerRegisteredOperators(t *testing.T) {
+	lexer := &Lexer{operators: map[string]ConditionOperator{"startswith": nil}}
+	tokens, err := lexer.Tokenize(NewStringScanner("a startswith b.startswith"))
+	if err != nil {
+		t.Fatalf("Tokenize() error = %v", err)
+	}
+	want := []Token{{":id", "a"}, {":comparison", "startswith"}, {":id", "b"}, lexerDot, {":id", "startswith"}, lexerEOS}
+	if !reflect.DeepEqual(tokens, want) {
+		t.Errorf("Tokenize() = %v, want %v", tokens, want)
+	}
+
+	tokens, err = Tokenize(NewStringScanner("a startswith b"))
+	if err != nil {
+		t.Fatalf("Tokenize() error = %v", err)
+	}
+	if tokens[1] != (Token{":id", "startswith"}) {
+		t.Errorf("Tokenize() = %v, want startswith as an identifier without registration", tokens)
+	}
+}
@@ -0,0 +1,279 @@
+// Package lint checks Liquid templates for likely mistakes by running rules
+// over their parse tree.
+//
+//	linter := lint.New(env)
+//	linter.Disable("unbounded-for")
+//	for _, d := range linter.Lint("product", source) {
+//		fmt.Println(d)
+//	}
+package lint
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Notifuse/liquidgo/liquid"
+)
+
+// Severity is how serious a diagnostic is.
+type Severity int
+
+const (
+	// SeverityError marks templates that fail or render wrong output.
+	SeverityError Severity = iota
+	// SeverityWarning marks code that is probably a mistake.
+	SeverityWarning
+	// SeverityInfo marks code worth a second look.
+	SeverityInfo
+)
+
+// String returns the name of the severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "info"
+	default:
+		return "unknown"
+	}
+}
+
+// Diagnostic is a problem found by a rule.
+type Diagnostic struct {
+	// Rule is the name of the rule, or "syntax" for parse errors.
+	Rule     string
+	Severity Severity
+	Message  string
+	// Template is the name passed to Lint.
+	Template string
+	// Line and Column are 1-based, or 0 when unknown.
+	Line   int
+	Column int
+}
+
+// String formats the diagnostic as template:line:column: severity: message (rule).
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s (%s)", d.Template, d.Line, d.Column, d.Severity, d.Message, d.Rule)
+}
+
+// Rule is a check run over the parse tree of a template.
+type Rule struct {
+	// Name identifies the rule, e.g. "unknown-filter".
+	Name string
+	// Doc is a one-line description.
+	Doc string
+	// Severity is used for the diagnostics the rule reports.
+	Severity Severity
+	// Run inspects pass.Template and calls pass.Report for each problem.
+	Run func(pass *Pass)
+}
+
+// Pass is a run of one rule over one template.
+type Pass struct {
+	// Template is the parsed template.
+	Template *liquid.Template
+	// Source is the template source.
+	Source string
+	// Environment is the linter's environment.
+	Environment *liquid.Environment
+	// FileSystem reads partials, or is nil when none is configured.
+	FileSystem liquid.FileSystem
+
+	rule        *Rule
+	diagnostics *[]Diagnostic
+}
+
+// Report records a diagnostic at pos, usually the start of the span of a node
+// or of part of it. Line and column are 0 when pos is not valid.
+func (p *Pass) Report(pos liquid.Position, format string, args ...interface{}) {
+	diagnostic := Diagnostic{
+		Rule:     p.rule.Name,
+		Severity: p.rule.Severity,
+		Message:  fmt.Sprintf(format, args...),
+		Template: p.Template.Name(),
+	}
+	if pos.IsValid() {
+		diagnostic.Line, diagnostic.Column = pos.Line, pos.Column
+	}
+	*p.diagnostics = append(*p.diagnostics, diagnostic)
+}
+
+// canRead reports whether the file system can read the partial name.
+func (p *Pass) canRead(name string) bool {
+	if name == "" || p.FileSystem == nil {
+		return false
+	}
+	_, err := p.FileSystem.ReadTemplateFile(liquid.ResolveParentTemplateName(name, p.Template.Name()))
+	return err == nil
+}
+
+// Walk calls visit for every node of the parse tree in template order, with
+// the span of the node, or of the enclosing tag or output for nodes without
+// one, such as conditions and expressions.
+func (p *Pass) Walk(visit func(node interface{}, span liquid.Span)) {
+	walk(p.Template.Root(), liquid.Span{}, visit)
+}
+
+func walk(node interface{}, span liquid.Span, visit func(node interface{}, span liquid.Span)) {
+	if spanned, ok := node.(interface{ Span() liquid.Span }); ok {
+		if s := spanned.Span(); s.IsValid() {
+			span = s
+		}
+	}
+	visit(node, span)
+	for _, child := range liquid.ForParseTreeVisitor(node, nil).Children() {
+		walk(child, span, visit)
+	}
+}
+
+// Linter runs a set of rules over templates.
+type Linter struct {
+	environment *liquid.Environment
+	fileSystem  liquid.FileSystem
+	rules       []*Rule
+	disabled    map[string]bool
+}
+
+// New creates a Linter for templates parsed with env (nil uses a new default
+// environment) with all DefaultRules enabled.
+func New(env *liquid.Environment) *Linter {
+	if env == nil {
+		env = liquid.NewEnvironment()
+	}
+	return &Linter{
+		environment: env,
+		rules:       DefaultRules(),
+		disabled:    make(map[string]bool),
+	}
+}
+
+// SetFileSystem sets the file system partials are read from. By default the
+// environment's file system is used.
+func (l *Linter) SetFileSystem(fs liquid.FileSystem) {
+	l.fileSystem = fs
+}
+
+// AddRule adds a custom rule, enabled, replacing any rule with the same name.
+func (l *Linter) AddRule(rule *Rule) {
+	for i, existing := range l.rules {
+		if existing.Name == rule.Name {
+			l.rules[i] = rule
+			delete(l.disabled, rule.Name)
+			return
+		}
+	}
+	l.rules = append(l.rules, rule)
+	delete(l.disabled, rule.Name)
+}
+
+// Enable enables the named rules. It returns an error for unknown names.
+func (l *Linter) Enable(names ...string) error {
+	return l.setEnabled(names, true)
+}
+
+// Disable disables the named rules. It returns an error for unknown names.
+func (l *Linter) Disable(names ...string) error {
+	return l.setEnabled(names, false)
+}
+
+// EnableOnly enables the named rules and disables all others.
+func (l *Linter) EnableOnly(names ...string) error {
+	for _, rule := range l.rules {
+		l.disabled[rule.Name] = true
+	}
+	return l.setEnabled(names, true)
+}
+
+func (l *Linter) setEnabled(names []string, enabled bool) error {
+	for _, name := range names {
+		if l.rule(name) == nil {
+			return fmt.Errorf("lint: unknown rule %q", name)
+		}
+		if enabled {
+			delete(l.disabled, name)
+		} else {
+			l.disabled[name] = true
+		}
+	}
+	return nil
+}
+
+func (l *Linter) rule(name string) *Rule {
+	for _, rule := range l.rules {
+		if rule.Name == name {
+			return rule
+		}
+	}
+	return nil
+}
+
+// Rules returns the rules of the linter, enabled or not.
+func (l *Linter) Rules() []*Rule {
+	return append([]*Rule{}, l.rules...)
+}
+
+// Enabled reports whether the named rule is enabled.
+func (l *Linter) Enabled(name string) bool {
+	return l.rule(name) != nil && !l.disabled[name]
+}
+
+// Lint parses source and runs the enabled rules over it. A source that does
+// not parse yields a single "syntax" diagnostic. Diagnostics are sorted by
+// position.
+func (l *Linter) Lint(name, source string) []Diagnostic {
+	tmpl, err := liquid.ParseTemplate(source, &liquid.TemplateOptions{Environment: l.environment, LineNumbers: true})
+	if err != nil {
+		diagnostic := Diagnostic{Rule: "syntax", Severity: SeverityError, Message: err.Error(), Template: name}
+		if liquidErr, ok := err.(liquid.LiquidError); ok {
+			diagnostic.Message = liquidErr.GetError().Message
+			if line := liquidErr.GetError().LineNumber; line != nil {
+				diagnostic.Line = *line
+			}
+		}
+		return []Diagnostic{diagnostic}
+	}
+	tmpl.SetName(name)
+	return l.LintTemplate(tmpl, source)
+}
+
+// LintTemplate runs the enabled rules over a template parsed from source.
+func (l *Linter) LintTemplate(tmpl *liquid.Template, source string) []Diagnostic {
+	fileSystem := l.fileSystem
+	if fileSystem == nil {
+		if fs := l.environment.FileSystem(); fs != nil {
+			if _, blank := fs.(*liquid.BlankFileSystem); !blank {
+				fileSystem = fs
+			}
+		}
+	}
+	if fileSystem != nil {
+		if _, ok := tmpl.Registers()["file_system"]; !ok {
+			tmpl.Registers()["file_system"] = fileSystem
+		}
+	}
+
+	diagnostics := []Diagnostic{}
+	for _, rule := range l.rules {
+		if l.disabled[rule.Name] {
+			continue
+		}
+		rule.Run(&Pass{
+			Template:    tmpl,
+			Source:      source,
+			Environment: l.environment,
+			FileSystem:  fileSystem,
+			rule:        rule,
+			diagnostics: &diagnostics,
+		})
+	}
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		if diagnostics[i].Line != diagnostics[j].Line {
+			return diagnostics[i].Line < diagnostics[j].Line
+		}
+		return diagnostics[i].Column < diagnostics[j].Column
+	})
+	return diagnostics
+}
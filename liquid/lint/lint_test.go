@@ -0,0 +1,161 @@
+package lint
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Notifuse/liquidgo/liquid"
+	"github.com/Notifuse/liquidgo/liquid/tags"
+)
+
+func newTestLinter() *Linter {
+	env := liquid.NewEnvironment()
+	tags.RegisterStandardTags(env)
+	linter := New(env)
+	linter.SetFileSystem(liquid.NewFSFileSystem(fstest.MapFS{
+		"_card.liquid":   {Data: []byte("{{ title }}")},
+		"_header.liquid": {Data: []byte("{{ heading }}")},
+	}))
+	return linter
+}
+
+// lintRule returns the diagnostics of a single rule as "line:column message".
+func lintRule(t *testing.T, rule, source string) []string {
+	t.Helper()
+	linter := newTestLinter()
+	if err := linter.EnableOnly(rule); err != nil {
+		t.Fatalf("EnableOnly() error = %v", err)
+	}
+	got := []string{}
+	for _, d := range linter.Lint("page", source) {
+		if d.Rule != rule {
+			t.Errorf("diagnostic from rule %q, want %q: %v", d.Rule, rule, d)
+		}
+		got = append(got, fmt.Sprintf("%d:%d %s", d.Line, d.Column, d.Message))
+	}
+	return got
+}
+
+func TestUnknownFilter(t *testing.T) {
+	got := lintRule(t, "unknown-filter", "{{ a | upcase | sort_natural | strip_html }}\n{% assign b = a | shout: 1 %}{{ b | wisper }}")
+	want := []string{`2:19 unknown filter "shout"`, `2:37 unknown filter "wisper"`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diagnostics = %q, want %q", got, want)
+	}
+
+	// Filter names are found after their pipe, on the line they are on
+	got = lintRule(t, "unknown-filter", "{{ data | a }}\n{{ 'x|y'\n  | upcase\n  | a }}")
+	want = []string{`1:11 unknown filter "a"`, `4:5 unknown filter "a"`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diagnostics = %q, want %q", got, want)
+	}
+}
+
+func TestUnusedVariable(t *testing.T) {
+	source := "{% assign used = 1 %}{% assign unused = 2 %}\n{% capture note %}x{% endcapture %}\n{% assign item = 3 %}{{ used }}{% render 'card', title: item %}"
+	want := []string{`1:22 assign variable "unused" is never read`, `2:1 capture variable "note" is never read`}
+	if got := lintRule(t, "unused-variable", source); !reflect.DeepEqual(got, want) {
+		t.Errorf("diagnostics = %q, want %q", got, want)
+	}
+
+	// Included partials read the caller's variables
+	source = "{% assign heading = 'Hi' %}{% include 'header' %}"
+	if got := lintRule(t, "unused-variable", source); len(got) != 0 {
+		t.Errorf("diagnostics = %q, want none", got)
+	}
+	source = "{% assign heading = 'Hi' %}{% include partial_name %}"
+	if got := lintRule(t, "unused-variable", source); len(got) != 0 {
+		t.Errorf("diagnostics = %q, want none for a partial that cannot be read", got)
+	}
+}
+
+func TestDeprecatedIncludeAndUndefinedPartial(t *testing.T) {
+	source := "{% render 'card' %}\n{% include 'header' %}\n{% render 'missing' %}"
+	if got, want := lintRule(t, "deprecated-include", source), []string{"2:1 include is deprecated, use render"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("deprecated-include diagnostics = %q, want %q", got, want)
+	}
+	if got, want := lintRule(t, "undefined-partial", source), []string{`3:1 render of undefined partial "missing"`}; !reflect.DeepEqual(got, want) {
+		t.Errorf("undefined-partial diagnostics = %q, want %q", got, want)
+	}
+
+	env := liquid.NewEnvironment()
+	tags.RegisterStandardTags(env)
+	linter := New(env)
+	if err := linter.EnableOnly("undefined-partial"); err != nil {
+		t.Fatal(err)
+	}
+	if got := linter.Lint("page", source); len(got) != 0 {
+		t.Errorf("diagnostics without a file system = %v, want none", got)
+	}
+}
+
+func TestUnboundedFor(t *testing.T) {
+	source := "{% for p in products %}{% endfor %}{% for p in products limit: 5 %}{% endfor %}\n{% for i in (1..3) %}{% endfor %}{% for c in 'abc' %}{% endfor %}"
+	if got, want := lintRule(t, "unbounded-for", source), []string{"1:1 for loop over products has no limit"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("diagnostics = %q, want %q", got, want)
+	}
+
+	// Collections assigned by the template or bound by an enclosing loop
+	source = "{% assign list = 'a,b' | split: ',' %}{% for x in list %}{% endfor %}{% for row in rows limit: 2 %}{% for cell in row %}{% endfor %}{% endfor %}"
+	if got := lintRule(t, "unbounded-for", source); len(got) != 0 {
+		t.Errorf("diagnostics = %q, want none", got)
+	}
+}
+
+func TestNilComparison(t *testing.T) {
+	source := "{% if a == nil %}{% endif %}\n{% if a == blank or b > 1 %}{% endif %}\n{% unless n > nil %}{% endunless %}{{ a < empty }}\n{% if list contains nil %}{% endif %}"
+	want := []string{
+		"1:1 == nil does not match empty strings and arrays, compare with blank",
+		"3:1 > comparison with nil is always false",
+		"3:36 < comparison with empty is always false",
+		"4:1 contains comparison with nil is always false",
+	}
+	if got := lintRule(t, "nil-comparison", source); !reflect.DeepEqual(got, want) {
+		t.Errorf("diagnostics = %q, want %q", got, want)
+	}
+}
+
+func TestLintSyntaxError(t *testing.T) {
+	diagnostics := newTestLinter().Lint("page", "ok\n{% if a %}")
+	if len(diagnostics) != 1 || diagnostics[0].Rule != "syntax" || diagnostics[0].Severity != SeverityError || diagnostics[0].Line != 2 {
+		t.Errorf("Lint() = %v, want one syntax error on line 2", diagnostics)
+	}
+}
+
+func TestLinterRules(t *testing.T) {
+	linter := newTestLinter()
+	if err := linter.Disable("unknown-filter", "unbounded-for"); err != nil {
+		t.Fatalf("Disable() error = %v", err)
+	}
+	if linter.Enabled("unknown-filter") || !linter.Enabled("nil-comparison") {
+		t.Error("Disable() did not disable only the named rules")
+	}
+	if err := linter.Enable("no-such-rule"); err == nil {
+		t.Error("Enable() expected an error for an unknown rule")
+	}
+	if got := linter.Lint("page", "{{ a | nope }}{% for p in list %}{% endfor %}"); len(got) != 0 {
+		t.Errorf("Lint() = %v, want no diagnostics from disabled rules", got)
+	}
+
+	linter.AddRule(&Rule{
+		Name:     "no-echo",
+		Severity: SeverityInfo,
+		Run: func(pass *Pass) {
+			pass.Walk(func(node interface{}, span liquid.Span) {
+				if tagName(node) == "echo" {
+					pass.Report(span.Start, "use {{ }} instead of echo")
+				}
+			})
+		},
+	})
+	got := linter.Lint("page", "\n  {% echo a %}")
+	want := []Diagnostic{{Rule: "no-echo", Severity: SeverityInfo, Message: "use {{ }} instead of echo", Template: "page", Line: 2, Column: 3}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Lint() = %v, want %v", got, want)
+	}
+	if s := got[0].String(); s != "page:2:3: info: use {{ }} instead of echo (no-echo)" {
+		t.Errorf("String() = %q", s)
+	}
+}
@@ -0,0 +1,299 @@
+package lint
+
+import (
+	"strings"
+
+	"github.com/Notifuse/liquidgo/liquid"
+)
+
+// DefaultRules returns the built-in rules.
+func DefaultRules() []*Rule {
+	return []*Rule{
+		UnknownFilter,
+		UnusedVariable,
+		DeprecatedInclude,
+		UndefinedPartial,
+		UnboundedFor,
+		NilComparison,
+	}
+}
+
+// UnknownFilter reports filters that are not among the environment's
+// FilterMethodNames. Unknown filters render their input unchanged, or fail
+// with strict filters.
+var UnknownFilter = &Rule{
+	Name:     "unknown-filter",
+	Doc:      "filters not registered in the environment",
+	Severity: SeverityError,
+	Run: func(pass *Pass) {
+		known := make(map[string]bool)
+		for _, name := range pass.Environment.FilterMethodNames() {
+			known[filterKey(name)] = true
+		}
+		pass.Walk(func(node interface{}, span liquid.Span) {
+			variable, ok := node.(*liquid.Variable)
+			if !ok {
+				return
+			}
+			positions := filterPositions(pass.Source, span, len(variable.Filters()))
+			for i, filter := range variable.Filters() {
+				if name, ok := filter[0].(string); ok && !known[filterKey(name)] {
+					pass.Report(positions[i], "unknown filter %q", name)
+				}
+			}
+		})
+	},
+}
+
+// filterPositions returns the positions of the names of the count filters of
+// the output or tag at span: the first word after each pipe outside quotes.
+// When the pipes don't match the filters, every filter is at the start of span.
+func filterPositions(source string, span liquid.Span, count int) []liquid.Position {
+	positions := make([]liquid.Position, count)
+	for i := range positions {
+		positions[i] = span.Start
+	}
+	markup := span.Source(source)
+	var offsets []int
+	var quote byte
+	for i := 0; i < len(markup); i++ {
+		switch c := markup[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '|':
+			name := i + 1
+			for name < len(markup) && (markup[name] == ' ' || markup[name] == '\t' || markup[name] == '\n' || markup[name] == '\r') {
+				name++
+			}
+			offsets = append(offsets, span.Start.Offset+name)
+		}
+	}
+	if len(offsets) != count {
+		return positions
+	}
+	for i, offset := range offsets {
+		positions[i] = positionAt(source, offset)
+	}
+	return positions
+}
+
+// positionAt returns the position of the byte at offset in source.
+func positionAt(source string, offset int) liquid.Position {
+	pos := liquid.Position{Offset: offset, Line: 1, Column: 1}
+	before := source[:offset]
+	pos.Line += strings.Count(before, "\n")
+	pos.Column += len(before) - (strings.LastIndexByte(before, '\n') + 1)
+	return pos
+}
+
+// filterKey normalizes filter and method names the way filters are looked up:
+// sort_natural, SortNatural and strip_html, StripHTML match.
+func filterKey(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
+
+// UnusedVariable reports assign and capture variables the template never
+// reads. It is skipped for templates that include partials it cannot read,
+// since included partials see the template's variables.
+var UnusedVariable = &Rule{
+	Name:     "unused-variable",
+	Doc:      "assign and capture variables that are never read",
+	Severity: SeverityWarning,
+	Run: func(pass *Pass) {
+		type assignment struct {
+			name, tag string
+			pos       liquid.Position
+		}
+		var assignments []assignment
+		seen := make(map[string]bool)
+		opaquePartial := false
+		pass.Walk(func(node interface{}, span liquid.Span) {
+			scoped, ok := node.(liquid.ScopedNode)
+			if !ok {
+				return
+			}
+			scope := scoped.VariableScope()
+			if partial := scope.Partial; partial != nil && !partial.Isolated && !pass.canRead(partial.Name) {
+				opaquePartial = true
+			}
+			tag := tagName(node)
+			if tag != "assign" && tag != "capture" {
+				return
+			}
+			for _, variable := range scope.Assigns {
+				if !seen[variable.Name] {
+					seen[variable.Name] = true
+					assignments = append(assignments, assignment{name: variable.Name, tag: tag, pos: span.Start})
+				}
+			}
+		})
+		if len(assignments) == 0 || opaquePartial {
+			return
+		}
+
+		analysis := liquid.AnalyzeVariables(pass.Template)
+		read := make(map[string]bool)
+		for _, ref := range analysis.References {
+			read[rootName(ref.Name)] = true
+		}
+		for _, a := range assignments {
+			if !read[a.name] {
+				pass.Report(a.pos, "%s variable %q is never read", a.tag, a.name)
+			}
+		}
+	},
+}
+
+// rootName returns the variable a lookup path starts with: "a" for a.b[0].
+func rootName(path string) string {
+	if i := strings.IndexAny(path, ".["); i >= 0 {
+		return path[:i]
+	}
+	return path
+}
+
+// DeprecatedInclude reports include tags, which share the caller's variables;
+// render is preferred.
+var DeprecatedInclude = &Rule{
+	Name:     "deprecated-include",
+	Doc:      "include tags, deprecated in favor of render",
+	Severity: SeverityWarning,
+	Run: func(pass *Pass) {
+		pass.Walk(func(node interface{}, span liquid.Span) {
+			if tagName(node) == "include" {
+				pass.Report(span.Start, "include is deprecated, use render")
+			}
+		})
+	},
+}
+
+// UndefinedPartial reports partials named by the template that the file
+// system cannot read. It is skipped when no file system is configured.
+var UndefinedPartial = &Rule{
+	Name:     "undefined-partial",
+	Doc:      "render, include and other partial tags naming missing templates",
+	Severity: SeverityError,
+	Run: func(pass *Pass) {
+		if pass.FileSystem == nil {
+			return
+		}
+		pass.Walk(func(node interface{}, span liquid.Span) {
+			scoped, ok := node.(liquid.ScopedNode)
+			if !ok {
+				return
+			}
+			partial := scoped.VariableScope().Partial
+			if partial != nil && partial.Name != "" && !pass.canRead(partial.Name) {
+				pass.Report(span.Start, "%s of undefined partial %q", tagName(node), partial.Name)
+			}
+		})
+	},
+}
+
+// UnboundedFor reports for loops without a limit over a collection read from
+// a variable, whose size the template does not control. Collections that
+// AnalyzeVariables finds assigned by the template or bound by an enclosing
+// loop are skipped.
+var UnboundedFor = &Rule{
+	Name:     "unbounded-for",
+	Doc:      "for loops without limit over variable collections",
+	Severity: SeverityInfo,
+	Run: func(pass *Pass) {
+		// Lookups are visited in the order AnalyzeVariables records them
+		var refs []liquid.VariableReference
+		for _, ref := range liquid.AnalyzeVariables(pass.Template).References {
+			if ref.Template == "" {
+				refs = append(refs, ref)
+			}
+		}
+		index := 0
+		var loop *liquid.VariableLookup // Collection of the for loop being visited
+		var loopSpan liquid.Span
+		pass.Walk(func(node interface{}, span liquid.Span) {
+			if lookup, ok := node.(*liquid.VariableLookup); ok {
+				if _, named := lookup.Name().(string); !named {
+					return
+				}
+				if lookup == loop {
+					local := index < len(refs) && (refs[index].Kind == liquid.AssignedVariable || refs[index].Kind == liquid.LoopVariable)
+					if !local {
+						pass.Report(loopSpan.Start, "for loop over %v has no limit", lookup.Name())
+					}
+					loop = nil
+				}
+				index++
+				return
+			}
+			tag, ok := node.(interface {
+				CollectionName() interface{}
+				Limit() interface{}
+			})
+			if !ok || tagName(node) != "for" || tag.Limit() != nil {
+				return
+			}
+			// The collection is the next node visited
+			if collection, ok := tag.CollectionName().(*liquid.VariableLookup); ok {
+				loop, loopSpan = collection, span
+			}
+		})
+	},
+}
+
+// NilComparison reports comparisons with nil that do not do what they seem
+// to: == nil does not match empty strings and arrays, and ordering
+// comparisons with nil, blank or empty, or contains nil, are always false.
+var NilComparison = &Rule{
+	Name:     "nil-comparison",
+	Doc:      "comparisons with nil, blank or empty that always fail",
+	Severity: SeverityWarning,
+	Run: func(pass *Pass) {
+		pass.Walk(func(node interface{}, span liquid.Span) {
+			var operator string
+			var operands []interface{}
+			switch n := node.(type) {
+			case *liquid.Condition:
+				operator, operands = n.Operator(), []interface{}{n.Left(), n.Right()}
+			case *liquid.Operation:
+				operator, operands = n.Operator(), n.Operands()
+			default:
+				return
+			}
+			if len(operands) != 2 {
+				return
+			}
+			switch operator {
+			case "==", "!=", "<>":
+				if operands[0] == nil || operands[1] == nil {
+					pass.Report(span.Start, "%s nil does not match empty strings and arrays, compare with blank", operator)
+				}
+			case "<", ">", "<=", ">=", "contains":
+				for _, operand := range operands {
+					_, literal := operand.(*liquid.MethodLiteral)
+					if operand == nil || (literal && operator != "contains") {
+						pass.Report(span.Start, "%s comparison with %s is always false", operator, literalName(operand))
+						return
+					}
+				}
+			}
+		})
+	},
+}
+
+func literalName(operand interface{}) string {
+	if literal, ok := operand.(*liquid.MethodLiteral); ok {
+		return literal.MethodName
+	}
+	return "nil"
+}
+
+// tagName returns the name of a tag node, or "".
+func tagName(node interface{}) string {
+	if tag, ok := node.(interface{ TagName() string }); ok {
+		return tag.TagName()
+	}
+	return ""
+}
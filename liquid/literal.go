@@ -0,0 +1,214 @@
+package liquid
+
+import (
+	"regexp"
+	"strings"
+)
+
+var literalHashKey = regexp.MustCompile(`^[\w\-]+$`)
+
+// ArrayLiteral is an array literal expression, e.g. [1, 2, x]. It evaluates
+// to a new []interface{} each time.
+type ArrayLiteral struct {
+	elements []interface{}
+}
+
+// NewArrayLiteral creates an ArrayLiteral from element expressions.
+func NewArrayLiteral(elements []interface{}) *ArrayLiteral {
+	return &ArrayLiteral{elements: elements}
+}
+
+// Elements returns the element expressions.
+func (a *ArrayLiteral) Elements() []interface{} {
+	return a.elements
+}
+
+// Evaluate evaluates the elements in context.
+func (a *ArrayLiteral) Evaluate(context *Context) interface{} {
+	result := make([]interface{}, len(a.elements))
+	for i, element := range a.elements {
+		result[i] = context.Evaluate(element)
+	}
+	return result
+}
+
+// ParseTreeChildren returns the element expressions.
+func (a *ArrayLiteral) ParseTreeChildren() []interface{} {
+	return a.elements
+}
+
+// HashLiteral is a hash literal expression, e.g. {"name": n, key: v}. Keys
+// are strings, whether quoted or not. It evaluates to a new
+// map[string]interface{} each time; a repeated key keeps its last value.
+type HashLiteral struct {
+	keys   []string
+	values []interface{}
+}
+
+// NewHashLiteral creates a HashLiteral from keys and the matching value expressions.
+func NewHashLiteral(keys []string, values []interface{}) *HashLiteral {
+	return &HashLiteral{keys: keys, values: values}
+}
+
+// Keys returns the keys in source order.
+func (h *HashLiteral) Keys() []string {
+	return h.keys
+}
+
+// Values returns the value expressions in source order.
+func (h *HashLiteral) Values() []interface{} {
+	return h.values
+}
+
+// Evaluate evaluates the values in context.
+func (h *HashLiteral) Evaluate(context *Context) interface{} {
+	result := make(map[string]interface{}, len(h.keys))
+	for i, key := range h.keys {
+		result[key] = context.Evaluate(h.values[i])
+	}
+	return result
+}
+
+// ParseTreeChildren returns the value expressions.
+func (h *HashLiteral) ParseTreeChildren() []interface{} {
+	return h.values
+}
+
+// parseArrayLiteral parses markup as an array literal. It returns false when
+// markup is not one, in particular for "[expr]", which is a variable lookup;
+// a single element array is written with a trailing comma, "[expr,]".
+func parseArrayLiteral(markup string, ss *StringScanner, cache map[string]interface{}, operators map[string]ConditionOperator) (*ArrayLiteral, bool) {
+	items, ok := literalItems(markup, '[', ']')
+	if !ok {
+		return nil, false
+	}
+	if len(items) == 1 {
+		// "[]" or "[expr]"
+		if items[0] != "" {
+			return nil, false
+		}
+		return NewArrayLiteral([]interface{}{}), true
+	}
+	if items[len(items)-1] == "" {
+		items = items[:len(items)-1]
+	}
+
+	elements := make([]interface{}, len(items))
+	for i, item := range items {
+		if item == "" {
+			return nil, false
+		}
+		elements[i] = parseExpression(item, ss, cache, operators)
+	}
+	return NewArrayLiteral(elements), true
+}
+
+// parseHashLiteral parses markup as a hash literal. It returns false when
+// markup is not one.
+func parseHashLiteral(markup string, ss *StringScanner, cache map[string]interface{}, operators map[string]ConditionOperator) (*HashLiteral, bool) {
+	items, ok := literalItems(markup, '{', '}')
+	if !ok {
+		return nil, false
+	}
+	if len(items) > 1 && items[len(items)-1] == "" {
+		items = items[:len(items)-1]
+	}
+	if len(items) == 1 && items[0] == "" {
+		return NewHashLiteral([]string{}, []interface{}{}), true
+	}
+
+	keys := make([]string, len(items))
+	values := make([]interface{}, len(items))
+	for i, item := range items {
+		colon := literalSeparator(item, ':')
+		if colon < 0 {
+			return nil, false
+		}
+		key := strings.TrimSpace(item[:colon])
+		switch {
+		case len(key) >= 2 && (key[0] == '"' || key[0] == '\'') && key[len(key)-1] == key[0]:
+			key = key[1 : len(key)-1]
+		case !literalHashKey.MatchString(key):
+			return nil, false
+		}
+		value := strings.TrimSpace(item[colon+1:])
+		if value == "" {
+			return nil, false
+		}
+		keys[i] = key
+		values[i] = parseExpression(value, ss, cache, operators)
+	}
+	return NewHashLiteral(keys, values), true
+}
+
+// literalItems returns the trimmed, comma separated items of markup when it
+// is entirely enclosed in open and close, or false. Commas inside quotes or
+// nested brackets do not separate items.
+func literalItems(markup string, open, close byte) ([]string, bool) {
+	if len(markup) < 2 || markup[0] != open || literalEnd(markup) != len(markup)-1 || markup[len(markup)-1] != close {
+		return nil, false
+	}
+	inner := markup[1 : len(markup)-1]
+	items := []string{}
+	for {
+		comma := literalSeparator(inner, ',')
+		if comma < 0 {
+			items = append(items, strings.TrimSpace(inner))
+			return items, true
+		}
+		items = append(items, strings.TrimSpace(inner[:comma]))
+		inner = inner[comma+1:]
+	}
+}
+
+// literalEnd returns the index of the bracket closing the one markup starts
+// with, or -1 when brackets are unbalanced.
+func literalEnd(markup string) int {
+	depth := 0
+	for i := 0; i < len(markup); i++ {
+		switch c := markup[i]; c {
+		case '"', '\'':
+			end := strings.IndexByte(markup[i+1:], c)
+			if end < 0 {
+				return -1
+			}
+			i += end + 1
+		case '[', '{', '(':
+			depth++
+		case ']', '}', ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+			if depth < 0 {
+				return -1
+			}
+		}
+	}
+	return -1
+}
+
+// literalSeparator returns the index of the first separator of s that is
+// not inside quotes or brackets, or -1.
+func literalSeparator(s string, separator byte) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"', '\'':
+			end := strings.IndexByte(s[i+1:], c)
+			if end < 0 {
+				return -1
+			}
+			i += end + 1
+		case '[', '{', '(':
+			depth++
+		case ']', '}', ')':
+			depth--
+		default:
+			if c == separator && depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,126 @@
+package liquid
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseArrayLiteral(t *testing.T) {
+	ctx := NewContext()
+	ctx.Set("x", "X")
+
+	tests := map[string]interface{}{
+		`[1, "b", x]`:           []interface{}{1, "b", "X"},
+		`[ 1 , 2 , ]`:           []interface{}{1, 2},
+		`[x,]`:                  []interface{}{"X"},
+		`[]`:                    []interface{}{},
+		`[[1, 2], [3,]]`:        []interface{}{[]interface{}{1, 2}, []interface{}{3}},
+		`["a, b", 'c]']`:        []interface{}{"a, b", "c]"},
+		`[(1..2), {k: x}]`:      []interface{}{&Range{Start: 1, End: 2}, map[string]interface{}{"k": "X"}},
+		`[nil, true, 1.5, 'y']`: []interface{}{nil, true, 1.5, "y"},
+	}
+	for markup, want := range tests {
+		expr := Parse(markup, nil, map[string]interface{}{})
+		if _, ok := expr.(*ArrayLiteral); !ok {
+			t.Errorf("Parse(%q) = %T, want *ArrayLiteral", markup, expr)
+			continue
+		}
+		if got := ctx.Evaluate(expr); !reflect.DeepEqual(got, want) {
+			t.Errorf("Evaluate(%q) = %#v, want %#v", markup, got, want)
+		}
+	}
+}
+
+func TestParseHashLiteral(t *testing.T) {
+	ctx := NewContext()
+	ctx.Set("n", "Ann")
+
+	tests := map[string]interface{}{
+		`{"name": n, key-2: 2}`:       map[string]interface{}{"name": "Ann", "key-2": 2},
+		`{ 'a': [1, 2], b: {c: n}, }`: map[string]interface{}{"a": []interface{}{1, 2}, "b": map[string]interface{}{"c": "Ann"}},
+		`{"a:b": 1, a: 1, a: 2}`:      map[string]interface{}{"a:b": 1, "a": 2},
+		`{}`:                          map[string]interface{}{},
+	}
+	for markup, want := range tests {
+		expr := Parse(markup, nil, map[string]interface{}{})
+		literal, ok := expr.(*HashLiteral)
+		if !ok {
+			t.Errorf("Parse(%q) = %T, want *HashLiteral", markup, expr)
+			continue
+		}
+		if got := ctx.Evaluate(literal); !reflect.DeepEqual(got, want) {
+			t.Errorf("Evaluate(%q) = %#v, want %#v", markup, got, want)
+		}
+	}
+}
+
+func TestParseLiteralFallsBackToVariableLookup(t *testing.T) {
+	for _, markup := range []string{`[x]`, `['foo'].bar`, `[1, 2`, `[1,, 2]`, `{a}`, `{a: }`, `{a b: 1}`} {
+		if _, ok := Parse(markup, nil, map[string]interface{}{}).(*VariableLookup); !ok {
+			t.Errorf("Parse(%q) = %T, want *VariableLookup", markup, Parse(markup, nil, nil))
+		}
+	}
+}
+
+func TestLiteralEvaluatesToFreshValues(t *testing.T) {
+	expr := Parse(`[1, {a: 2}]`, nil, map[string]interface{}{})
+	ctx := NewContext()
+	first := ctx.Evaluate(expr).([]interface{})
+	first[0] = "changed"
+	first[1].(map[string]interface{})["a"] = "changed"
+	if got := ctx.Evaluate(expr); !reflect.DeepEqual(got, []interface{}{1, map[string]interface{}{"a": 2}}) {
+		t.Errorf("Evaluate() = %#v, want the literal values", got)
+	}
+}
+
+func TestLiteralVariables(t *testing.T) {
+	sources := map[string]string{
+		`{{ [1, 2, x] | join: "-" }}`:              "1-2-X",
+		`{{ list | concat: [3, 4] | join: "" }}`:   "1234",
+		`{{ [[x, 1], [2,]] | first | join: "," }}`: "X,1",
+		`{{ ['x'] }}`: "X",
+	}
+	for _, mode := range []string{"lax", "strict", "rigid"} {
+		env := NewEnvironment()
+		env.SetErrorMode(mode)
+		for source, want := range sources {
+			tmpl, err := ParseTemplate(source, &TemplateOptions{Environment: env})
+			if err != nil {
+				t.Errorf("%s: ParseTemplate(%q) error = %v", mode, source, err)
+				continue
+			}
+			got := tmpl.Render(map[string]interface{}{"x": "X", "list": []interface{}{1, 2}}, nil)
+			if got != want {
+				t.Errorf("%s: Render(%q) = %q, want %q", mode, source, got, want)
+			}
+		}
+	}
+}
+
+func TestLiteralsCompiledRoundTrip(t *testing.T) {
+	tmpl, err := ParseTemplate(`{{ [1, x, [x,]] | size }}{{ [x, 'y'] | last }}`, nil)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	data, err := tmpl.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+	loaded, err := LoadCompiled(data, nil)
+	if err != nil {
+		t.Fatalf("LoadCompiled() error = %v", err)
+	}
+	if got := loaded.Render(map[string]interface{}{"x": "X"}, nil); got != "3y" {
+		t.Errorf("Render() = %q, want %q", got, "3y")
+	}
+}
+
+func TestLiteralVariableAnalysis(t *testing.T) {
+	tmpl, err := ParseTemplate(`{{ [a, [b.c,]] | join }}`, nil)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	if got, want := AnalyzeVariables(tmpl).Globals(), []string{"a", "b.c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Globals() = %v, want %v", got, want)
+	}
+}
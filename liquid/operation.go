@@ -0,0 +1,150 @@
+package liquid
+
+import (
+	"strings"
+)
+
+// Operation is an operator expression, e.g. price * quantity, total > 100
+// or not (a and b). Arithmetic follows the plus, minus, times, divided_by and
+// modulo filters, including ZeroDivisionError; comparisons, including
+// registered operators, follow Condition; and, or and not return booleans.
+type Operation struct {
+	operator  string
+	operands  []interface{} // One operand for not and unary minus, two otherwise
+	condition *Condition    // Comparison, for comparison operators
+}
+
+// NewOperation creates an Operation applying operator to one or two operands.
+func NewOperation(operator string, operands ...interface{}) *Operation {
+	operation := &Operation{operator: operator, operands: operands}
+	if len(operands) == 2 && isComparisonOperator(operator) {
+		operation.condition = NewCondition(operands[0], operator, operands[1])
+	}
+	return operation
+}
+
+// Operator returns the operator.
+func (o *Operation) Operator() string {
+	return o.operator
+}
+
+// Operands returns the operand expressions.
+func (o *Operation) Operands() []interface{} {
+	return o.operands
+}
+
+// ParseTreeChildren returns the operand expressions.
+func (o *Operation) ParseTreeChildren() []interface{} {
+	return o.operands
+}
+
+// Evaluate evaluates the operation in context. Errors, such as a division by
+// zero, are raised as panics, like filter errors in strict mode.
+func (o *Operation) Evaluate(context *Context) interface{} {
+	if len(o.operands) == 1 {
+		value := context.Evaluate(o.operands[0])
+		if o.operator == "not" {
+			return !operationTruthy(value)
+		}
+		return applyOperation(0, value, "-")
+	}
+
+	switch o.operator {
+	case "and":
+		return operationTruthy(context.Evaluate(o.operands[0])) && operationTruthy(context.Evaluate(o.operands[1]))
+	case "or":
+		return operationTruthy(context.Evaluate(o.operands[0])) || operationTruthy(context.Evaluate(o.operands[1]))
+	}
+	if o.condition != nil {
+		result, err := o.condition.Evaluate(context)
+		if err != nil {
+			panic(err)
+		}
+		return result
+	}
+
+	left := ToLiquidValue(context.Evaluate(o.operands[0]))
+	right := ToLiquidValue(context.Evaluate(o.operands[1]))
+	filters := &StandardFilters{context: context}
+	var (
+		result interface{}
+		err    error
+	)
+	switch o.operator {
+	case "/":
+		result, err = filters.DividedBy(left, right)
+	case "%":
+		result, err = filters.Modulo(left, right)
+	default:
+		result = applyOperation(left, right, o.operator)
+	}
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// operationTruthy returns the truthiness of a value in conditions.
+func operationTruthy(value interface{}) bool {
+	value = ToLiquidValue(value)
+	return value != nil && value != false && value != ""
+}
+
+// isComparisonOperator reports whether operator is evaluated by Condition:
+// built-in and registered comparison operators.
+func isComparisonOperator(operator string) bool {
+	switch operator {
+	case "+", "-", "*", "/", "%", "and", "or", "not":
+		return false
+	}
+	return true
+}
+
+// parseOperation parses markup as an Operation. It returns false when markup
+// has no operator or is not a valid expression, so that it is parsed as
+// before.
+func parseOperation(markup string, ss *StringScanner, cache map[string]interface{}, operators map[string]ConditionOperator) (*Operation, bool) {
+	if !strings.ContainsAny(markup, "+-*/%<>=!(") && !strings.Contains(markup, " and ") &&
+		!strings.Contains(markup, " or ") && !strings.Contains(markup, "not ") &&
+		!strings.Contains(markup, " contains ") && !containsRegisteredOperator(markup, operators) {
+		return nil, false
+	}
+
+	p := newParser(NewStringScanner(markup), operators)
+	if p.Error() != nil {
+		return nil, false
+	}
+	syntax, err := p.operation(0)
+	if err != nil || !p.Look(":end_of_string", 0) || len(syntax.operands) == 0 {
+		return nil, false
+	}
+	return syntax.build(ss, cache, operators).(*Operation), true
+}
+
+// containsRegisteredOperator reports whether markup may use one of the
+// registered comparison operators.
+func containsRegisteredOperator(markup string, operators map[string]ConditionOperator) bool {
+	for name := range operators {
+		if strings.Contains(markup, " "+name+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// build creates the expression described by the syntax.
+func (s *operationSyntax) build(ss *StringScanner, cache map[string]interface{}, operators map[string]ConditionOperator) interface{} {
+	if len(s.operands) == 0 {
+		return parseExpression(s.markup, ss, cache, operators)
+	}
+	operands := make([]interface{}, len(s.operands))
+	for i, operand := range s.operands {
+		// blank and empty compare as method literals, as in conditions
+		if ml, ok := conditionMethodLiterals[operand.markup]; ok && isComparisonOperator(s.operator) {
+			operands[i] = ml
+			continue
+		}
+		operands[i] = operand.build(ss, cache, operators)
+	}
+	return NewOperation(s.operator, operands...)
+}
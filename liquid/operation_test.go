@@ -0,0 +1,100 @@
+package liquid
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOperationEvaluate(t *testing.T) {
+	ctx := NewContext()
+	ctx.Set("price", 7)
+	ctx.Set("qty", 3)
+	ctx.Set("name", "Ann")
+	ctx.Set("tags", []interface{}{"sale", "new"})
+
+	tests := map[string]interface{}{
+		"price * qty + 1":              22.0,
+		"price * (qty + 1)":            28.0,
+		"price - qty - 1":              3.0,
+		"10 / 4":                       2.5,
+		"price % qty":                  1.0,
+		"-price + 1":                   -6.0,
+		"price -1":                     6.0,
+		"'2' + 3":                      5.0,
+		"price * qty == 21":            true,
+		"price > 5 and qty > 5":        false,
+		"price > 5 or qty > 5":         true,
+		"not price > 5":                false,
+		"not (price > 5 and qty > 5)":  true,
+		"name contains 'nn'":           true,
+		"tags contains 'sale' and nil": false,
+		"name != empty":                true,
+		"missing == blank":             true,
+	}
+	for markup, want := range tests {
+		expr := Parse(markup, nil, map[string]interface{}{})
+		if _, ok := expr.(*Operation); !ok {
+			t.Errorf("Parse(%q) = %T, want *Operation", markup, expr)
+			continue
+		}
+		if got := ctx.Evaluate(expr); !reflect.DeepEqual(got, want) {
+			t.Errorf("Evaluate(%q) = %#v, want %#v", markup, got, want)
+		}
+	}
+}
+
+func TestOperationZeroDivision(t *testing.T) {
+	for _, markup := range []string{"1 / 0", "price % 0", "1 / missing"} {
+		func() {
+			defer func() {
+				if _, ok := recover().(*ZeroDivisionError); !ok {
+					t.Errorf("Evaluate(%q) expected a ZeroDivisionError panic", markup)
+				}
+			}()
+			NewContext().Evaluate(Parse(markup, nil, map[string]interface{}{}))
+		}()
+	}
+}
+
+func TestParseOperationKeepsOperands(t *testing.T) {
+	tests := map[string]interface{}{
+		"product-title": &VariableLookup{},
+		"-5":            -5,
+		"'a + b'":       "a + b",
+		"(1..3)":        &Range{},
+		"not":           &VariableLookup{},
+	}
+	for markup, want := range tests {
+		if got := Parse(markup, nil, map[string]interface{}{}); reflect.TypeOf(got) != reflect.TypeOf(want) {
+			t.Errorf("Parse(%q) = %T, want %T", markup, got, want)
+		}
+	}
+}
+
+func TestOperationCompiledRoundTrip(t *testing.T) {
+	tmpl, err := ParseTemplate(`{{ (price + 1) * qty }} {{ not (price > qty) }} {{ -price }}`, nil)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	data, err := tmpl.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+	loaded, err := LoadCompiled(data, nil)
+	if err != nil {
+		t.Fatalf("LoadCompiled() error = %v", err)
+	}
+	if got := loaded.Render(map[string]interface{}{"price": 7, "qty": 3}, nil); got != "24 false -7" {
+		t.Errorf("Render() = %q, want %q", got, "24 false -7")
+	}
+}
+
+func TestOperationVariableAnalysis(t *testing.T) {
+	tmpl, err := ParseTemplate(`{{ order.total * rate > limit }}`, nil)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	if got, want := AnalyzeVariables(tmpl).Globals(), []string{"limit", "order.total", "rate"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Globals() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,96 @@
+package liquid
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// OutputBuffer is the destination that tags and variables render into.
+//
+// An OutputBuffer either accumulates output in memory (NewOutputBuffer) or
+// streams it to an io.Writer through a buffered writer (NewStreamingOutputBuffer).
+// In both cases it keeps track of the number of bytes written, so resource
+// limits can be enforced without re-measuring the output after every node.
+type OutputBuffer struct {
+	writer  *bufio.Writer
+	err     error
+	builder strings.Builder
+	length  int
+}
+
+// NewOutputBuffer creates an OutputBuffer that accumulates output in memory.
+func NewOutputBuffer() *OutputBuffer {
+	return &OutputBuffer{}
+}
+
+// NewStreamingOutputBuffer creates an OutputBuffer that writes output to w
+// as nodes render. Call Flush once rendering is done.
+func NewStreamingOutputBuffer(w io.Writer) *OutputBuffer {
+	return &OutputBuffer{
+		writer: bufio.NewWriter(w),
+	}
+}
+
+// WriteString appends s to the output.
+// Once the underlying writer has failed, further writes are discarded and
+// the first error is returned (see Err).
+func (o *OutputBuffer) WriteString(s string) (int, error) {
+	if o.err != nil {
+		return 0, o.err
+	}
+	if o.writer == nil {
+		o.builder.WriteString(s)
+		o.length += len(s)
+		return len(s), nil
+	}
+	n, err := o.writer.WriteString(s)
+	o.length += n
+	o.err = err
+	return n, err
+}
+
+// Write appends p to the output. It implements io.Writer.
+func (o *OutputBuffer) Write(p []byte) (int, error) {
+	return o.WriteString(string(p))
+}
+
+// Len returns the number of bytes written to the output so far.
+func (o *OutputBuffer) Len() int {
+	return o.length
+}
+
+// String returns the output accumulated in memory.
+// A streaming buffer does not retain its output and always returns "".
+func (o *OutputBuffer) String() string {
+	return o.builder.String()
+}
+
+// Streaming returns true if the buffer writes through to an io.Writer.
+func (o *OutputBuffer) Streaming() bool {
+	return o.writer != nil
+}
+
+// Reset discards the output accumulated in memory.
+// It has no effect on a streaming buffer, whose output may already have been written.
+func (o *OutputBuffer) Reset() {
+	if o.writer != nil {
+		return
+	}
+	o.builder.Reset()
+	o.length = 0
+}
+
+// Flush writes any buffered data to the underlying io.Writer.
+func (o *OutputBuffer) Flush() error {
+	if o.writer == nil || o.err != nil {
+		return o.err
+	}
+	o.err = o.writer.Flush()
+	return o.err
+}
+
+// Err returns the first error reported by the underlying io.Writer, if any.
+func (o *OutputBuffer) Err() error {
+	return o.err
+}
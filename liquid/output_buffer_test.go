@@ -0,0 +1,82 @@
+package liquid
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestOutputBufferInMemory(t *testing.T) {
+	output := NewOutputBuffer()
+	output.WriteString("Hello ")
+	_, _ = output.Write([]byte("world"))
+
+	if output.String() != "Hello world" {
+		t.Errorf("Expected 'Hello world', got %q", output.String())
+	}
+	if output.Len() != 11 {
+		t.Errorf("Expected length 11, got %d", output.Len())
+	}
+	if output.Streaming() {
+		t.Error("Expected in-memory buffer not to be streaming")
+	}
+
+	output.Reset()
+	if output.String() != "" || output.Len() != 0 {
+		t.Errorf("Expected empty buffer after reset, got %q (%d)", output.String(), output.Len())
+	}
+}
+
+func TestOutputBufferLenCountsBytes(t *testing.T) {
+	output := NewOutputBuffer()
+	output.WriteString("测试")
+	if output.Len() != 6 {
+		t.Errorf("Expected 6 bytes, got %d", output.Len())
+	}
+}
+
+func TestOutputBufferStreaming(t *testing.T) {
+	var w bytes.Buffer
+	output := NewStreamingOutputBuffer(&w)
+	output.WriteString("streamed")
+
+	if !output.Streaming() {
+		t.Error("Expected streaming buffer")
+	}
+	if output.String() != "" {
+		t.Errorf("Expected streaming buffer not to retain output, got %q", output.String())
+	}
+	if output.Len() != 8 {
+		t.Errorf("Expected length 8, got %d", output.Len())
+	}
+
+	// Reset cannot take back streamed output
+	output.Reset()
+	if err := output.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if w.String() != "streamed" {
+		t.Errorf("Expected 'streamed', got %q", w.String())
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestOutputBufferStreamingWriteError(t *testing.T) {
+	output := NewStreamingOutputBuffer(failingWriter{})
+	output.WriteString("data")
+
+	if err := output.Flush(); err == nil {
+		t.Fatal("Expected flush error")
+	}
+	if output.Err() == nil {
+		t.Fatal("Expected Err() to report the write error")
+	}
+	if _, err := output.WriteString("more"); err == nil {
+		t.Error("Expected writes after a failure to return the error")
+	}
+}
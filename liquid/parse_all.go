@@ -0,0 +1,346 @@
+package liquid
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DiagnosticSeverity is how serious a Diagnostic is.
+type DiagnosticSeverity int
+
+const (
+	// DiagnosticError marks syntax errors Parse fails on.
+	DiagnosticError DiagnosticSeverity = iota
+	// DiagnosticWarning marks markup the warn error mode parses laxly.
+	DiagnosticWarning
+)
+
+// String returns the name of the severity.
+func (s DiagnosticSeverity) String() string {
+	if s == DiagnosticWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// Codes of the diagnostics reported by ParseAll. They do not change with the
+// locale or wording of the messages.
+const (
+	// CodeUnknownTag is a tag not registered in the environment.
+	CodeUnknownTag = "unknown-tag"
+	// CodeUnexpectedTag is an else or end tag that does not belong to the
+	// enclosing block.
+	CodeUnexpectedTag = "unexpected-tag"
+	// CodeUnclosedTag is a block tag without its end tag.
+	CodeUnclosedTag = "unclosed-tag"
+	// CodeUnterminatedTag is a {% without %}.
+	CodeUnterminatedTag = "unterminated-tag"
+	// CodeInvalidTag is a tag whose markup does not parse.
+	CodeInvalidTag = "invalid-tag"
+	// CodeInvalidOutput is a {{ }} whose markup, e.g. a filter, does not parse.
+	CodeInvalidOutput = "invalid-output"
+	// CodeUnterminatedOutput is a {{ without }}.
+	CodeUnterminatedOutput = "unterminated-output"
+	// CodeInvalidEncoding is a source that is not valid UTF-8.
+	CodeInvalidEncoding = "invalid-encoding"
+	// CodeSyntaxError is any other error parsing cannot recover from.
+	CodeSyntaxError = "syntax-error"
+)
+
+// Diagnostic is a syntax error or warning found by ParseAll.
+type Diagnostic struct {
+	Code     string
+	Severity DiagnosticSeverity
+	Message  string
+	// Template is the name of the template, or "".
+	Template string
+	// Line and Column locate the start of the offending tag or output, and
+	// EndLine and EndColumn the position just after it. Lines and columns are
+	// 1-based; columns count bytes.
+	Line      int
+	Column    int
+	EndLine   int
+	EndColumn int
+}
+
+// String formats the diagnostic as template:line:column: severity: message (code).
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s (%s)", d.Template, d.Line, d.Column, d.Severity, d.Message, d.Code)
+}
+
+// ParseAll parses source like ParseTemplate but does not stop at the first
+// syntax error. It returns the template with the tags and outputs that
+// parsed, usable for analysis such as AnalyzeVariables, and every error as a
+// Diagnostic sorted by position. name is used for the diagnostics and the
+// template.
+func ParseAll(name, source string, options *TemplateOptions) (*Template, []Diagnostic) {
+	template := NewTemplate(options)
+	template.SetName(name)
+	return template, template.ParseAll(source, options)
+}
+
+// ParseAll parses source like Parse, recovering from syntax errors at tag
+// boundaries:
+//
+//   - a tag with invalid markup is left out, but the body of a block tag is
+//     still parsed;
+//   - unknown tags, else and end tags outside their block, and outputs with
+//     invalid markup are left out;
+//   - a block still open at the end of the template, or at the end tag of an
+//     enclosing block, is closed there.
+//
+// Errors are reported in the environment's error mode: lax parsing accepts
+// markup strict parsing rejects, and markup the warn mode parses laxly is
+// reported with DiagnosticWarning. Rendering a template with errors is not
+// meaningful; a block tag with invalid markup renders nothing.
+func (t *Template) ParseAll(source string, options *TemplateOptions) []Diagnostic {
+	parseContext := t.configureOptions(options)
+	pc, ok := parseContext.(*ParseContext)
+	if !ok {
+		pc = NewParseContext(ParseContextOptions{Environment: t.environment})
+	}
+	recovery := &parseRecovery{parseContext: pc, source: source, template: t.name}
+	pc.recovery = recovery
+
+	doc := NewDocument(pc)
+	t.root = doc
+	if !isValidUTF8(source) {
+		start := Position{Line: 1, Column: 1}
+		recovery.add(CodeInvalidEncoding, DiagnosticError, NewTemplateEncodingError(pc.Locale().T("errors.syntax.invalid_template_encoding", nil)), Span{Start: start, End: start})
+		return recovery.diagnostics
+	}
+
+	var startLineNumber *int
+	if t.lineNumbers {
+		lineNum := 1
+		startLineNumber = &lineNum
+	}
+	tokenizer := pc.NewTokenizer(source, false, startLineNumber, false)
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err, ok := r.(error)
+				if !ok {
+					panic(r)
+				}
+				recovery.add(CodeSyntaxError, DiagnosticError, err, recovery.current())
+			}
+		}()
+		if err := doc.Parse(tokenizer, pc); err != nil {
+			recovery.add(CodeSyntaxError, DiagnosticError, err, recovery.current())
+		}
+	}()
+	t.warnings = pc.Warnings()
+
+	sort.SliceStable(recovery.diagnostics, func(i, j int) bool {
+		a, b := recovery.diagnostics[i], recovery.diagnostics[j]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return recovery.diagnostics
+}
+
+// parseRecovery collects the diagnostics of ParseAll while parsing goes on
+// past syntax errors.
+type parseRecovery struct {
+	parseContext *ParseContext
+	source       string
+	template     string
+	diagnostics  []Diagnostic
+	// open holds the tags whose Parse is running, innermost last.
+	open []openTag
+}
+
+// openTag is a tag being parsed from tokenizer.
+type openTag struct {
+	name, delimiter string
+	span            Span
+	tokenizer       *Tokenizer
+}
+
+// recoveryOf returns the recovery of a ParseAll parse context, or nil.
+func recoveryOf(parseContext ParseContextInterface) *parseRecovery {
+	if pc, ok := parseContext.(*ParseContext); ok {
+		return pc.recovery
+	}
+	return nil
+}
+
+// current returns the span of the token being parsed.
+func (r *parseRecovery) current() Span {
+	return r.parseContext.span
+}
+
+// add records err as a diagnostic covering span.
+func (r *parseRecovery) add(code string, severity DiagnosticSeverity, err error, span Span) {
+	message := err.Error()
+	if liquidErr, ok := err.(LiquidError); ok {
+		message = liquidErr.GetError().Message
+	}
+	r.diagnostics = append(r.diagnostics, Diagnostic{
+		Code:      code,
+		Severity:  severity,
+		Message:   message,
+		Template:  r.template,
+		Line:      span.Start.Line,
+		Column:    span.Start.Column,
+		EndLine:   span.End.Line,
+		EndColumn: span.End.Column,
+	})
+}
+
+// addWarning records a warning of the warn error mode for the current token.
+func (r *parseRecovery) addWarning(err error) {
+	span, code := r.current(), CodeInvalidTag
+	if strings.HasPrefix(span.Source(r.source), blockBodyVARSTART) {
+		code = CodeInvalidOutput
+	}
+	r.add(code, DiagnosticWarning, err, span)
+}
+
+// push records tag as being parsed from tokenizer, from the current token.
+func (r *parseRecovery) push(name string, tag interface{}, tokenizer *Tokenizer, span Span) {
+	open := openTag{name: name, span: span, tokenizer: tokenizer}
+	if block, ok := tag.(interface{ BlockDelimiter() string }); ok {
+		open.delimiter = block.BlockDelimiter()
+	}
+	r.open = append(r.open, open)
+}
+
+func (r *parseRecovery) pop() {
+	r.open = r.open[:len(r.open)-1]
+}
+
+// innermost returns the innermost block being parsed from tokenizer, or nil
+// when the body being parsed does not belong to a block.
+func (r *parseRecovery) innermost(tokenizer *Tokenizer) *openTag {
+	if len(r.open) == 0 {
+		return nil
+	}
+	open := &r.open[len(r.open)-1]
+	if open.tokenizer != tokenizer || open.delimiter == "" {
+		return nil
+	}
+	return open
+}
+
+// closesEnclosing reports whether tag ends a block enclosing the innermost one.
+func (r *parseRecovery) closesEnclosing(tokenizer *Tokenizer, tag string) bool {
+	for i := len(r.open) - 2; i >= 0 && r.open[i].tokenizer == tokenizer; i-- {
+		if r.open[i].delimiter == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// intermediateTags are the tags blocks accept between their start and end
+// tags.
+var intermediateTags = map[string]bool{"else": true, "elsif": true, "when": true, "rescue": true}
+
+// handler wraps the unknown tag handler of a block body parsed from
+// tokenizer so that parsing goes on after the errors it raises, and blocks
+// left open are closed.
+func (r *parseRecovery) handler(tokenizer *Tokenizer, unknownTagHandler func(string, string) bool) func(string, string) bool {
+	return func(tagName, markup string) (keepParsing bool) {
+		span := r.current()
+		if open := r.innermost(tokenizer); open != nil && tagName != open.delimiter {
+			if tagName == "" || r.closesEnclosing(tokenizer, tagName) {
+				r.add(CodeUnclosedTag, DiagnosticError, NewSyntaxError("'"+open.name+"' tag was never closed"), open.span)
+				if tagName != "" {
+					tokenizer.unshift()
+				}
+				return unknownTagHandler(open.delimiter, "")
+			}
+			// Some blocks end at unknown tags without an error
+			if unknownTagCode(tagName) == CodeUnknownTag && !intermediateTags[tagName] {
+				r.add(CodeUnknownTag, DiagnosticError, RaiseUnknownTag(tagName, open.name, open.delimiter, r.parseContext), span)
+				return true
+			}
+		}
+
+		defer func() {
+			if p := recover(); p != nil {
+				err, ok := p.(error)
+				if !ok {
+					panic(p)
+				}
+				r.add(unknownTagCode(tagName), DiagnosticError, err, span)
+				keepParsing = true
+			}
+		}()
+		return unknownTagHandler(tagName, markup)
+	}
+}
+
+// unknownTagCode returns the code of the error for a tag its block does not
+// know.
+func unknownTagCode(tagName string) string {
+	switch {
+	case strings.HasPrefix(tagName, blockBodyTAGSTART) && !strings.HasSuffix(tagName, "%}"):
+		return CodeUnterminatedTag
+	case strings.HasPrefix(tagName, blockBodyTAGSTART):
+		return CodeUnknownTag
+	case tagName == "else" || tagName == "elsif" || tagName == "when" || strings.HasPrefix(tagName, "end"):
+		return CodeUnexpectedTag
+	default:
+		return CodeUnknownTag
+	}
+}
+
+// parseTag runs the Parse method of a tag opened by the current token,
+// returning what it panics with as an error.
+func (r *parseRecovery) parseTag(tagName string, tag interface{ Parse(*Tokenizer) error }, tokenizer *Tokenizer, span Span) (err error) {
+	r.push(tagName, tag, tokenizer, span)
+	defer r.pop()
+	defer func() {
+		if p := recover(); p != nil {
+			e, ok := p.(error)
+			if !ok {
+				panic(p)
+			}
+			err = e
+		}
+	}()
+	return tag.Parse(tokenizer)
+}
+
+// addTagError records an error returned by the Parse method of the tag
+// opened by the token at span: the tag is unclosed when the tokens ran out.
+func (r *parseRecovery) addTagError(err error, tokenizer *Tokenizer, span Span) {
+	if tokenizer.offset >= len(tokenizer.tokens) {
+		r.add(CodeUnclosedTag, DiagnosticError, err, span)
+		return
+	}
+	r.add(CodeInvalidTag, DiagnosticError, err, r.current())
+}
+
+// hasEndTag reports whether a token after the current one is the end tag of
+// a block named tagName.
+func hasEndTag(tokenizer *Tokenizer, tagName string) bool {
+	delimiter := "end" + tagName
+	for _, token := range tokenizer.tokens[tokenizer.offset:] {
+		if tokenizer.forLiquidTag {
+			if m := blockBodyLiquidTagToken.FindStringSubmatch(token); m != nil && m[1] == delimiter {
+				return true
+			}
+		} else if strings.HasPrefix(token, blockBodyTAGSTART) {
+			if m := blockBodyFullToken.FindStringSubmatch(token); m != nil && m[2] == delimiter {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// invalidBlock stands for a block tag whose markup did not parse in
+// ParseAll. It keeps the body for analysis and renders nothing.
+type invalidBlock struct {
+	*Block
+}
+
+// RenderToOutputBuffer renders nothing.
+func (b *invalidBlock) RenderToOutputBuffer(_ TagContext, _ *OutputBuffer) {}
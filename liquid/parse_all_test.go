@@ -0,0 +1,48 @@
+package liquid
+
+import "testing"
+
+func TestParseAllWithoutErrors(t *testing.T) {
+	tmpl, diagnostics := ParseAll("page", "Hello {{ name }}", nil)
+	if len(diagnostics) != 0 {
+		t.Fatalf("diagnostics = %v, want none", diagnostics)
+	}
+	if got := tmpl.Render(map[string]interface{}{"name": "Ann"}, nil); got != "Hello Ann" {
+		t.Errorf("Render() = %q, want %q", got, "Hello Ann")
+	}
+}
+
+func TestParseAllDiagnostics(t *testing.T) {
+	_, diagnostics := ParseAll("page", "{% bogus %}\n{{ a }}{% endif %}\n{{ b", nil)
+	want := []Diagnostic{
+		{Code: CodeUnknownTag, Line: 1, Column: 1, EndLine: 1, EndColumn: 12},
+		{Code: CodeUnexpectedTag, Line: 2, Column: 8, EndLine: 2, EndColumn: 19},
+		{Code: CodeUnterminatedOutput, Line: 3, Column: 1, EndLine: 3, EndColumn: 3},
+	}
+	if len(diagnostics) != len(want) {
+		t.Fatalf("diagnostics = %v, want %d", diagnostics, len(want))
+	}
+	for i, d := range diagnostics {
+		w := want[i]
+		if d.Code != w.Code || d.Line != w.Line || d.Column != w.Column || d.EndLine != w.EndLine || d.EndColumn != w.EndColumn {
+			t.Errorf("diagnostics[%d] = %+v, want %+v", i, d, w)
+		}
+		if d.Template != "page" || d.Severity != DiagnosticError {
+			t.Errorf("diagnostics[%d] = %+v, want an error in page", i, d)
+		}
+	}
+}
+
+func TestParseAllInvalidEncoding(t *testing.T) {
+	_, diagnostics := ParseAll("page", "\xff", nil)
+	if len(diagnostics) != 1 || diagnostics[0].Code != CodeInvalidEncoding {
+		t.Errorf("diagnostics = %v, want %s", diagnostics, CodeInvalidEncoding)
+	}
+}
+
+func TestDiagnosticString(t *testing.T) {
+	d := Diagnostic{Code: CodeUnclosedTag, Severity: DiagnosticWarning, Message: "oops", Template: "page", Line: 2, Column: 5}
+	if got, want := d.String(), "page:2:5: warning: oops (unclosed-tag)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -14,6 +14,7 @@ type ParseContext struct {
 	environment     *Environment
 	locale          *I18n
 	lineNumber      *int
+	span            Span // Span of the token being parsed
 	stringScanner   *StringScanner
 	expressionCache map[string]interface{}
 	templateOptions map[string]interface{}
@@ -23,6 +24,7 @@ type ParseContext struct {
 	depth           int
 	trimWhitespace  bool
 	partial         bool
+	recovery        *parseRecovery // Set by ParseAll
 }
 
 // NewParseContext creates a new ParseContext.
@@ -86,6 +88,9 @@ func (pc *ParseContext) Warnings() []error {
 // AddWarning adds a warning.
 func (pc *ParseContext) AddWarning(warning error) {
 	pc.warnings = append(pc.warnings, warning)
+	if pc.recovery != nil {
+		pc.recovery.addWarning(warning)
+	}
 }
 
 // ErrorMode returns the error mode.
@@ -193,7 +198,7 @@ func (pc *ParseContext) NewBlockBody() *BlockBody {
 func (pc *ParseContext) NewParser(input string) *Parser {
 	pc.stringScanner.SetString(input)
 	// Create parser from scanner (it will tokenize the scanner's string)
-	return NewParser(pc.stringScanner)
+	return newParser(pc.stringScanner, pc.environment.operators)
 }
 
 // NewTokenizer creates a new Tokenizer with the shared StringScanner.
@@ -215,10 +220,14 @@ func (pc *ParseContext) SafeParseExpression(parser *Parser) interface{} {
 			// Don't add markup context here - let ParserSwitching handle it
 			panic(err)
 		}
-		return Parse(expr, pc.stringScanner, pc.expressionCache)
+		return pc.parseExpression(expr)
 	}
 	// In lax mode, swallow errors
-	return SafeParse(parser, pc.stringScanner, pc.expressionCache)
+	expr, err := parser.Expression()
+	if err != nil {
+		return nil
+	}
+	return pc.parseExpression(expr)
 }
 
 // SafeParseCompleteExpression parses a complete expression and validates all tokens are consumed.
@@ -241,7 +250,7 @@ func (pc *ParseContext) SafeParseCompleteExpression(parser *Parser) interface{}
 				panic(err)
 			}
 		}
-		return Parse(expr, pc.stringScanner, pc.expressionCache)
+		return pc.parseExpression(expr)
 	}
 	// In lax mode, return nil if there are leftover tokens
 	if err := parser.Error(); err != nil {
@@ -255,12 +264,17 @@ func (pc *ParseContext) SafeParseCompleteExpression(parser *Parser) interface{}
 	if !parser.Look(":end_of_string", 0) {
 		return nil
 	}
-	return Parse(expr, pc.stringScanner, pc.expressionCache)
+	return pc.parseExpression(expr)
+}
+
+// parseExpression parses markup with the environment's registered operators.
+func (pc *ParseContext) parseExpression(markup string) interface{} {
+	return parseExpression(markup, pc.stringScanner, pc.expressionCache, pc.environment.operators)
 }
 
 // ParseExpression parses an expression.
 func (pc *ParseContext) ParseExpression(markup string) interface{} {
-	return Parse(markup, pc.stringScanner, pc.expressionCache)
+	return pc.parseExpression(markup)
 }
 
 // ParseExpressionSafe parses an expression with safe flag.
@@ -268,5 +282,5 @@ func (pc *ParseContext) ParseExpressionSafe(markup string, safe bool) interface{
 	if !safe && pc.errorMode == "rigid" {
 		panic(NewInternalError("unsafe parse_expression cannot be used in rigid mode"))
 	}
-	return Parse(markup, pc.stringScanner, pc.expressionCache)
+	return pc.parseExpression(markup)
 }
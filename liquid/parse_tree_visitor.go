@@ -5,6 +5,14 @@ import "reflect"
 // ParseTreeVisitorCallback is a function type for visitor callbacks.
 type ParseTreeVisitorCallback func(node interface{}, context interface{}) (interface{}, interface{})
 
+// ParseTreeNode is implemented by nodes whose children are not just their
+// nodelist, such as tags that evaluate expressions before rendering a body.
+type ParseTreeNode interface {
+	// ParseTreeChildren returns the expressions the node evaluates followed
+	// by the bodies it renders. Nil entries are skipped by the visitor.
+	ParseTreeChildren() []interface{}
+}
+
 // ParseTreeVisitor visits nodes in a parse tree.
 type ParseTreeVisitor struct {
 	node      interface{}
@@ -89,7 +97,31 @@ func (ptv *ParseTreeVisitor) Visit(context interface{}) []interface{} {
 	return result
 }
 
+// Children returns the child nodes of the visited node: the result of
+// ParseTreeChildren when the node implements ParseTreeNode, its nodelist otherwise.
+func (ptv *ParseTreeVisitor) Children() []interface{} {
+	return ptv.children()
+}
+
 func (ptv *ParseTreeVisitor) children() []interface{} {
+	if node, ok := ptv.node.(ParseTreeNode); ok {
+		if nodeValue := reflect.ValueOf(ptv.node); nodeValue.Kind() == reflect.Ptr && nodeValue.IsNil() {
+			return EmptyArray
+		}
+		children := node.ParseTreeChildren()
+		result := make([]interface{}, 0, len(children))
+		for _, child := range children {
+			if child == nil {
+				continue
+			}
+			if childValue := reflect.ValueOf(child); childValue.Kind() == reflect.Ptr && childValue.IsNil() {
+				continue
+			}
+			result = append(result, child)
+		}
+		return result
+	}
+
 	// Check if node has Nodelist method
 	nodeValue := reflect.ValueOf(ptv.node)
 	if !nodeValue.IsValid() {
@@ -24,7 +24,14 @@ func NewParser(input interface{}) *Parser {
 		ss = NewStringScanner(fmt.Sprintf("%v", v))
 	}
 
-	tokens, err := Tokenize(ss)
+	return newParser(ss, nil)
+}
+
+// newParser creates a parser whose lexer also recognizes the given registered
+// comparison operators.
+func newParser(ss *StringScanner, operators map[string]ConditionOperator) *Parser {
+	lexer := &Lexer{operators: operators}
+	tokens, err := lexer.Tokenize(ss)
 	if err != nil {
 		// If tokenization fails, create parser with empty tokens but store the error
 		tokens = []Token{lexerEOS}
@@ -124,8 +131,187 @@ func (p *Parser) Look(tokenType string, ahead int) bool {
 	return p.tokens[pos][0] == tokenType
 }
 
-// Expression parses an expression.
+// Expression parses an expression and returns it normalized for Parse.
+// Operators, from lowest to highest precedence, are or, and, not,
+// comparisons, + and -, * / and %, and unary minus; parentheses group
+// operations.
 func (p *Parser) Expression() (string, error) {
+	syntax, err := p.operation(0)
+	if err != nil {
+		return "", err
+	}
+	return syntax.String(), nil
+}
+
+// Binary operator precedence levels, from lowest to highest.
+const (
+	orLevel = iota
+	andLevel
+	comparisonLevel
+	additiveLevel
+	multiplicativeLevel
+	operatorLevels
+)
+
+// operationSyntax is a parsed expression: either an operand markup, or an
+// operator applied to one or two operations.
+type operationSyntax struct {
+	markup   string // Operand markup, when there is no operator
+	operator string
+	operands []*operationSyntax
+}
+
+// String returns the expression with the operands of operators in
+// parentheses, so that it parses back to the same operation.
+func (s *operationSyntax) String() string {
+	switch len(s.operands) {
+	case 0:
+		return s.markup
+	case 1:
+		if s.operator == "not" {
+			return "not " + s.operands[0].operandString()
+		}
+		return s.operator + s.operands[0].operandString()
+	}
+	return s.operands[0].operandString() + " " + s.operator + " " + s.operands[1].operandString()
+}
+
+func (s *operationSyntax) operandString() string {
+	if len(s.operands) == 0 {
+		return s.markup
+	}
+	return "(" + s.String() + ")"
+}
+
+// operation parses the binary operators of level and higher.
+func (p *Parser) operation(level int) (*operationSyntax, error) {
+	left, err := p.operationOperand(level)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator, ok := p.binaryOperator(level)
+		if !ok {
+			return left, nil
+		}
+		right, err := p.operationOperand(level)
+		if err != nil {
+			return nil, err
+		}
+		left = &operationSyntax{operator: operator, operands: []*operationSyntax{left, right}}
+	}
+}
+
+// operationOperand parses an operand of the binary operators of level.
+// "not" applies to comparisons, so it is parsed between and and comparisons.
+func (p *Parser) operationOperand(level int) (*operationSyntax, error) {
+	if level == andLevel && p.lookNot() {
+		p.p++
+		operand, err := p.operationOperand(level)
+		if err != nil {
+			return nil, err
+		}
+		return &operationSyntax{operator: "not", operands: []*operationSyntax{operand}}, nil
+	}
+	if level+1 == operatorLevels {
+		return p.unaryOperation()
+	}
+	return p.operation(level + 1)
+}
+
+// lookNot reports whether the next token is the not operator rather than a
+// variable named not.
+func (p *Parser) lookNot() bool {
+	if p.p+1 >= len(p.tokens) || p.tokens[p.p][0] != ":id" || p.tokens[p.p][1] != "not" {
+		return false
+	}
+	switch p.tokens[p.p+1][0] {
+	case ":id", ":number", ":string", ":open_round", ":open_square", ":open_curly", ":dash":
+		return true
+	}
+	return false
+}
+
+// binaryOperator consumes a binary operator of level.
+func (p *Parser) binaryOperator(level int) (string, bool) {
+	switch level {
+	case orLevel:
+		return p.ID("or")
+	case andLevel:
+		return p.ID("and")
+	case comparisonLevel:
+		return p.ConsumeOptional(":comparison")
+	case additiveLevel:
+		if operator, ok := p.ConsumeOptional(":plus"); ok {
+			return operator, true
+		}
+		if operator, ok := p.ConsumeOptional(":dash"); ok {
+			return operator, true
+		}
+		// The lexer reads "a -1" as a followed by the number -1
+		if p.Look(":number", 0) {
+			if number := fmt.Sprintf("%v", p.tokens[p.p][1]); strings.HasPrefix(number, "-") {
+				p.tokens[p.p] = Token{":number", number[1:]}
+				return "-", true
+			}
+		}
+	case multiplicativeLevel:
+		for _, tokenType := range []string{":times", ":divide", ":modulo"} {
+			if operator, ok := p.ConsumeOptional(tokenType); ok {
+				return operator, true
+			}
+		}
+	}
+	return "", false
+}
+
+// unaryOperation parses an operand with an optional unary minus.
+func (p *Parser) unaryOperation() (*operationSyntax, error) {
+	if _, ok := p.ConsumeOptional(":dash"); ok {
+		operand, err := p.unaryOperation()
+		if err != nil {
+			return nil, err
+		}
+		return &operationSyntax{operator: "-", operands: []*operationSyntax{operand}}, nil
+	}
+	return p.primary()
+}
+
+// primary parses a parenthesized expression, a range or an operand.
+func (p *Parser) primary() (*operationSyntax, error) {
+	if _, ok := p.ConsumeOptional(":open_round"); !ok {
+		markup, err := p.operandMarkup()
+		if err != nil {
+			return nil, err
+		}
+		return &operationSyntax{markup: markup}, nil
+	}
+
+	first, err := p.operation(0)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.ConsumeOptional(":close_round"); ok {
+		return first, nil
+	}
+	if len(first.operands) > 0 {
+		return nil, NewSyntaxError("Range bounds must not contain operators")
+	}
+	if _, err := p.Consume(":dotdot"); err != nil {
+		return nil, err
+	}
+	last, err := p.operandMarkup()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.Consume(":close_round"); err != nil {
+		return nil, err
+	}
+	return &operationSyntax{markup: fmt.Sprintf("(%s..%s)", first.markup, last)}, nil
+}
+
+// operandMarkup parses a variable lookup or a literal.
+func (p *Parser) operandMarkup() (string, error) {
 	if p.p >= len(p.tokens) {
 		return "", NewSyntaxError("Unexpected end of expression")
 	}
@@ -149,10 +335,17 @@ func (p *Parser) Expression() (string, error) {
 		if err != nil {
 			return "", err
 		}
+		if _, ok := p.ConsumeOptional(":close_square"); ok {
+			return "[]", nil
+		}
 		expr, err := p.Expression()
 		if err != nil {
 			return "", err
 		}
+		// A comma makes an array literal; [expr] alone stays a variable lookup
+		if p.Look(":comma", 0) {
+			return p.arrayLiteral(expr)
+		}
 		str += expr
 		closeSquare, err := p.Consume(":close_square")
 		if err != nil {
@@ -166,31 +359,73 @@ func (p *Parser) Expression() (string, error) {
 		return str + lookups, nil
 	case ":string", ":number":
 		return p.Consume(tokenType)
-	case ":open_round":
-		_, err := p.Consume(":open_round")
-		if err != nil {
-			return "", err
+	case ":open_curly":
+		return p.hashLiteral()
+	case ":plus", ":times", ":divide", ":modulo":
+		// An operator without left operand
+		return "", NewSyntaxError(fmt.Sprintf("Unexpected character %v", token[1]))
+	default:
+		return "", NewSyntaxError(fmt.Sprintf("%v is not a valid expression", token))
+	}
+}
+
+// arrayLiteral parses the rest of an array literal whose first element has
+// been parsed, e.g. ", 2, x]". A single element keeps its trailing comma so
+// that it is not read back as a variable lookup.
+func (p *Parser) arrayLiteral(first string) (string, error) {
+	elements := []string{first}
+	for {
+		if _, ok := p.ConsumeOptional(":comma"); !ok {
+			break
 		}
-		first, err := p.Expression()
-		if err != nil {
-			return "", err
+		if p.Look(":close_square", 0) {
+			break
 		}
-		_, err = p.Consume(":dotdot")
+		expr, err := p.Expression()
 		if err != nil {
 			return "", err
 		}
-		last, err := p.Expression()
-		if err != nil {
+		elements = append(elements, expr)
+	}
+	if _, err := p.Consume(":close_square"); err != nil {
+		return "", err
+	}
+	if len(elements) == 1 {
+		return "[" + first + ",]", nil
+	}
+	return "[" + strings.Join(elements, ", ") + "]", nil
+}
+
+// hashLiteral parses a hash literal, e.g. {"name": n, key: v}.
+func (p *Parser) hashLiteral() (string, error) {
+	if _, err := p.Consume(":open_curly"); err != nil {
+		return "", err
+	}
+	entries := []string{}
+	for !p.Look(":close_curly", 0) {
+		key, ok := p.ConsumeOptional(":string")
+		if !ok {
+			var err error
+			if key, err = p.Consume(":id"); err != nil {
+				return "", err
+			}
+		}
+		if _, err := p.Consume(":colon"); err != nil {
 			return "", err
 		}
-		_, err = p.Consume(":close_round")
+		value, err := p.Expression()
 		if err != nil {
 			return "", err
 		}
-		return fmt.Sprintf("(%s..%s)", first, last), nil
-	default:
-		return "", NewSyntaxError(fmt.Sprintf("%v is not a valid expression", token))
+		entries = append(entries, key+": "+value)
+		if _, ok := p.ConsumeOptional(":comma"); !ok {
+			break
+		}
+	}
+	if _, err := p.Consume(":close_curly"); err != nil {
+		return "", err
 	}
+	return "{" + strings.Join(entries, ", ") + "}", nil
 }
 
 // Argument parses an argument (possibly a keyword argument).
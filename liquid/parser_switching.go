@@ -10,6 +10,7 @@ type ParserSwitching struct {
 		AddWarning(error)
 	}
 	lineNumber    *int
+	span          Span
 	markupContext func(string) string
 }
 
@@ -76,6 +77,7 @@ func (p *ParserSwitching) rigidParseWithErrorContext(markup string, rigidParse f
 			if p.lineNumber != nil {
 				syntaxErr.Err.LineNumber = p.lineNumber
 			}
+			setErrorSpan(syntaxErr.Err, p.span)
 			if p.markupContext != nil {
 				syntaxErr.Err.MarkupContext = p.markupContext(markup)
 			}
@@ -93,6 +95,7 @@ func (p *ParserSwitching) strictParseWithErrorContext(markup string, strictParse
 			if p.lineNumber != nil {
 				syntaxErr.Err.LineNumber = p.lineNumber
 			}
+			setErrorSpan(syntaxErr.Err, p.span)
 			if p.markupContext != nil {
 				syntaxErr.Err.MarkupContext = p.markupContext(markup)
 			}
@@ -195,6 +195,83 @@ func TestParserExpressionArrayBrackets(t *testing.T) {
 	}
 }
 
+func TestParserExpressionLiterals(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"empty array", "[]", "[]"},
+		{"array", "[1,'b' , x.y]", "[1, 'b', x.y]"},
+		{"single element array", "[x,]", "[x,]"},
+		{"trailing comma", "[1, 2,]", "[1, 2]"},
+		{"nested array", "[[1, 2], [3,]]", "[[1, 2], [3,]]"},
+		{"empty hash", "{}", "{}"},
+		{"hash", "{\"name\": n, key: [1, 2],}", "{\"name\": n, key: [1, 2]}"},
+		{"nested hash", "{a: {b: 1}}", "{a: {b: 1}}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := NewParser(tt.input)
+			result, err := parser.Expression()
+			if err != nil {
+				t.Fatalf("Expression() error = %v", err)
+			}
+			if result != tt.want {
+				t.Errorf("Expression() = %v, want %v", result, tt.want)
+			}
+		})
+	}
+
+	for _, input := range []string{"[1, 2", "{a 1}", "{a: 1 b: 2}", "{1: 2}"} {
+		if _, err := NewParser(input).Expression(); err == nil {
+			t.Errorf("Expression(%q) expected error", input)
+		}
+	}
+}
+
+func TestParserExpressionOperators(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"additive", "a + b - 1", "(a + b) - 1"},
+		{"precedence", "a + b * c", "a + (b * c)"},
+		{"parentheses", "(a + b) * c", "(a + b) * c"},
+		{"modulo", "a % 2 == 0", "(a % 2) == 0"},
+		{"negative number", "a -1", "a - 1"},
+		{"unary minus", "-a * 2", "(-a) * 2"},
+		{"logic", "a > 1 and not b or c", "((a > 1) and (not b)) or c"},
+		{"not comparison", "not a == b", "not (a == b)"},
+		{"contains", "tags contains 'x'", "tags contains 'x'"},
+		{"range", "(1..n)", "(1..n)"},
+		{"variable named not", "not", "not"},
+		{"lookup", "items[i + 1].name", "items[i + 1].name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := NewParser(tt.input)
+			result, err := parser.Expression()
+			if err != nil {
+				t.Fatalf("Expression() error = %v", err)
+			}
+			if result != tt.want {
+				t.Errorf("Expression() = %v, want %v", result, tt.want)
+			}
+		})
+	}
+
+	for _, input := range []string{"a +", "* 2", "(a + b", "(a + 1..3)", "a and"} {
+		parser := NewParser(input)
+		if _, err := parser.Expression(); err == nil && parser.Look(":end_of_string", 0) {
+			t.Errorf("Expression(%q) expected error", input)
+		}
+	}
+}
+
 func TestParserExpressionRange(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -1,12 +1,212 @@
 package liquid
 
+import (
+	"container/list"
+	"reflect"
+	"strings"
+	"sync"
+)
+
 // PartialCache provides caching for partial templates.
 type PartialCache struct{}
 
+// VersionedFileSystem is implemented by file systems that can report a
+// version (a modification time, an ETag, a content hash...) of a template
+// without reading it. The shared partial cache re-reads a partial whenever
+// its version changes.
+type VersionedFileSystem interface {
+	FileSystem
+	TemplateVersion(templatePath string) (string, error)
+}
+
+// SharedPartialCacheOptions configures a SharedPartialCache.
+type SharedPartialCacheOptions struct {
+	// MaxEntries is the maximum number of cached partials (0 means no limit).
+	MaxEntries int
+	// MaxBytes is the maximum total source size of cached partials (0 means no limit).
+	MaxBytes int
+}
+
+// PartialCacheStats reports the activity of a SharedPartialCache.
+type PartialCacheStats struct {
+	Hits          uint64
+	Misses        uint64
+	Evictions     uint64 // Entries removed to respect the size limits
+	Invalidations uint64 // Entries removed because their template changed or was invalidated
+	Entries       int
+	Bytes         int
+}
+
+// SharedPartialCache caches parsed partials across renders with LRU eviction.
+// Entries are keyed by environment, file system, template name, error mode and
+// line number setting, and remember the version reported by a
+// VersionedFileSystem so that a changed template is parsed again. Partials
+// read from a file system that does not report versions stay cached until
+// evicted or invalidated. One cache may be set on several environments.
+//
+// SharedPartialCache is safe for concurrent use.
+type SharedPartialCache struct {
+	mu      sync.Mutex
+	options SharedPartialCacheOptions
+	entries map[sharedPartialKey]*list.Element
+	lru     *list.List // Front is the most recently used entry
+	bytes   int
+	stats   PartialCacheStats
+}
+
+type sharedPartialKey struct {
+	environment *Environment
+	fileSystem  FileSystem
+	name        string
+	errorMode   string
+	lineNumbers bool
+}
+
+type sharedPartialEntry struct {
+	key      sharedPartialKey
+	version  string
+	template *Template
+	size     int
+}
+
+// NewSharedPartialCache creates a new SharedPartialCache.
+func NewSharedPartialCache(options SharedPartialCacheOptions) *SharedPartialCache {
+	return &SharedPartialCache{
+		options: options,
+		entries: make(map[sharedPartialKey]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// Stats returns a snapshot of the cache statistics.
+func (c *SharedPartialCache) Stats() PartialCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = c.lru.Len()
+	stats.Bytes = c.bytes
+	return stats
+}
+
+// Len returns the number of cached partials.
+func (c *SharedPartialCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Invalidate removes every cached version of the named partial, including
+// the ones loaded through ParentTemplatePrefix names.
+func (c *SharedPartialCache) Invalidate(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, base := splitParentTemplateName(name)
+	for key, element := range c.entries {
+		if _, keyBase := splitParentTemplateName(key.name); keyBase == base {
+			c.remove(element)
+			c.stats.Invalidations++
+		}
+	}
+}
+
+// Clear removes all cached partials. Statistics are kept.
+func (c *SharedPartialCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[sharedPartialKey]*list.Element)
+	c.lru.Init()
+	c.bytes = 0
+}
+
+func (c *SharedPartialCache) get(key sharedPartialKey, version string) (*Template, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	entry := element.Value.(*sharedPartialEntry)
+	if entry.version != version {
+		c.remove(element)
+		c.stats.Invalidations++
+		c.stats.Misses++
+		return nil, false
+	}
+	c.lru.MoveToFront(element)
+	c.stats.Hits++
+	return entry.template, true
+}
+
+func (c *SharedPartialCache) add(key sharedPartialKey, version string, template *Template, size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+	if c.options.MaxBytes > 0 && size > c.options.MaxBytes {
+		return
+	}
+
+	entry := &sharedPartialEntry{key: key, version: version, template: template, size: size}
+	c.entries[key] = c.lru.PushFront(entry)
+	c.bytes += size
+
+	for c.overLimit() {
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
+	}
+}
+
+func (c *SharedPartialCache) overLimit() bool {
+	if c.options.MaxEntries > 0 && c.lru.Len() > c.options.MaxEntries {
+		return true
+	}
+	return c.options.MaxBytes > 0 && c.bytes > c.options.MaxBytes
+}
+
+func (c *SharedPartialCache) remove(element *list.Element) {
+	entry := c.lru.Remove(element).(*sharedPartialEntry)
+	delete(c.entries, entry.key)
+	c.bytes -= entry.size
+}
+
+// ResolveParentTemplateName resolves a partial name using ParentTemplatePrefix
+// relative to the template that references it: from "header" or from a
+// template that is not a layer of "header", "parent:header" stays as is; from
+// "parent:header" it becomes "parent:parent:header". Other names are returned unchanged.
+func ResolveParentTemplateName(name, caller string) string {
+	if !strings.HasPrefix(name, ParentTemplatePrefix) {
+		return name
+	}
+	nameDepth, nameBase := splitParentTemplateName(name)
+	callerDepth, callerBase := splitParentTemplateName(caller)
+	if callerDepth == 0 || callerBase != nameBase {
+		return name
+	}
+	return strings.Repeat(ParentTemplatePrefix, callerDepth+nameDepth) + nameBase
+}
+
+// splitParentTemplateName returns the number of ParentTemplatePrefix prefixes
+// of name and the name without them.
+func splitParentTemplateName(name string) (int, string) {
+	depth := 0
+	for strings.HasPrefix(name, ParentTemplatePrefix) {
+		name = name[len(ParentTemplatePrefix):]
+		depth++
+	}
+	return depth, name
+}
+
 // Load loads a partial template from cache or file system.
 func (pc *PartialCache) Load(templateName string, context interface {
 	Registers() *Registers
 }, parseContext ParseContextInterface) (interface{}, error) {
+	// A "parent:" name is relative to the template rendering it
+	if caller, ok := context.(interface{ TemplateName() string }); ok {
+		templateName = ResolveParentTemplateName(templateName, caller.TemplateName())
+	}
+
 	registers := context.Registers()
 	cachedPartials := registers.Get("cached_partials")
 
@@ -39,6 +239,29 @@ func (pc *PartialCache) Load(templateName string, context interface {
 		fs = &BlankFileSystem{}
 	}
 
+	// Check the cache shared across renders
+	var shared *SharedPartialCache
+	var sharedKey sharedPartialKey
+	var version string
+	if env := parseContext.Environment(); env != nil && env.PartialCache() != nil && reflect.TypeOf(fs).Comparable() {
+		shared = env.PartialCache()
+		sharedKey = sharedPartialKey{environment: env, fileSystem: fs, name: templateName, errorMode: errorMode}
+		if parseCtx, ok := parseContext.(*ParseContext); ok {
+			sharedKey.lineNumbers, _ = parseCtx.GetOption("line_numbers").(bool)
+		}
+		if vfs, ok := fs.(VersionedFileSystem); ok {
+			v, err := vfs.TemplateVersion(templateName)
+			if err != nil {
+				return nil, err
+			}
+			version = v
+		}
+		if tmpl, ok := shared.get(sharedKey, version); ok {
+			cache[cacheKey] = tmpl
+			return tmpl, nil
+		}
+	}
+
 	source, err := fs.ReadTemplateFile(templateName)
 	if err != nil {
 		return nil, err
@@ -120,6 +343,9 @@ func (pc *PartialCache) Load(templateName string, context interface {
 
 	// Cache the partial
 	cache[cacheKey] = tmpl
+	if shared != nil {
+		shared.add(sharedKey, version, tmpl, len(source))
+	}
 
 	return tmpl, nil
 }
@@ -295,3 +295,209 @@ func TestPartialCacheLoadDifferentErrorModes(t *testing.T) {
 		t.Logf("Note: Same instance despite different error modes (cache key may not include mode)")
 	}
 }
+
+// versionedFileSystem is an in-memory VersionedFileSystem counting reads.
+type versionedFileSystem struct {
+	templates map[string]string
+	versions  map[string]string
+	reads     int
+}
+
+func (v *versionedFileSystem) ReadTemplateFile(templatePath string) (string, error) {
+	v.reads++
+	source, ok := v.templates[templatePath]
+	if !ok {
+		return "", NewFileSystemError("No such template '" + templatePath + "'")
+	}
+	return source, nil
+}
+
+func (v *versionedFileSystem) TemplateVersion(templatePath string) (string, error) {
+	if _, ok := v.templates[templatePath]; !ok {
+		return "", NewFileSystemError("No such template '" + templatePath + "'")
+	}
+	return v.versions[templatePath], nil
+}
+
+// loadSharedPartial loads a partial the way a new render would, with fresh registers.
+func loadSharedPartial(t *testing.T, env *Environment, fs FileSystem, name string) *Template {
+	t.Helper()
+	registers := NewRegisters(nil)
+	registers.Set("file_system", fs)
+	partial, err := LoadPartial(name, &mockContextForPartial{registers: registers}, NewParseContext(ParseContextOptions{Environment: env}))
+	if err != nil {
+		t.Fatalf("LoadPartial(%q) error = %v", name, err)
+	}
+	return partial.(*Template)
+}
+
+func TestSharedPartialCacheAcrossRenders(t *testing.T) {
+	env := NewEnvironment()
+	env.SetPartialCache(NewSharedPartialCache(SharedPartialCacheOptions{}))
+	fs := &versionedFileSystem{
+		templates: map[string]string{"card": "{{ name }}"},
+		versions:  map[string]string{"card": "v1"},
+	}
+
+	first := loadSharedPartial(t, env, fs, "card")
+	second := loadSharedPartial(t, env, fs, "card")
+	if first != second || fs.reads != 1 {
+		t.Errorf("Expected the second render to reuse the parsed partial, got %d reads", fs.reads)
+	}
+
+	// A new version is read and parsed again
+	fs.templates["card"] = "{{ title }}"
+	fs.versions["card"] = "v2"
+	third := loadSharedPartial(t, env, fs, "card")
+	if third == first || fs.reads != 2 {
+		t.Errorf("Expected the changed partial to be read again, got %d reads", fs.reads)
+	}
+
+	stats := env.PartialCache().Stats()
+	want := PartialCacheStats{Hits: 1, Misses: 2, Invalidations: 1, Entries: 1, Bytes: len("{{ title }}")}
+	if stats != want {
+		t.Errorf("Stats() = %+v, want %+v", stats, want)
+	}
+}
+
+func TestSharedPartialCacheKeysByFileSystemAndErrorMode(t *testing.T) {
+	env := NewEnvironment()
+	env.SetPartialCache(NewSharedPartialCache(SharedPartialCacheOptions{}))
+	tenantA := &mockFileSystemWithTemplate{template: "a"}
+	tenantB := &mockFileSystemWithTemplate{template: "b"}
+
+	a := loadSharedPartial(t, env, tenantA, "card")
+	b := loadSharedPartial(t, env, tenantB, "card")
+	if a == b {
+		t.Error("Expected partials from different file systems to be cached separately")
+	}
+
+	env.SetErrorMode("strict")
+	loadSharedPartial(t, env, tenantA, "card")
+	if got := env.PartialCache().Len(); got != 3 {
+		t.Errorf("Len() = %d, want 3", got)
+	}
+}
+
+func TestSharedPartialCacheKeysByEnvironment(t *testing.T) {
+	cache := NewSharedPartialCache(SharedPartialCacheOptions{})
+	first := NewEnvironment()
+	first.SetPartialCache(cache)
+	second := NewEnvironment()
+	second.SetPartialCache(cache)
+	fs := &mockFileSystemWithTemplate{template: "{{ name }}"}
+
+	a := loadSharedPartial(t, first, fs, "card")
+	b := loadSharedPartial(t, second, fs, "card")
+	if a == b {
+		t.Error("Expected partials parsed for different environments to be cached separately")
+	}
+	if a.environment != first || b.environment != second {
+		t.Error("Expected each partial to be parsed with its own environment")
+	}
+	if got := cache.Len(); got != 2 {
+		t.Errorf("Len() = %d, want 2", got)
+	}
+}
+
+func TestSharedPartialCacheLRUEviction(t *testing.T) {
+	env := NewEnvironment()
+	env.SetPartialCache(NewSharedPartialCache(SharedPartialCacheOptions{MaxEntries: 2}))
+	fs := &versionedFileSystem{templates: map[string]string{"a": "a", "b": "b", "c": "c"}}
+
+	loadSharedPartial(t, env, fs, "a")
+	loadSharedPartial(t, env, fs, "b")
+	loadSharedPartial(t, env, fs, "a") // a is now the most recently used
+	loadSharedPartial(t, env, fs, "c") // evicts b
+
+	reads := fs.reads
+	loadSharedPartial(t, env, fs, "a")
+	if fs.reads != reads {
+		t.Error("Expected a to stay cached")
+	}
+	loadSharedPartial(t, env, fs, "b")
+	if fs.reads != reads+1 {
+		t.Error("Expected b to have been evicted")
+	}
+
+	stats := env.PartialCache().Stats()
+	if stats.Evictions != 2 || stats.Entries != 2 {
+		t.Errorf("Stats() = %+v, want 2 evictions and 2 entries", stats)
+	}
+}
+
+func TestSharedPartialCacheMaxBytes(t *testing.T) {
+	cache := NewSharedPartialCache(SharedPartialCacheOptions{MaxBytes: 10})
+	env := NewEnvironment()
+	env.SetPartialCache(cache)
+	fs := &versionedFileSystem{templates: map[string]string{
+		"small":  "12345",
+		"medium": "1234567",
+		"huge":   "12345678901",
+	}}
+
+	loadSharedPartial(t, env, fs, "small")
+	loadSharedPartial(t, env, fs, "medium") // evicts small
+	loadSharedPartial(t, env, fs, "huge")   // larger than the cache, not stored
+
+	stats := cache.Stats()
+	if stats.Entries != 1 || stats.Bytes != 7 || stats.Evictions != 1 {
+		t.Errorf("Stats() = %+v, want only medium cached", stats)
+	}
+}
+
+func TestSharedPartialCacheInvalidateAndClear(t *testing.T) {
+	cache := NewSharedPartialCache(SharedPartialCacheOptions{})
+	env := NewEnvironment()
+	env.SetPartialCache(cache)
+	fs := &versionedFileSystem{templates: map[string]string{"a": "a", "b": "b"}}
+
+	loadSharedPartial(t, env, fs, "a")
+	loadSharedPartial(t, env, fs, "b")
+	cache.Invalidate("a")
+	if cache.Len() != 1 || cache.Stats().Invalidations != 1 {
+		t.Errorf("Expected a to be invalidated, stats = %+v", cache.Stats())
+	}
+
+	loadSharedPartial(t, env, fs, "a")
+	loadSharedPartial(t, env, &versionedFileSystem{templates: map[string]string{"parent:a": "a"}}, "parent:a")
+	cache.Invalidate("a")
+	if cache.Len() != 1 {
+		t.Errorf("Expected a and parent:a to be invalidated, %d entries left", cache.Len())
+	}
+
+	cache.Clear()
+	if stats := cache.Stats(); stats.Entries != 0 || stats.Bytes != 0 {
+		t.Errorf("Stats() after Clear = %+v", stats)
+	}
+}
+
+func TestSharedPartialCacheMissingVersionedTemplate(t *testing.T) {
+	env := NewEnvironment()
+	env.SetPartialCache(NewSharedPartialCache(SharedPartialCacheOptions{}))
+	registers := NewRegisters(nil)
+	registers.Set("file_system", &versionedFileSystem{templates: map[string]string{}})
+
+	_, err := LoadPartial("missing", &mockContextForPartial{registers: registers}, NewParseContext(ParseContextOptions{Environment: env}))
+	if _, ok := err.(*FileSystemError); !ok {
+		t.Errorf("Expected FileSystemError, got %v", err)
+	}
+}
+
+func TestResolveParentTemplateName(t *testing.T) {
+	tests := []struct {
+		name, caller, want string
+	}{
+		{"header", "parent:header", "header"},
+		{"parent:header", "", "parent:header"},
+		{"parent:header", "header", "parent:header"},
+		{"parent:header", "parent:header", "parent:parent:header"},
+		{"parent:header", "parent:parent:header", "parent:parent:parent:header"},
+		{"parent:footer", "parent:header", "parent:footer"},
+	}
+	for _, tt := range tests {
+		if got := ResolveParentTemplateName(tt.name, tt.caller); got != tt.want {
+			t.Errorf("ResolveParentTemplateName(%q, %q) = %q, want %q", tt.name, tt.caller, got, tt.want)
+		}
+	}
+}
@@ -21,6 +21,11 @@ func (rl *RangeLookup) EndObj() interface{} {
 	return rl.endObj
 }
 
+// ParseTreeChildren returns the start and end expressions.
+func (rl *RangeLookup) ParseTreeChildren() []interface{} {
+	return []interface{}{rl.startObj, rl.endObj}
+}
+
 // Range represents a simple integer range.
 type Range struct {
 	Start int
@@ -0,0 +1,207 @@
+package liquid
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultReloadInterval is the polling interval of a ReloadingFileSystem
+// when none is configured.
+const DefaultReloadInterval = time.Second
+
+// ReloadingFileSystemOptions configures a ReloadingFileSystem.
+type ReloadingFileSystemOptions struct {
+	// Environment parses the templates returned by Template, and its
+	// partial cache is cleared of changed templates.
+	Environment *Environment
+	// Interval is the polling interval used by Start (DefaultReloadInterval when 0).
+	Interval time.Duration
+	// OnChange, if set, is called with the name of each changed template
+	// after it has been evicted.
+	OnChange func(name string)
+}
+
+// ReloadingFileSystem wraps a LocalFileSystem for development: it remembers
+// the modification time of every template it reads and, when polled, evicts
+// the templates whose file changed or was removed, so that the next render
+// reads the new source.
+//
+// Changed partials are removed from the partial cache of the environment, and
+// root templates obtained through Template are parsed again on their next use.
+// Polling only uses os.Stat; no file notification API is required.
+//
+// ReloadingFileSystem is safe for concurrent use.
+type ReloadingFileSystem struct {
+	local   *LocalFileSystem
+	options ReloadingFileSystemOptions
+
+	mu        sync.Mutex
+	versions  map[string]string    // Last seen version of each template read
+	templates map[string]*Template // Root templates parsed by Template
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// NewReloadingFileSystem creates a ReloadingFileSystem reading from local.
+func NewReloadingFileSystem(local *LocalFileSystem, options ReloadingFileSystemOptions) *ReloadingFileSystem {
+	if options.Interval <= 0 {
+		options.Interval = DefaultReloadInterval
+	}
+	return &ReloadingFileSystem{
+		local:     local,
+		options:   options,
+		versions:  make(map[string]string),
+		templates: make(map[string]*Template),
+	}
+}
+
+// ReadTemplateFile reads a template file and starts watching it.
+func (r *ReloadingFileSystem) ReadTemplateFile(templatePath string) (string, error) {
+	// The version is taken before reading, so a write in between is seen by the next poll
+	version, err := r.local.TemplateVersion(templatePath)
+	if err != nil {
+		return "", err
+	}
+	source, err := r.local.ReadTemplateFile(templatePath)
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	r.versions[templatePath] = version
+	r.mu.Unlock()
+	return source, nil
+}
+
+// Template returns the root template with the given name, parsed with the
+// environment of the options. The parsed template is reused until its file
+// changes.
+func (r *ReloadingFileSystem) Template(name string) (*Template, error) {
+	r.mu.Lock()
+	tmpl, ok := r.templates[name]
+	r.mu.Unlock()
+	if ok {
+		return tmpl, nil
+	}
+
+	source, err := r.ReadTemplateFile(name)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err = ParseTemplate(source, &TemplateOptions{Environment: r.options.Environment})
+	if err != nil {
+		return nil, err
+	}
+	tmpl.SetName(name)
+
+	r.mu.Lock()
+	r.templates[name] = tmpl
+	r.mu.Unlock()
+	return tmpl, nil
+}
+
+// Watched returns the names of the watched templates, sorted.
+func (r *ReloadingFileSystem) Watched() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, 0, len(r.versions))
+	for name := range r.versions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Poll checks the watched templates once, evicts the ones that changed and
+// returns their names, sorted. A removed template is evicted and no longer
+// watched until it is read again.
+func (r *ReloadingFileSystem) Poll() []string {
+	r.mu.Lock()
+	watched := make(map[string]string, len(r.versions))
+	for name, version := range r.versions {
+		watched[name] = version
+	}
+	r.mu.Unlock()
+
+	changed := []string{}
+	for name, version := range watched {
+		current, err := r.local.TemplateVersion(name)
+		if err == nil && current == version {
+			continue
+		}
+
+		r.mu.Lock()
+		// Skip templates read again since the snapshot
+		if r.versions[name] != version {
+			r.mu.Unlock()
+			continue
+		}
+		if err != nil {
+			delete(r.versions, name)
+		} else {
+			r.versions[name] = current
+		}
+		delete(r.templates, name)
+		r.mu.Unlock()
+
+		changed = append(changed, name)
+	}
+	sort.Strings(changed)
+
+	for _, name := range changed {
+		r.evict(name)
+	}
+	return changed
+}
+
+// evict removes a changed template from the partial cache and notifies OnChange.
+func (r *ReloadingFileSystem) evict(name string) {
+	if env := r.options.Environment; env != nil {
+		if cache := env.PartialCache(); cache != nil {
+			cache.Invalidate(name)
+		}
+	}
+	if r.options.OnChange != nil {
+		r.options.OnChange(name)
+	}
+}
+
+// Start polls the watched templates in the background until Stop is called.
+// Calling Start on a started file system does nothing.
+func (r *ReloadingFileSystem) Start() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stop != nil {
+		return
+	}
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+
+	go func(stop, done chan struct{}) {
+		defer close(done)
+		ticker := time.NewTicker(r.options.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				r.Poll()
+			}
+		}
+	}(r.stop, r.done)
+}
+
+// Stop stops background polling and waits for the current poll to finish.
+func (r *ReloadingFileSystem) Stop() {
+	r.mu.Lock()
+	stop, done := r.stop, r.done
+	r.stop, r.done = nil, nil
+	r.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
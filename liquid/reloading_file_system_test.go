@@ -0,0 +1,164 @@
+package liquid
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeTemplateFile writes a template and moves its modification time
+// forward, so that changes are detected on file systems with coarse mtimes.
+func writeTemplateFile(t *testing.T, dir, name, source string, age int) {
+	t.Helper()
+	path := filepath.Join(dir, "_"+name+".liquid")
+	if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Unix(1700000000+int64(age), 0)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloadingFileSystemPoll(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplateFile(t, dir, "card", "v1", 0)
+	writeTemplateFile(t, dir, "footer", "footer", 0)
+
+	var notified []string
+	fileSystem := NewReloadingFileSystem(NewLocalFileSystem(dir, ""), ReloadingFileSystemOptions{
+		OnChange: func(name string) { notified = append(notified, name) },
+	})
+	for _, name := range []string{"card", "footer"} {
+		if _, err := fileSystem.ReadTemplateFile(name); err != nil {
+			t.Fatalf("ReadTemplateFile(%q) error = %v", name, err)
+		}
+	}
+	if _, err := fileSystem.ReadTemplateFile("missing"); err == nil {
+		t.Error("Expected error for missing template")
+	}
+	if got := fileSystem.Watched(); !reflect.DeepEqual(got, []string{"card", "footer"}) {
+		t.Errorf("Watched() = %v", got)
+	}
+
+	if changed := fileSystem.Poll(); len(changed) != 0 {
+		t.Errorf("Poll() = %v, want no changes", changed)
+	}
+
+	writeTemplateFile(t, dir, "card", "v2", 1)
+	if changed := fileSystem.Poll(); !reflect.DeepEqual(changed, []string{"card"}) {
+		t.Errorf("Poll() = %v, want [card]", changed)
+	}
+	if changed := fileSystem.Poll(); len(changed) != 0 {
+		t.Errorf("Poll() = %v, want the change to be reported once", changed)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "_footer.liquid")); err != nil {
+		t.Fatal(err)
+	}
+	if changed := fileSystem.Poll(); !reflect.DeepEqual(changed, []string{"footer"}) {
+		t.Errorf("Poll() = %v, want [footer]", changed)
+	}
+	if got := fileSystem.Watched(); !reflect.DeepEqual(got, []string{"card"}) {
+		t.Errorf("Watched() = %v, want the removed template to be dropped", got)
+	}
+	if !reflect.DeepEqual(notified, []string{"card", "footer"}) {
+		t.Errorf("OnChange called with %v", notified)
+	}
+}
+
+func TestReloadingFileSystemEvictsPartialCache(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplateFile(t, dir, "card", "v1", 0)
+
+	env := NewEnvironment()
+	env.SetPartialCache(NewSharedPartialCache(SharedPartialCacheOptions{}))
+	fileSystem := NewReloadingFileSystem(NewLocalFileSystem(dir, ""), ReloadingFileSystemOptions{Environment: env})
+
+	first := loadSharedPartial(t, env, fileSystem, "card")
+	if loadSharedPartial(t, env, fileSystem, "card") != first {
+		t.Fatal("Expected the partial to be cached")
+	}
+
+	writeTemplateFile(t, dir, "card", "v2", 1)
+	if loadSharedPartial(t, env, fileSystem, "card") != first {
+		t.Error("Expected the partial to stay cached until the next poll")
+	}
+	fileSystem.Poll()
+	reloaded := loadSharedPartial(t, env, fileSystem, "card")
+	if reloaded == first || reloaded.Render(nil, nil) != "v2" {
+		t.Errorf("Expected the partial to be parsed again, got %q", reloaded.Render(nil, nil))
+	}
+}
+
+func TestReloadingFileSystemTemplate(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplateFile(t, dir, "page", "Hello {{ name }}", 0)
+	fileSystem := NewReloadingFileSystem(NewLocalFileSystem(dir, ""), ReloadingFileSystemOptions{})
+
+	first, err := fileSystem.Template("page")
+	if err != nil {
+		t.Fatalf("Template() error = %v", err)
+	}
+	if first.Name() != "page" {
+		t.Errorf("Name() = %q, want page", first.Name())
+	}
+	if second, _ := fileSystem.Template("page"); second != first {
+		t.Error("Expected the parsed template to be reused")
+	}
+
+	writeTemplateFile(t, dir, "page", "Bye {{ name }}", 1)
+	fileSystem.Poll()
+	reloaded, err := fileSystem.Template("page")
+	if err != nil {
+		t.Fatalf("Template() error = %v", err)
+	}
+	if got := reloaded.Render(map[string]interface{}{"name": "Ann"}, nil); got != "Bye Ann" {
+		t.Errorf("Render() = %q, want %q", got, "Bye Ann")
+	}
+
+	writeTemplateFile(t, dir, "broken", "{% if %}", 0)
+	if _, err := fileSystem.Template("broken"); err == nil {
+		t.Error("Expected a syntax error")
+	}
+}
+
+func TestReloadingFileSystemStartStop(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplateFile(t, dir, "card", "v1", 0)
+
+	changes := make(chan string, 1)
+	fileSystem := NewReloadingFileSystem(NewLocalFileSystem(dir, ""), ReloadingFileSystemOptions{
+		Interval: time.Millisecond,
+		OnChange: func(name string) { changes <- name },
+	})
+	if _, err := fileSystem.ReadTemplateFile("card"); err != nil {
+		t.Fatalf("ReadTemplateFile() error = %v", err)
+	}
+
+	fileSystem.Start()
+	fileSystem.Start()
+	defer fileSystem.Stop()
+	writeTemplateFile(t, dir, "card", "v2", 1)
+
+	select {
+	case name := <-changes:
+		if name != "card" {
+			t.Errorf("OnChange(%q), want card", name)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the change to be detected by background polling")
+	}
+
+	fileSystem.Stop()
+	fileSystem.Stop()
+}
+
+func TestNewReloadingFileSystemDefaultInterval(t *testing.T) {
+	fileSystem := NewReloadingFileSystem(NewLocalFileSystem(t.TempDir(), ""), ReloadingFileSystemOptions{})
+	if fileSystem.options.Interval != DefaultReloadInterval {
+		t.Errorf("Interval = %v, want %v", fileSystem.options.Interval, DefaultReloadInterval)
+	}
+}
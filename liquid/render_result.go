@@ -0,0 +1,38 @@
+package liquid
+
+// RenderResult is the outcome of a single Template.Execute call.
+type RenderResult struct {
+	// Assigns holds the variables assigned at the top level of the template
+	// (assign, capture, increment) when the render finished.
+	Assigns map[string]interface{}
+	// Profiler holds the profiling data when the template was parsed with Profile.
+	Profiler      *Profiler
+	Output        string
+	Errors        []error
+	Warnings      []error
+	ResourceUsage ResourceUsage
+}
+
+// ResourceUsage reports the resources consumed by a render.
+type ResourceUsage struct {
+	RenderScore  int
+	AssignScore  int
+	RenderLength int
+	LimitReached bool
+}
+
+// uniqueErrors returns errs without repeated occurrences of the same error.
+// In rethrow mode an error can be handled once where it occurs and again
+// where the render recovers from it.
+func uniqueErrors(errs []error) []error {
+	unique := make([]error, 0, len(errs))
+	seen := make(map[error]bool, len(errs))
+	for _, err := range errs {
+		if seen[err] {
+			continue
+		}
+		seen[err] = true
+		unique = append(unique, err)
+	}
+	return unique
+}
@@ -46,13 +46,13 @@ func (rl *ResourceLimits) IncrementAssignScore(amount int) {
 }
 
 // IncrementWriteScore updates either render_length or assign_score based on whether writes are captured.
-func (rl *ResourceLimits) IncrementWriteScore(output string) {
+// length is the number of bytes written so far to the output being rendered.
+func (rl *ResourceLimits) IncrementWriteScore(length int) {
 	if rl.lastCaptureLength != nil {
-		captured := len([]byte(output))
-		increment := captured - *rl.lastCaptureLength
-		rl.lastCaptureLength = &captured
+		increment := length - *rl.lastCaptureLength
+		rl.lastCaptureLength = &length
 		rl.IncrementAssignScore(increment)
-	} else if rl.renderLengthLimit != nil && len([]byte(output)) > *rl.renderLengthLimit {
+	} else if rl.renderLengthLimit != nil && length > *rl.renderLengthLimit {
 		rl.raiseLimitsReached()
 	}
 }
@@ -109,7 +109,7 @@ func TestResourceLimitsIncrementWriteScore(t *testing.T) {
 	rl := NewResourceLimits(config)
 
 	// Test without capture (should check render length limit)
-	rl.IncrementWriteScore("short")
+	rl.IncrementWriteScore(len("short"))
 	if rl.Reached() {
 		t.Error("Expected not reached for short output")
 	}
@@ -121,7 +121,7 @@ func TestResourceLimitsIncrementWriteScore(t *testing.T) {
 				t.Error("Expected panic when exceeding render length limit")
 			}
 		}()
-		rl.IncrementWriteScore("this is a very long string that exceeds the limit")
+		rl.IncrementWriteScore(len("this is a very long string that exceeds the limit"))
 	}()
 }
 
@@ -135,12 +135,12 @@ func TestResourceLimitsIncrementWriteScoreWithCapture(t *testing.T) {
 
 	// Test with capture
 	rl.WithCapture(func() {
-		rl.IncrementWriteScore("first")
+		rl.IncrementWriteScore(len("first"))
 		if rl.AssignScore() == 0 {
 			t.Error("Expected assign score to be incremented")
 		}
 
-		rl.IncrementWriteScore("first second")
+		rl.IncrementWriteScore(len("first second"))
 		// Should increment by difference in length
 		score := rl.AssignScore()
 		if score <= 5 {
@@ -149,7 +149,7 @@ func TestResourceLimitsIncrementWriteScoreWithCapture(t *testing.T) {
 	})
 
 	// After capture, should reset lastCaptureLength
-	rl.IncrementWriteScore("test")
+	rl.IncrementWriteScore(len("test"))
 	// Should check render length limit, not assign score
 	if rl.Reached() {
 		t.Error("Expected not reached after capture")
@@ -172,7 +172,7 @@ func TestResourceLimitsIncrementWriteScoreWithCaptureLimit(t *testing.T) {
 			}
 		}()
 		rl.WithCapture(func() {
-			rl.IncrementWriteScore("this is a very long string")
+			rl.IncrementWriteScore(len("this is a very long string"))
 		})
 	}()
 }
@@ -183,7 +183,7 @@ func TestResourceLimitsIncrementWriteScoreEmptyString(t *testing.T) {
 	rl := NewResourceLimits(config)
 
 	// Should not panic with empty string
-	rl.IncrementWriteScore("")
+	rl.IncrementWriteScore(len(""))
 	if rl.Reached() {
 		t.Error("Expected not reached for empty string")
 	}
@@ -205,6 +205,6 @@ func TestResourceLimitsIncrementWriteScoreByteLength(t *testing.T) {
 			}
 		}()
 		// Use a string with multi-byte characters
-		rl.IncrementWriteScore("测试测试测试") // 6 Chinese characters = 18 bytes
+		rl.IncrementWriteScore(len("测试测试测试")) // 6 Chinese characters = 18 bytes
 	}()
 }
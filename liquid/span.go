@@ -0,0 +1,109 @@
+package liquid
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Position is a place in template source. Lines and columns are 1-based and
+// columns count bytes; the zero Position is unknown.
+type Position struct {
+	Offset int // Byte offset from the start of the source
+	Line   int
+	Column int
+}
+
+// Span is the part of template source from Start up to End, excluded, that a
+// token, node or error comes from.
+type Span struct {
+	Start Position
+	End   Position
+}
+
+// IsValid reports whether the position is known.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// String formats the position as "line 12, col 7".
+func (p Position) String() string {
+	if !p.IsValid() {
+		return "unknown position"
+	}
+	return fmt.Sprintf("line %d, col %d", p.Line, p.Column)
+}
+
+// IsValid reports whether the span is known.
+func (s Span) IsValid() bool {
+	return s.Start.IsValid()
+}
+
+// String formats the start of the span as "line 12, col 7".
+func (s Span) String() string {
+	return s.Start.String()
+}
+
+// Source returns the text of source covered by the span.
+func (s Span) Source(source string) string {
+	if !s.IsValid() || s.Start.Offset > s.End.Offset || s.End.Offset > len(source) {
+		return ""
+	}
+	return source[s.Start.Offset:s.End.Offset]
+}
+
+// advance returns the position after text, read from p.
+func (p Position) advance(text string) Position {
+	p.Offset += len(text)
+	if i := strings.LastIndexByte(text, '\n'); i >= 0 {
+		p.Line += strings.Count(text, "\n")
+		p.Column = len(text) - i
+	} else {
+		p.Column += len(text)
+	}
+	return p
+}
+
+// in converts p, a position in text that starts at base in the template, to
+// a position in the template.
+func (p Position) in(base Position) Position {
+	if !base.IsValid() || !p.IsValid() {
+		return p
+	}
+	if p.Line == 1 {
+		return Position{Offset: base.Offset + p.Offset, Line: base.Line, Column: base.Column + p.Column - 1}
+	}
+	return Position{Offset: base.Offset + p.Offset, Line: base.Line + p.Line - 1, Column: p.Column}
+}
+
+// markupSpan returns the span of markup, found in token, where token starts
+// the span.
+func markupSpan(span Span, token, markup string) Span {
+	i := strings.Index(token, markup)
+	if i < 0 || markup == "" || !span.IsValid() {
+		return span
+	}
+	start := span.Start.advance(token[:i])
+	return Span{Start: start, End: start.advance(markup)}
+}
+
+// setErrorSpan sets the span of e to span unless it is already set.
+func setErrorSpan(e *Error, span Span) {
+	if e.Span == nil && span.IsValid() {
+		e.Span = &span
+	}
+}
+
+// parseSpan returns the span of the token being parsed with parseContext.
+func parseSpan(parseContext ParseContextInterface) Span {
+	if pc, ok := parseContext.(*ParseContext); ok {
+		return pc.span
+	}
+	return Span{}
+}
+
+// setParseSpan records span as the one of the token being parsed.
+func setParseSpan(parseContext ParseContextInterface, span Span) {
+	if pc, ok := parseContext.(*ParseContext); ok {
+		pc.span = span
+	}
+}
@@ -0,0 +1,76 @@
+package liquid
+
+import "testing"
+
+func TestPositionString(t *testing.T) {
+	if got, want := (Position{Offset: 20, Line: 12, Column: 7}).String(), "line 12, col 7"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if got, want := (Span{}).String(), "unknown position"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestNodeSpans(t *testing.T) {
+	env := NewEnvironment()
+	env.RegisterTag("custom", func(tagName, markup string, parseContext ParseContextInterface) (interface{}, error) {
+		return NewTag(tagName, markup, parseContext), nil
+	})
+	source := "Hi {{ name | upcase }}\n{% custom %}{% liquid custom  a %}"
+	tmpl, err := ParseTemplate(source, &TemplateOptions{Environment: env})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	nodes := tmpl.Root().Nodelist()
+	if got, want := nodes[1].(*Variable).Span(), (Span{Start: Position{3, 1, 4}, End: Position{22, 1, 23}}); got != want {
+		t.Errorf("variable Span() = %#v, want %#v", got, want)
+	}
+	for i, want := range []string{"{% custom %}", "custom  a"} {
+		if got := nodes[3+i].(*Tag).Span().Source(source); got != want {
+			t.Errorf("tag %d Span().Source() = %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestSyntaxErrorSpan(t *testing.T) {
+	_, err := ParseTemplate("ok\n  {{ a }}{{ b", nil)
+	syntaxErr, ok := err.(*SyntaxError)
+	if !ok {
+		t.Fatalf("ParseTemplate() error = %v, want a SyntaxError", err)
+	}
+	if syntaxErr.Err.LineNumber != nil {
+		t.Errorf("LineNumber = %d, want nil without the LineNumbers option", *syntaxErr.Err.LineNumber)
+	}
+	if span := syntaxErr.Err.Span; span == nil || span.String() != "line 2, col 10" {
+		t.Errorf("Span = %v, want line 2, col 10", span)
+	}
+}
+
+// failAfterBody is a block tag that raises an error after rendering its body.
+type failAfterBody struct {
+	*Block
+}
+
+func (b *failAfterBody) RenderToOutputBuffer(context TagContext, output *OutputBuffer) {
+	b.Body().RenderToOutputBuffer(context, output)
+	output.WriteString(context.HandleError(NewStandardError("after body"), b.LineNumber()))
+}
+
+func TestRenderErrorSpanAfterBody(t *testing.T) {
+	env := NewEnvironment()
+	env.RegisterTag("fail", func(tagName, markup string, parseContext ParseContextInterface) (interface{}, error) {
+		return &failAfterBody{NewBlock(tagName, markup, parseContext)}, nil
+	})
+	tmpl, err := ParseTemplate("{% fail %}\n  {{ a }}{% endfail %}", &TemplateOptions{Environment: env})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	tmpl.Render(nil, nil)
+	errs := tmpl.Errors()
+	if len(errs) != 1 {
+		t.Fatalf("Errors() = %v, want 1", errs)
+	}
+	if span := errs[0].(LiquidError).GetError().Span; span == nil || span.String() != "line 1, col 1" {
+		t.Errorf("Span = %v, want line 1, col 1 of the fail tag", span)
+	}
+}
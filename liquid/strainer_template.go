@@ -184,9 +184,14 @@ func (st *StrainerTemplate) Invoke(method string, args ...interface{}) (interfac
 			continue
 		}
 
-		// Check if method signature matches (first arg is input, rest are filter args)
+		// Check if method signature matches (first arg is input, rest are filter args).
+		// Filters that declare a leading *Context parameter receive the render context.
 		methodType := methodValue.Type()
-		if methodType.NumIn() < 1 {
+		offset := 0
+		if methodType.NumIn() > 0 && methodType.In(0) == contextPtrType {
+			offset = 1
+		}
+		if methodType.NumIn() < 1+offset {
 			continue
 		}
 
@@ -205,20 +210,23 @@ func (st *StrainerTemplate) Invoke(method string, args ...interface{}) (interfac
 		}
 
 		// Build call arguments - convert all args to reflect.Value
-		callArgs := make([]reflect.Value, len(args))
+		callArgs := make([]reflect.Value, len(args)+offset)
+		if offset == 1 {
+			callArgs[0] = reflect.ValueOf(st.renderContext())
+		}
 		for i := 0; i < len(args); i++ {
 			if args[i] == nil {
 				// For nil values, use zero value of the expected type
 				var paramType reflect.Type
-				if isVariadic && i >= minRequired {
+				if isVariadic && i+offset >= minRequired {
 					// For variadic params beyond fixed params, use element type
 					paramType = methodType.In(numIn - 1).Elem()
 				} else {
-					paramType = methodType.In(i)
+					paramType = methodType.In(i + offset)
 				}
-				callArgs[i] = reflect.Zero(paramType)
+				callArgs[i+offset] = reflect.Zero(paramType)
 			} else {
-				callArgs[i] = reflect.ValueOf(args[i])
+				callArgs[i+offset] = reflect.ValueOf(args[i])
 			}
 		}
 
@@ -245,6 +253,18 @@ func (st *StrainerTemplate) Invoke(method string, args ...interface{}) (interfac
 	return nil, nil
 }
 
+// contextPtrType is the type of a filter's optional leading *Context parameter.
+var contextPtrType = reflect.TypeOf((*Context)(nil))
+
+// renderContext returns the *Context the strainer was created for, or nil.
+func (st *StrainerTemplate) renderContext() *Context {
+	if st.context == nil {
+		return nil
+	}
+	ctx, _ := st.context.Context().(*Context)
+	return ctx
+}
+
 // snakeToCamelCase converts snake_case to CamelCase.
 // e.g., find_index -> FindIndex, sort_natural -> SortNatural, strip_html -> StripHTML
 func snakeToCamelCase(s string) string {
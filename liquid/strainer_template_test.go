@@ -208,3 +208,41 @@ func TestStrainerTemplateInvokeErrorHandling(t *testing.T) {
 		t.Errorf("Expected 'arg' in non-strict mode, got %v", result)
 	}
 }
+
+type contextAwareFilter struct{}
+
+func (f *contextAwareFilter) TemplateName(ctx *Context, input interface{}, suffix string) interface{} {
+	if ctx == nil {
+		return "no context"
+	}
+	return ToS(input, nil) + ctx.TemplateName() + suffix
+}
+
+// TestStrainerTemplateInvokeWithContextParameter tests that filters declaring a leading *Context receive it
+func TestStrainerTemplateInvokeWithContextParameter(t *testing.T) {
+	stc := NewStrainerTemplateClass()
+	filter := &contextAwareFilter{}
+	_ = stc.AddFilter(filter)
+
+	ctx := NewContext()
+	ctx.SetTemplateName("page")
+	st := NewStrainerTemplateWithFilters(stc, ctx, false, []interface{}{filter})
+
+	result, err := st.Invoke("template_name", "in ", "!")
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if result != "in page!" {
+		t.Errorf("Expected 'in page!', got %v", result)
+	}
+
+	// A strainer without a *Context passes nil
+	st = NewStrainerTemplateWithFilters(stc, &mockContext{}, false, []interface{}{filter})
+	result, err = st.Invoke("template_name", "in ")
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if result != "no context" {
+		t.Errorf("Expected 'no context', got %v", result)
+	}
+}
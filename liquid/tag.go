@@ -28,6 +28,7 @@ type Tag struct {
 	markup       string
 	parseContext ParseContextInterface
 	lineNumber   *int
+	span         Span
 	nodelist     []interface{} // For block tags
 }
 
@@ -43,6 +44,7 @@ func NewTag(tagName, markup string, parseContext ParseContextInterface) *Tag {
 		markup:       markup,
 		parseContext: parseContext,
 		lineNumber:   lineNum,
+		span:         parseSpan(parseContext),
 		nodelist:     []interface{}{},
 	}
 }
@@ -72,6 +74,19 @@ func (t *Tag) LineNumber() *int {
 	return t.lineNumber
 }
 
+// Span returns where the tag is in the template. The span of a block tag
+// ends with its end tag.
+func (t *Tag) Span() Span {
+	return t.span
+}
+
+// extendSpan moves the end of the span of the tag to end.
+func (t *Tag) extendSpan(end Position) {
+	if t.span.IsValid() && end.Offset > t.span.End.Offset {
+		t.span.End = end
+	}
+}
+
 // ParseContext returns the parse context.
 func (t *Tag) ParseContext() ParseContextInterface {
 	return t.parseContext
@@ -102,10 +117,10 @@ func (t *Tag) Render(context TagContext) string {
 // Note: Due to Go's method dispatch with embedded pointers, this method cannot
 // automatically call overridden Render() methods in subtypes. Tags that override
 // Render() must be handled specially in the rendering code.
-func (t *Tag) RenderToOutputBuffer(context TagContext, output *string) {
+func (t *Tag) RenderToOutputBuffer(context TagContext, output *OutputBuffer) {
 	renderResult := t.Render(context)
 	if renderResult != "" {
-		*output += renderResult
+		output.WriteString(renderResult)
 	}
 }
 
@@ -15,13 +15,13 @@ func (d *Disableable) RenderToOutputBuffer(
 	context liquid.TagContext,
 	lineNumber *int,
 	parseContext liquid.ParseContextInterface,
-	output *string,
+	output *liquid.OutputBuffer,
 	renderFn func(),
 ) {
 	disabled := context.TagDisabled(tagName)
 	if disabled {
 		errorMsg := d.disabledError(tagName, context, lineNumber, parseContext)
-		*output += errorMsg
+		output.WriteString(errorMsg)
 		return
 	}
 	renderFn()
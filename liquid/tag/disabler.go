@@ -13,7 +13,7 @@ type Disabler struct{}
 func (d *Disabler) RenderToOutputBuffer(
 	disabledTags []string,
 	context liquid.TagContext,
-	output *string,
+	output *liquid.OutputBuffer,
 	renderFn func(),
 ) {
 	context.WithDisabledTags(disabledTags, func() {
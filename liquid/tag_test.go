@@ -146,19 +146,19 @@ func TestTagRenderToOutputBuffer(t *testing.T) {
 
 	tag := NewTag("test", "", pc)
 	ctx := NewContext()
-	output := ""
+	output := NewOutputBuffer()
 
 	// Test with empty render result
-	tag.RenderToOutputBuffer(ctx, &output)
-	if output != "" {
-		t.Errorf("Expected empty output, got %q", output)
+	tag.RenderToOutputBuffer(ctx, output)
+	if output.String() != "" {
+		t.Errorf("Expected empty output, got %q", output.String())
 	}
 
 	// Test with non-empty render result (Tag.Render returns empty, so this tests the path)
 	// Since Tag.Render always returns empty, output should remain empty
-	tag.RenderToOutputBuffer(ctx, &output)
-	if output != "" {
-		t.Errorf("Expected empty output, got %q", output)
+	tag.RenderToOutputBuffer(ctx, output)
+	if output.String() != "" {
+		t.Errorf("Expected empty output, got %q", output.String())
 	}
 }
 
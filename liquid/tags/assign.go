@@ -49,7 +49,7 @@ func (a *AssignTag) From() *liquid.Variable {
 }
 
 // RenderToOutputBuffer renders the assign tag.
-func (a *AssignTag) RenderToOutputBuffer(context liquid.TagContext, output *string) {
+func (a *AssignTag) RenderToOutputBuffer(context liquid.TagContext, output *liquid.OutputBuffer) {
 	val := a.from.Render(context)
 
 	// Set in the last scope (outermost scope, matching Ruby's context.scopes.last[@to] = val)
@@ -91,3 +91,33 @@ func assignScoreOf(val interface{}) int {
 func (a *AssignTag) Blank() bool {
 	return true
 }
+
+// ParseTreeChildren returns the assigned variable expression.
+func (a *AssignTag) ParseTreeChildren() []interface{} {
+	return []interface{}{a.from}
+}
+
+// VariableScope reports the assigned variable. A plain copy such as
+// {% assign c = contact %} keeps its source so lookups through it resolve.
+func (a *AssignTag) VariableScope() *liquid.VariableScope {
+	assign := liquid.ScopedVariable{Name: a.to}
+	if a.from != nil && len(a.from.Filters()) == 0 {
+		assign.Source = a.from.Name()
+	}
+	return &liquid.VariableScope{Assigns: []liquid.ScopedVariable{assign}}
+}
+
+// MarshalNode writes the tag for compiled templates.
+func (a *AssignTag) MarshalNode(enc *liquid.NodeEncoder) {
+	enc.EncodeTag(a.Tag)
+	enc.WriteString(a.to)
+	enc.Encode(a.from)
+}
+
+// decodeAssignTag rebuilds an AssignTag written by MarshalNode.
+func decodeAssignTag(dec *liquid.NodeDecoder) (interface{}, error) {
+	tag := &AssignTag{Tag: dec.DecodeTag()}
+	tag.to = dec.ReadString()
+	tag.from = dec.DecodeVariable()
+	return tag, dec.Err()
+}
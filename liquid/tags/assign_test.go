@@ -22,8 +22,8 @@ func TestAssignTag(t *testing.T) {
 	}
 
 	ctx := liquid.NewContext()
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 
 	// Check that variable was assigned
 	val := ctx.Get("var")
@@ -53,8 +53,8 @@ func TestAssignTagEmptyString(t *testing.T) {
 	}
 
 	ctx := liquid.NewContext()
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 
 	val := ctx.Get("a")
 	if val != "" {
@@ -72,8 +72,8 @@ func TestAssignTagFromVariable(t *testing.T) {
 		t.Fatalf("NewAssignTag() error = %v", err)
 	}
 
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 
 	// Check that var2 was assigned from var
 	val := ctx.Get("var2")
@@ -96,8 +96,8 @@ func TestAssignTagWithHyphenInVariableName(t *testing.T) {
 	}
 
 	ctx := liquid.NewContext()
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 
 	// Check that variable was assigned
 	val := ctx.Get("this-thing")
@@ -119,8 +119,8 @@ func TestAssignTagWithArray(t *testing.T) {
 		t.Fatalf("NewAssignTag() error = %v", err)
 	}
 
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 
 	// Check that foo was assigned
 	val := ctx.Get("foo")
@@ -173,8 +173,8 @@ func TestAssignTagAssignScoreOf(t *testing.T) {
 	// Test assignScoreOf with string
 	tag1, _ := NewAssignTag("assign", `var = "hello"`, pc)
 	ctx.Set("var", "hello")
-	var output1 string
-	tag1.RenderToOutputBuffer(ctx, &output1)
+	output1 := liquid.NewOutputBuffer()
+	tag1.RenderToOutputBuffer(ctx, output1)
 	// assignScoreOf is called internally, verify assignment worked
 	if ctx.Get("var") != "hello" {
 		t.Error("Expected variable to be assigned")
@@ -183,8 +183,8 @@ func TestAssignTagAssignScoreOf(t *testing.T) {
 	// Test assignScoreOf with array
 	tag2, _ := NewAssignTag("assign", `arr = values`, pc)
 	ctx.Set("values", []interface{}{"a", "b", "c"})
-	var output2 string
-	tag2.RenderToOutputBuffer(ctx, &output2)
+	output2 := liquid.NewOutputBuffer()
+	tag2.RenderToOutputBuffer(ctx, output2)
 	arr := ctx.Get("arr")
 	if arr == nil {
 		t.Error("Expected array to be assigned")
@@ -193,8 +193,8 @@ func TestAssignTagAssignScoreOf(t *testing.T) {
 	// Test assignScoreOf with map
 	tag3, _ := NewAssignTag("assign", `map = data`, pc)
 	ctx.Set("data", map[string]interface{}{"key": "value"})
-	var output3 string
-	tag3.RenderToOutputBuffer(ctx, &output3)
+	output3 := liquid.NewOutputBuffer()
+	tag3.RenderToOutputBuffer(ctx, output3)
 	m := ctx.Get("map")
 	if m == nil {
 		t.Error("Expected map to be assigned")
@@ -212,8 +212,8 @@ func TestAssignTagWithMap(t *testing.T) {
 		t.Fatalf("NewAssignTag() error = %v", err)
 	}
 
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 
 	// Check that r was assigned
 	val := ctx.Get("r")
@@ -243,8 +243,8 @@ func TestAssignTagWithResourceLimits(t *testing.T) {
 		t.Fatalf("NewAssignTag() error = %v", err)
 	}
 
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 
 	// Verify assignment worked
 	val := ctx.Get("var")
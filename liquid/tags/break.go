@@ -17,7 +17,17 @@ func NewBreakTag(tagName, markup string, parseContext liquid.ParseContextInterfa
 }
 
 // RenderToOutputBuffer renders the break tag by pushing a break interrupt.
-func (b *BreakTag) RenderToOutputBuffer(context liquid.TagContext, output *string) {
+func (b *BreakTag) RenderToOutputBuffer(context liquid.TagContext, output *liquid.OutputBuffer) {
 	interrupt := liquid.NewBreakInterrupt()
 	context.PushInterrupt(interrupt)
 }
+
+// MarshalNode writes the tag for compiled templates.
+func (b *BreakTag) MarshalNode(enc *liquid.NodeEncoder) {
+	enc.EncodeTag(b.Tag)
+}
+
+// decodeBreakTag rebuilds a BreakTag written by MarshalNode.
+func decodeBreakTag(dec *liquid.NodeDecoder) (interface{}, error) {
+	return &BreakTag{Tag: dec.DecodeTag()}, dec.Err()
+}
@@ -0,0 +1,166 @@
+package tags
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Notifuse/liquidgo/liquid"
+)
+
+// fragmentStoreRegister is the register overriding the fragment store of the environment.
+const fragmentStoreRegister = "fragment_store"
+
+// CacheTag stores the output of its body in a liquid.FragmentStore:
+// {% cache 'products', collection.id, expires_in: 300 %}...{% endcache %}.
+// The key is made of the name of the template, the position of the tag and the
+// evaluated key expressions, so that templates sharing a store don't serve each
+// other's fragments; expires_in is in seconds.
+// Without a store, the body is rendered every time.
+type CacheTag struct {
+	*liquid.Block
+	keys      []interface{} // Key expressions
+	expiresIn interface{}   // Expression or nil
+}
+
+// NewCacheTag creates a new CacheTag.
+func NewCacheTag(tagName, markup string, parseContext liquid.ParseContextInterface) (*CacheTag, error) {
+	keys, options, err := parseMacroArguments(markup, parseContext)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, liquid.NewSyntaxError("Syntax Error in 'cache' - Valid syntax: cache key, key, expires_in: seconds")
+	}
+
+	tag := &CacheTag{
+		Block: liquid.NewBlock(tagName, markup, parseContext),
+		keys:  keys,
+	}
+	for _, option := range options {
+		if option.name != "expires_in" {
+			return nil, liquid.NewSyntaxError("Syntax Error in 'cache' - Unknown option '" + option.name + "'")
+		}
+		tag.expiresIn = option.value
+	}
+	return tag, nil
+}
+
+// Keys returns the key expressions.
+func (c *CacheTag) Keys() []interface{} {
+	return c.keys
+}
+
+// ExpiresIn returns the expires_in expression, or nil.
+func (c *CacheTag) ExpiresIn() interface{} {
+	return c.expiresIn
+}
+
+// Blank returns false so the cached output is never skipped.
+func (c *CacheTag) Blank() bool {
+	return false
+}
+
+// RenderToOutputBuffer writes the stored fragment, or renders the body and
+// stores it. Bodies that raised errors are not stored.
+func (c *CacheTag) RenderToOutputBuffer(context liquid.TagContext, output *liquid.OutputBuffer) {
+	ctx, ok := context.(*liquid.Context)
+	if !ok {
+		output.WriteString(context.HandleError(liquid.NewInternalError("context is not a liquid.Context"), c.LineNumber()))
+		return
+	}
+
+	store := contextFragmentStore(ctx)
+	if store == nil {
+		c.Block.RenderToOutputBuffer(context, output)
+		return
+	}
+	ttl, err := c.ttl(ctx)
+	if err != nil {
+		output.WriteString(context.HandleError(err, c.LineNumber()))
+		return
+	}
+
+	key := c.key(ctx)
+	if fragment, ok := store.Get(key); ok {
+		output.WriteString(fragment)
+		if rl := ctx.ResourceLimits(); rl != nil {
+			rl.IncrementWriteScore(output.Len())
+		}
+		return
+	}
+
+	errorCount := len(ctx.Errors())
+	fragment := liquid.NewOutputBuffer()
+	c.Block.RenderToOutputBuffer(context, fragment)
+	output.WriteString(fragment.String())
+	if len(ctx.Errors()) == errorCount && !ctx.Interrupt() {
+		store.Set(key, fragment.String(), ttl)
+	}
+}
+
+// key joins the template name, the offset of the tag and the evaluated key
+// expressions. Each part is prefixed with its length so that parts containing
+// the separator can't collide.
+func (c *CacheTag) key(ctx *liquid.Context) string {
+	var key strings.Builder
+	writeKeyPart(&key, ctx.TemplateName())
+	writeKeyPart(&key, strconv.Itoa(c.Span().Start.Offset))
+	for _, expr := range c.keys {
+		writeKeyPart(&key, liquid.ToS(ctx.Evaluate(expr), nil))
+	}
+	return key.String()
+}
+
+// writeKeyPart writes part to key as "<length>:<part>".
+func writeKeyPart(key *strings.Builder, part string) {
+	key.WriteString(strconv.Itoa(len(part)))
+	key.WriteByte(':')
+	key.WriteString(part)
+}
+
+// ttl evaluates expires_in.
+func (c *CacheTag) ttl(ctx *liquid.Context) (time.Duration, error) {
+	if c.expiresIn == nil {
+		return 0, nil
+	}
+	seconds, ok := liquid.ToNumberValue(ctx.Evaluate(c.expiresIn))
+	if !ok || seconds < 0 {
+		return 0, liquid.NewArgumentError("cache tag expires_in must be a positive number of seconds")
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// ParseTreeChildren returns the key and expires_in expressions, and the body.
+func (c *CacheTag) ParseTreeChildren() []interface{} {
+	children := append([]interface{}{}, c.keys...)
+	children = append(children, c.expiresIn)
+	return append(children, c.Nodelist()...)
+}
+
+// MarshalNode writes the tag for compiled templates.
+func (c *CacheTag) MarshalNode(enc *liquid.NodeEncoder) {
+	enc.EncodeBlock(c.Block)
+	enc.Encode(c.keys)
+	enc.Encode(c.expiresIn)
+}
+
+// decodeCacheTag rebuilds a CacheTag written by MarshalNode.
+func decodeCacheTag(dec *liquid.NodeDecoder) (interface{}, error) {
+	tag := &CacheTag{Block: dec.DecodeBlock()}
+	tag.keys = dec.DecodeArray()
+	tag.expiresIn = dec.Decode()
+	return tag, dec.Err()
+}
+
+// contextFragmentStore returns the fragment store of the render: the
+// "fragment_store" register, or the store of the environment.
+func contextFragmentStore(ctx *liquid.Context) liquid.FragmentStore {
+	if store, ok := ctx.Registers().Get(fragmentStoreRegister).(liquid.FragmentStore); ok {
+		return store
+	}
+	if env := ctx.Environment(); env != nil {
+		return env.FragmentStore()
+	}
+	return nil
+}
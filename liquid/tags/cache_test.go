@@ -0,0 +1,149 @@
+package tags
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Notifuse/liquidgo/liquid"
+)
+
+func TestNewCacheTag(t *testing.T) {
+	pc := liquid.NewParseContext(liquid.ParseContextOptions{})
+	tag, err := NewCacheTag("cache", "'grid', collection.id, expires_in: 300", pc)
+	if err != nil {
+		t.Fatalf("NewCacheTag() error = %v", err)
+	}
+	if len(tag.Keys()) != 2 || tag.Keys()[0] != "grid" || tag.ExpiresIn() != 300 {
+		t.Errorf("Keys() = %v, ExpiresIn() = %v", tag.Keys(), tag.ExpiresIn())
+	}
+
+	for _, markup := range []string{"", "expires_in: 5", "'a', size: 1", "expires_in: 5, 'a'"} {
+		if _, err := NewCacheTag("cache", markup, pc); err == nil {
+			t.Errorf("NewCacheTag(%q) expected error", markup)
+		}
+	}
+}
+
+func TestCacheTagStoresFragments(t *testing.T) {
+	store := liquid.NewMemoryFragmentStore(liquid.MemoryFragmentStoreOptions{})
+	env := newTestEnvironment(nil, RegisterCacheTag)
+	env.SetFragmentStore(store)
+	template := parseTestTemplate(t, env, `{% cache 'grid', id %}{{ id }}-{{ name }}{% endcache %}`)
+
+	tests := []struct {
+		assigns map[string]interface{}
+		want    string
+	}{
+		{map[string]interface{}{"id": 1, "name": "Ann"}, "1-Ann"},
+		{map[string]interface{}{"id": 1, "name": "Bob"}, "1-Ann"},
+		{map[string]interface{}{"id": 2, "name": "Bob"}, "2-Bob"},
+	}
+	for _, tt := range tests {
+		if got := template.Render(tt.assigns, nil); got != tt.want {
+			t.Errorf("Render(%v) = %q, want %q", tt.assigns, got, tt.want)
+		}
+	}
+	if got := store.Len(); got != 2 {
+		t.Errorf("Len() = %d, want 2", got)
+	}
+}
+
+func TestCacheTagKeysDoNotCollide(t *testing.T) {
+	store := liquid.NewMemoryFragmentStore(liquid.MemoryFragmentStoreOptions{})
+	env := newTestEnvironment(nil, RegisterCacheTag)
+	env.SetFragmentStore(store)
+
+	// Parts containing the separator
+	if got := renderTestTemplate(t, env, `{% cache 'a:b', 'c' %}1{% endcache %}{% cache 'a', 'b:c' %}2{% endcache %}`, nil); got != "12" {
+		t.Errorf("Render() = %q, want %q", got, "12")
+	}
+
+	// Two templates with the same key at the same position
+	header := parseTestTemplate(t, env, `{% cache 'header' %}header{% endcache %}`)
+	header.SetName("header")
+	footer := parseTestTemplate(t, env, `{% cache 'header' %}footer{% endcache %}`)
+	footer.SetName("footer")
+	for i := 0; i < 2; i++ {
+		if got := header.Render(nil, nil); got != "header" {
+			t.Errorf("Render() = %q, want %q", got, "header")
+		}
+		if got := footer.Render(nil, nil); got != "footer" {
+			t.Errorf("Render() = %q, want %q", got, "footer")
+		}
+	}
+	if got := store.Len(); got != 4 {
+		t.Errorf("Len() = %d, want 4", got)
+	}
+}
+
+func TestCacheTagWithoutStore(t *testing.T) {
+	env := newTestEnvironment(nil, RegisterCacheTag)
+	template := parseTestTemplate(t, env, `{% cache 'k' %}{{ name }}{% endcache %}`)
+	for _, name := range []string{"Ann", "Bob"} {
+		if got := template.Render(map[string]interface{}{"name": name}, nil); got != name {
+			t.Errorf("Render() = %q, want %q", got, name)
+		}
+	}
+
+	store := liquid.NewMemoryFragmentStore(liquid.MemoryFragmentStoreOptions{})
+	options := &liquid.RenderOptions{Registers: map[string]interface{}{"fragment_store": store}}
+	template.Render(map[string]interface{}{"name": "Ann"}, options)
+	if got := template.Render(map[string]interface{}{"name": "Bob"}, options); got != "Ann" {
+		t.Errorf("Render() with a fragment_store register = %q, want Ann", got)
+	}
+}
+
+func TestCacheTagSkipsErrors(t *testing.T) {
+	store := liquid.NewMemoryFragmentStore(liquid.MemoryFragmentStoreOptions{})
+	env := newTestEnvironment(nil, RegisterCacheTag)
+	env.SetFragmentStore(store)
+	template := parseTestTemplate(t, env, `{% cache 'k' %}{% include 'missing' %}{% endcache %}`)
+	if got := template.Render(nil, nil); !strings.Contains(got, "Liquid error") {
+		t.Errorf("Render() = %q, want an error", got)
+	}
+	if store.Len() != 0 {
+		t.Error("A fragment rendered with errors should not be stored")
+	}
+
+	template = parseTestTemplate(t, env, `{% cache 'k', expires_in: 'soon' %}x{% endcache %}`)
+	if got := template.Render(nil, nil); !strings.Contains(got, "expires_in must be a positive number") {
+		t.Errorf("Render() = %q, want an expires_in error", got)
+	}
+}
+
+func TestCacheTagCountsTowardsRenderLength(t *testing.T) {
+	env := newTestEnvironment(nil, RegisterCacheTag)
+	env.SetFragmentStore(liquid.NewMemoryFragmentStore(liquid.MemoryFragmentStoreOptions{}))
+	template := parseTestTemplate(t, env, `{% cache 'k' %}{{ s }}{% endcache %}`)
+	template.Render(map[string]interface{}{"s": "0123456789"}, nil)
+
+	limit := 9
+	template.SetResourceLimits(liquid.NewResourceLimits(liquid.ResourceLimitsConfig{RenderLengthLimit: &limit}))
+	if got := template.Render(nil, nil); !strings.Contains(got, "Memory limits exceeded") {
+		t.Errorf("Render() = %q, want a memory limit error", got)
+	}
+}
+
+func TestCacheTagCompiledRoundTrip(t *testing.T) {
+	env := newTestEnvironment(nil, RegisterCacheTag)
+	env.SetFragmentStore(liquid.NewMemoryFragmentStore(liquid.MemoryFragmentStoreOptions{}))
+	options := &liquid.TemplateOptions{Environment: env}
+	template, err := liquid.ParseTemplate(`{% cache 'k', id, expires_in: 60 %}{{ id }}{% endcache %}`, options)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	data, err := template.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+	loaded, err := liquid.LoadCompiled(data, options)
+	if err != nil {
+		t.Fatalf("LoadCompiled() error = %v", err)
+	}
+	if got := loaded.Render(map[string]interface{}{"id": 7}, nil); got != "7" {
+		t.Errorf("Render() = %q", got)
+	}
+	if got := template.Render(map[string]interface{}{"id": 7}, nil); got != "7" {
+		t.Errorf("Render() of the source template = %q", got)
+	}
+}
@@ -63,7 +63,7 @@ func (c *CaptureTag) To() string {
 // RenderToOutputBuffer renders the capture tag.
 // Following Ruby implementation: always uses resource_limits.with_capture,
 // renders the block body, and assigns to context.scopes.last
-func (c *CaptureTag) RenderToOutputBuffer(context liquid.TagContext, output *string) {
+func (c *CaptureTag) RenderToOutputBuffer(context liquid.TagContext, output *liquid.OutputBuffer) {
 	ctx := context.Context().(*liquid.Context)
 	rl := context.ResourceLimits()
 
@@ -74,7 +74,7 @@ func (c *CaptureTag) RenderToOutputBuffer(context liquid.TagContext, output *str
 			captureOutput := c.Render(context)
 			// Increment write score with captured output (like Ruby: increment_write_score is called in block_body)
 			// This will increment assign_score by the byte difference since lastCaptureLength is set
-			rl.IncrementWriteScore(captureOutput)
+			rl.IncrementWriteScore(len(captureOutput))
 			// Set in the last scope (outermost scope, matching Ruby's context.scopes.last[@to] = capture_output)
 			ctx.SetLast(c.to, captureOutput)
 		})
@@ -92,3 +92,21 @@ func (c *CaptureTag) RenderToOutputBuffer(context liquid.TagContext, output *str
 func (c *CaptureTag) Blank() bool {
 	return true
 }
+
+// VariableScope reports the captured variable.
+func (c *CaptureTag) VariableScope() *liquid.VariableScope {
+	return &liquid.VariableScope{Assigns: []liquid.ScopedVariable{{Name: c.to}}}
+}
+
+// MarshalNode writes the tag for compiled templates.
+func (c *CaptureTag) MarshalNode(enc *liquid.NodeEncoder) {
+	enc.EncodeBlock(c.Block)
+	enc.WriteString(c.to)
+}
+
+// decodeCaptureTag rebuilds a CaptureTag written by MarshalNode.
+func decodeCaptureTag(dec *liquid.NodeDecoder) (interface{}, error) {
+	tag := &CaptureTag{Block: dec.DecodeBlock()}
+	tag.to = dec.ReadString()
+	return tag, dec.Err()
+}
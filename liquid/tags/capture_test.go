@@ -52,8 +52,8 @@ func TestCaptureTagRender(t *testing.T) {
 	}
 
 	ctx := liquid.NewContext()
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 
 	// Check that variable was captured
 	val := ctx.Get("var")
@@ -66,8 +66,8 @@ func TestCaptureTagRender(t *testing.T) {
 	}
 
 	// Capture tag should not output anything
-	if output != "" {
-		t.Errorf("Expected empty output, got %q", output)
+	if output.String() != "" {
+		t.Errorf("Expected empty output, got %q", output.String())
 	}
 }
 
@@ -131,8 +131,8 @@ func TestCaptureTagRenderToOutputBufferWithoutResourceLimits(t *testing.T) {
 	// Create context without resource limits
 	ctx := liquid.NewContext()
 	ctx.SetResourceLimits(nil) // Explicitly set to nil
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 
 	// Check that variable was captured even without resource limits
 	val := ctx.Get("var")
@@ -12,6 +12,8 @@ var (
 	caseSyntax = regexp.MustCompile(`^(` + liquid.QuotedFragment.String() + `)$`)
 	// whenSyntax matches: value [or value2] or value1, value2, value3
 	whenSyntax = regexp.MustCompile(`(` + liquid.QuotedFragment.String() + `)(?:(?:\s+or\s+|\s*,\s*)(` + liquid.QuotedFragment.String() + `.*))?`)
+	// whenOperatorSyntax matches a value preceded by a registered operator: startswith 'a'
+	whenOperatorSyntax = regexp.MustCompile(`(?s)^([a-zA-Z_]\w*)\s+(\S.*)$`)
 )
 
 // CaseBlock represents a case condition block (when or else).
@@ -213,6 +215,15 @@ func (c *CaseTag) recordWhenCondition(markup string) error {
 
 	remainingMarkup := strings.TrimSpace(markup)
 	for remainingMarkup != "" {
+		// Values compare with ==, or with a registered operator preceding them
+		operator := "=="
+		if matches := whenOperatorSyntax.FindStringSubmatch(remainingMarkup); len(matches) > 0 {
+			if env := c.Block.ParseContext().Environment(); env != nil && env.Operator(matches[1]) != nil {
+				operator = matches[1]
+				remainingMarkup = matches[2]
+			}
+		}
+
 		matches := whenSyntax.FindStringSubmatch(remainingMarkup)
 		if len(matches) == 0 {
 			return liquid.NewSyntaxError("invalid when condition syntax")
@@ -221,8 +232,8 @@ func (c *CaseTag) recordWhenCondition(markup string) error {
 		// Parse the value expression
 		valueExpr := c.Block.ParseContext().ParseExpression(matches[1])
 
-		// Create condition: left == value
-		condition := liquid.NewCondition(c.left, "==", valueExpr)
+		// Create condition: left == value, or left <operator> value
+		condition := liquid.NewCondition(c.left, operator, valueExpr)
 		condition.Attach(body)
 
 		// Wrap in caseCondition to implement CaseBlock
@@ -260,7 +271,7 @@ func (c *CaseTag) recordElseCondition(markup string) error {
 }
 
 // RenderToOutputBuffer renders the case tag.
-func (c *CaseTag) RenderToOutputBuffer(context liquid.TagContext, output *string) {
+func (c *CaseTag) RenderToOutputBuffer(context liquid.TagContext, output *liquid.OutputBuffer) {
 	ctx := context.Context().(*liquid.Context)
 	executeElseBlock := true
 
@@ -277,7 +288,7 @@ func (c *CaseTag) RenderToOutputBuffer(context liquid.TagContext, output *string
 		result, err := block.Evaluate(ctx)
 		if err != nil {
 			errorMsg := context.HandleError(err, nil)
-			*output += errorMsg
+			output.WriteString(errorMsg)
 			return
 		}
 
@@ -295,3 +306,54 @@ func (c *CaseTag) RenderToOutputBuffer(context liquid.TagContext, output *string
 func (c *CaseTag) Blank() bool {
 	return c.Block.Blank()
 }
+
+// ParseTreeChildren returns the case expression followed by each when value
+// and block.
+func (c *CaseTag) ParseTreeChildren() []interface{} {
+	children := []interface{}{c.left}
+	for _, block := range c.blocks {
+		if condition, ok := block.(*caseCondition); ok {
+			children = append(children, condition.Right())
+		}
+		children = append(children, block.Attachment())
+	}
+	return children
+}
+
+// MarshalNode writes the tag for compiled templates.
+func (c *CaseTag) MarshalNode(enc *liquid.NodeEncoder) {
+	enc.EncodeBlock(c.Block)
+	enc.Encode(c.left)
+	enc.WriteInt(len(c.blocks))
+	for _, block := range c.blocks {
+		switch b := block.(type) {
+		case *caseCondition:
+			enc.Encode(b.Condition)
+		case *caseElseCondition:
+			enc.Encode(b.ElseCondition)
+		default:
+			enc.Encode(block)
+		}
+	}
+}
+
+// decodeCaseTag rebuilds a CaseTag written by MarshalNode.
+func decodeCaseTag(dec *liquid.NodeDecoder) (interface{}, error) {
+	tag := &CaseTag{Block: dec.DecodeBlock()}
+	tag.left = dec.Decode()
+	count := dec.ReadInt()
+	tag.blocks = make([]CaseBlock, 0, count)
+	for i := 0; i < count && dec.Err() == nil; i++ {
+		switch b := dec.Decode().(type) {
+		case *liquid.Condition:
+			tag.blocks = append(tag.blocks, &caseCondition{Condition: b})
+		case *liquid.ElseCondition:
+			tag.blocks = append(tag.blocks, &caseElseCondition{ElseCondition: b})
+		case CaseBlock:
+			tag.blocks = append(tag.blocks, b)
+		default:
+			dec.Fail(liquid.NewCompiledTemplateError("corrupt compiled case tag"))
+		}
+	}
+	return tag, dec.Err()
+}
@@ -27,7 +27,7 @@ func NewCommentTag(tagName, markup string, parseContext liquid.ParseContextInter
 }
 
 // RenderToOutputBuffer renders the comment tag (does nothing - comments don't render).
-func (c *CommentTag) RenderToOutputBuffer(context liquid.TagContext, output *string) {
+func (c *CommentTag) RenderToOutputBuffer(context liquid.TagContext, output *liquid.OutputBuffer) {
 	// Comments don't render anything
 	_ = context // no-op to register coverage
 }
@@ -135,3 +135,13 @@ func (c *CommentTag) parseRawTagBody(tokenizer *liquid.Tokenizer) error {
 
 	return liquid.NewSyntaxError("tag raw was never closed")
 }
+
+// MarshalNode writes the tag for compiled templates.
+func (c *CommentTag) MarshalNode(enc *liquid.NodeEncoder) {
+	enc.EncodeBlock(c.Block)
+}
+
+// decodeCommentTag rebuilds a CommentTag written by MarshalNode.
+func decodeCommentTag(dec *liquid.NodeDecoder) (interface{}, error) {
+	return &CommentTag{Block: dec.DecodeBlock()}, dec.Err()
+}
@@ -21,11 +21,11 @@ func TestCommentTag(t *testing.T) {
 	}
 
 	ctx := liquid.NewContext()
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 
-	if output != "" {
-		t.Errorf("Expected empty output, got %q", output)
+	if output.String() != "" {
+		t.Errorf("Expected empty output, got %q", output.String())
 	}
 }
 
@@ -99,13 +99,13 @@ func TestCommentTagRenderToOutputBuffer(t *testing.T) {
 	}
 
 	ctx := liquid.NewContext()
-	var output string
+	output := liquid.NewOutputBuffer()
 	// Explicitly test RenderToOutputBuffer
-	tag.RenderToOutputBuffer(ctx, &output)
+	tag.RenderToOutputBuffer(ctx, output)
 
 	// Comment should render nothing
-	if output != "" {
-		t.Errorf("Expected empty output from RenderToOutputBuffer, got %q", output)
+	if output.String() != "" {
+		t.Errorf("Expected empty output from RenderToOutputBuffer, got %q", output.String())
 	}
 }
 
@@ -219,12 +219,12 @@ func TestCommentTagRenderToOutputBufferExplicit(t *testing.T) {
 	}
 
 	ctx := liquid.NewContext()
-	var output string
+	output := liquid.NewOutputBuffer()
 	// Explicitly test RenderToOutputBuffer (should do nothing)
-	tag.RenderToOutputBuffer(ctx, &output)
+	tag.RenderToOutputBuffer(ctx, output)
 
 	// Comment should render nothing
-	if output != "" {
-		t.Errorf("Expected empty output from RenderToOutputBuffer, got %q", output)
+	if output.String() != "" {
+		t.Errorf("Expected empty output from RenderToOutputBuffer, got %q", output.String())
 	}
 }
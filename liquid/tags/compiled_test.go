@@ -0,0 +1,75 @@
+package tags
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Notifuse/liquidgo/liquid"
+)
+
+func TestStandardTagsCompiledRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "_item.liquid"), []byte("[{{ item }}{{ sep }}]"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	env := liquid.NewEnvironment()
+	RegisterStandardTags(env)
+
+	sources := map[string]string{
+		"assign":    `{% assign x = name | upcase %}{{ x }}`,
+		"capture":   `{% capture c %}<{{ name }}>{% endcapture %}{{ c }}`,
+		"case":      `{% case n %}{% when 1, 2 %}low{% when 3 or 4 %}mid{% else %}high{% endcase %}`,
+		"comment":   `a{% comment %}hidden {{ name }}{% endcomment %}b{% # inline %}`,
+		"cycle":     `{% for i in list %}{% cycle "odd", "even" %}{% cycle "g": 1, 2, 3 %}{% endfor %}`,
+		"counters":  `{% increment c %}{% increment c %}{% decrement d %}{% decrement d %}`,
+		"doc":       `{% doc %}@param name{% enddoc %}x`,
+		"echo":      `{% echo name | append: "!" %}`,
+		"for":       `{% for i in list limit: 2 offset: 1 reversed %}{{ forloop.index }}{{ i }}{% else %}none{% endfor %}`,
+		"for else":  `{% for i in empty %}{{ i }}{% else %}none{% endfor %}`,
+		"for range": `{% for i in (1..n) %}{% if i == 2 %}{% continue %}{% endif %}{% if i > 3 %}{% break %}{% endif %}{{ i }}{% endfor %}`,
+		"if":        `{% if n > 5 and name != blank %}big{% elsif n == 3 or list contains 2 %}three{% else %}other{% endif %}`,
+		"ifchanged": `{% for i in dupes %}{% ifchanged %}{{ i }}{% endifchanged %}{% endfor %}`,
+		"include":   `{% include 'item' with name as item, sep: "," %}`,
+		"liquid":    "{% liquid assign y = n | plus: 1 %}{{ y }}",
+		"raw":       `{% raw %}{{ not parsed }}{% endraw %}`,
+		"render":    `{% render 'item' for list as item, sep: ";" %}`,
+		"tablerow":  `{% tablerow i in list cols: 2 %}{{ i }}{% endtablerow %}`,
+		"unless":    `{% unless n == 1 %}not one{% else %}one{% endunless %}`,
+	}
+	assigns := map[string]interface{}{
+		"name":  "ann",
+		"n":     3,
+		"list":  []interface{}{1, 2, 3},
+		"empty": []interface{}{},
+		"dupes": []interface{}{1, 1, 2, 2, 1},
+	}
+
+	for name, source := range sources {
+		t.Run(name, func(t *testing.T) {
+			options := &liquid.TemplateOptions{Environment: env}
+			renderOptions := &liquid.RenderOptions{
+				Registers: map[string]interface{}{"file_system": liquid.NewLocalFileSystem(tmpDir, "_%s.liquid")},
+			}
+
+			template, err := liquid.ParseTemplate(source, options)
+			if err != nil {
+				t.Fatalf("ParseTemplate() error = %v", err)
+			}
+			want := template.Render(assigns, renderOptions)
+
+			data, err := template.MarshalBinary()
+			if err != nil {
+				t.Fatalf("MarshalBinary() error = %v", err)
+			}
+			loaded, err := liquid.LoadCompiled(data, options)
+			if err != nil {
+				t.Fatalf("LoadCompiled() error = %v", err)
+			}
+			if got := loaded.Render(assigns, renderOptions); got != want {
+				t.Errorf("Render() = %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package tags
+
+import (
+	"regexp"
+
+	"github.com/Notifuse/liquidgo/liquid"
+)
+
+var (
+	componentSyntax = regexp.MustCompile(`^\s*(` + liquid.QuotedString.String() + `)\s*(?:,|$)`)
+	slotSyntax      = regexp.MustCompile(`^\s*(` + liquid.QuotedString.String() + `|[\w\-]+)\s*$`)
+)
+
+// componentSlotsRegister is the register holding the named slots of the
+// component whose body is rendering.
+const componentSlotsRegister = "component_slots"
+
+// ComponentTag renders a partial with the content of its body:
+// {% component 'card', title: product.title %}...{% endcomponent %}.
+// Like render, the partial only sees the attributes passed to it. The body is
+// rendered in the caller's context and exposed to the partial as "slot", and
+// the {% slot %} blocks of the body as "slots", e.g. slots.footer.
+type ComponentTag struct {
+	*liquid.Block
+	templateNameExpr interface{}            // Expression
+	attributes       map[string]interface{} // Attribute expressions
+}
+
+// NewComponentTag creates a new ComponentTag.
+func NewComponentTag(tagName, markup string, parseContext liquid.ParseContextInterface) (*ComponentTag, error) {
+	matches := componentSyntax.FindStringSubmatch(markup)
+	if len(matches) == 0 {
+		return nil, liquid.NewSyntaxError("Syntax Error in 'component' - Valid syntax: component '[template]', attr: value")
+	}
+	return &ComponentTag{
+		Block:            liquid.NewBlock(tagName, markup, parseContext),
+		templateNameExpr: parseContext.ParseExpression(matches[1]),
+		attributes:       parseTagAttributes(markup, parseContext),
+	}, nil
+}
+
+// TemplateNameExpr returns the template name expression.
+func (c *ComponentTag) TemplateNameExpr() interface{} {
+	return c.templateNameExpr
+}
+
+// Attributes returns the attributes map.
+func (c *ComponentTag) Attributes() map[string]interface{} {
+	return c.attributes
+}
+
+// Blank returns false: the partial may render content even if the body is blank.
+func (c *ComponentTag) Blank() bool {
+	return false
+}
+
+// RenderToOutputBuffer renders the body, then the partial with the slots.
+func (c *ComponentTag) RenderToOutputBuffer(context liquid.TagContext, output *liquid.OutputBuffer) {
+	ctx, ok := context.(*liquid.Context)
+	if !ok {
+		output.WriteString(context.HandleError(liquid.NewInternalError("context is not a liquid.Context"), c.LineNumber()))
+		return
+	}
+
+	templateName, ok := context.Evaluate(c.templateNameExpr).(string)
+	if !ok {
+		output.WriteString(context.HandleError(liquid.NewArgumentError("component tag requires a string template name"), c.LineNumber()))
+		return
+	}
+
+	slot, slots := c.renderSlots(ctx)
+
+	partialInterface, err := liquid.LoadPartial(templateName, context, c.ParseContext())
+	if err != nil {
+		output.WriteString(context.HandleError(err, c.LineNumber()))
+		return
+	}
+	partial, ok := partialInterface.(*liquid.Template)
+	if !ok {
+		output.WriteString(context.HandleError(liquid.NewFileSystemError("partial is not a template"), c.LineNumber()))
+		return
+	}
+	if name := partial.Name(); name != "" {
+		templateName = name
+	}
+
+	inner := ctx.NewIsolatedSubcontext()
+	inner.SetTemplateName(templateName)
+	inner.SetPartial(true)
+	for key, valueExpr := range c.attributes {
+		inner.Set(key, context.Evaluate(valueExpr))
+	}
+	inner.Set("slot", slot)
+	inner.Set("slots", slots)
+
+	partial.RenderToOutputBuffer(inner, output)
+}
+
+// renderSlots renders the body in ctx and returns its output, without the
+// slot blocks, and the output of each slot block.
+func (c *ComponentTag) renderSlots(ctx *liquid.Context) (string, map[string]interface{}) {
+	slots := make(map[string]interface{})
+	body := c.Body()
+	if body == nil {
+		return "", slots
+	}
+
+	registers := ctx.Registers()
+	previous := registers.Get(componentSlotsRegister)
+	registers.Set(componentSlotsRegister, slots)
+
+	slotOutput := liquid.NewOutputBuffer()
+	body.RenderToOutputBuffer(ctx, slotOutput)
+
+	if previous != nil {
+		registers.Set(componentSlotsRegister, previous)
+	} else {
+		registers.Delete(componentSlotsRegister)
+	}
+	return slotOutput.String(), slots
+}
+
+// ParseTreeChildren returns the template name and attribute expressions, and
+// the body.
+func (c *ComponentTag) ParseTreeChildren() []interface{} {
+	children := []interface{}{c.templateNameExpr}
+	children = append(children, sortedAttributeValues(c.attributes)...)
+	return append(children, c.Nodelist()...)
+}
+
+// VariableScope reports the rendered partial, which only sees the attributes
+// and the slots.
+func (c *ComponentTag) VariableScope() *liquid.VariableScope {
+	partial := partialReference(c.templateNameExpr, nil, "", c.attributes, false, true)
+	partial.Variables = append(partial.Variables, liquid.ScopedVariable{Name: "slot"}, liquid.ScopedVariable{Name: "slots"})
+	return &liquid.VariableScope{Partial: partial}
+}
+
+// MarshalNode writes the tag for compiled templates.
+func (c *ComponentTag) MarshalNode(enc *liquid.NodeEncoder) {
+	enc.EncodeBlock(c.Block)
+	enc.Encode(c.templateNameExpr)
+	enc.Encode(c.attributes)
+}
+
+// decodeComponentTag rebuilds a ComponentTag written by MarshalNode.
+func decodeComponentTag(dec *liquid.NodeDecoder) (interface{}, error) {
+	tag := &ComponentTag{Block: dec.DecodeBlock()}
+	tag.templateNameExpr = dec.Decode()
+	tag.attributes = dec.DecodeMap()
+	return tag, dec.Err()
+}
+
+// SlotTag fills a named slot of the enclosing component:
+// {% slot 'footer' %}...{% endslot %}. It renders nothing in place.
+type SlotTag struct {
+	*liquid.Block
+	name string
+}
+
+// NewSlotTag creates a new SlotTag.
+func NewSlotTag(tagName, markup string, parseContext liquid.ParseContextInterface) (*SlotTag, error) {
+	matches := slotSyntax.FindStringSubmatch(markup)
+	if len(matches) == 0 {
+		return nil, liquid.NewSyntaxError("Syntax Error in 'slot' - Valid syntax: slot '[name]'")
+	}
+	name := matches[1]
+	if unquoted, ok := parseContext.ParseExpression(name).(string); ok {
+		name = unquoted
+	}
+	return &SlotTag{
+		Block: liquid.NewBlock(tagName, markup, parseContext),
+		name:  name,
+	}, nil
+}
+
+// Name returns the slot name.
+func (s *SlotTag) Name() string {
+	return s.name
+}
+
+// Blank returns true since the content is rendered by the component.
+func (s *SlotTag) Blank() bool {
+	return true
+}
+
+// RenderToOutputBuffer renders the body into the slots of the enclosing component.
+func (s *SlotTag) RenderToOutputBuffer(context liquid.TagContext, output *liquid.OutputBuffer) {
+	ctx, ok := context.(*liquid.Context)
+	if !ok {
+		output.WriteString(context.HandleError(liquid.NewInternalError("context is not a liquid.Context"), s.LineNumber()))
+		return
+	}
+	slots, ok := ctx.Registers().Get(componentSlotsRegister).(map[string]interface{})
+	if !ok {
+		output.WriteString(context.HandleError(liquid.NewSyntaxError("slot tag must be used inside a component"), s.LineNumber()))
+		return
+	}
+
+	slotOutput := liquid.NewOutputBuffer()
+	if body := s.Body(); body != nil {
+		body.RenderToOutputBuffer(ctx, slotOutput)
+	}
+	slots[s.name] = slotOutput.String()
+}
+
+// MarshalNode writes the tag for compiled templates.
+func (s *SlotTag) MarshalNode(enc *liquid.NodeEncoder) {
+	enc.EncodeBlock(s.Block)
+	enc.WriteString(s.name)
+}
+
+// decodeSlotTag rebuilds a SlotTag written by MarshalNode.
+func decodeSlotTag(dec *liquid.NodeDecoder) (interface{}, error) {
+	tag := &SlotTag{Block: dec.DecodeBlock()}
+	tag.name = dec.ReadString()
+	return tag, dec.Err()
+}
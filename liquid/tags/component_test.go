@@ -0,0 +1,99 @@
+package tags
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Notifuse/liquidgo/liquid"
+)
+
+func TestNewComponentTag(t *testing.T) {
+	pc := liquid.NewParseContext(liquid.ParseContextOptions{})
+	tag, err := NewComponentTag("component", "'card', title: product.title, size: 2", pc)
+	if err != nil {
+		t.Fatalf("NewComponentTag() error = %v", err)
+	}
+	if tag.TemplateNameExpr() != "card" {
+		t.Errorf("TemplateNameExpr() = %v, want card", tag.TemplateNameExpr())
+	}
+	if attributes := tag.Attributes(); len(attributes) != 2 || attributes["size"] != 2 {
+		t.Errorf("Attributes() = %v", attributes)
+	}
+
+	for _, markup := range []string{"", "card", "'card' title: x"} {
+		if _, err := NewComponentTag("component", markup, pc); err == nil {
+			t.Errorf("NewComponentTag(%q) expected error", markup)
+		}
+	}
+}
+
+func TestComponentTagSlots(t *testing.T) {
+	env := newTestEnvironment(map[string]string{
+		"card": `<div class="card"><h2>{{ title }}</h2>{{ slot }}{% if slots.footer %}<footer>{{ slots.footer }}</footer>{% endif %}{{ secret }}</div>`,
+	}, RegisterComponentTags)
+
+	source := `{% component 'card', title: product.title %}<p>{{ product.price }}</p>{% slot 'footer' %}{{ secret }}!{% endslot %}{% endcomponent %}`
+	assigns := map[string]interface{}{
+		"product": map[string]interface{}{"title": "Tea", "price": 5},
+		"secret":  "s",
+	}
+	got := renderTestTemplate(t, env, source, assigns)
+	if want := `<div class="card"><h2>Tea</h2><p>5</p><footer>s!</footer></div>`; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+
+	got = renderTestTemplate(t, env, `{% component 'card', title: "Empty" %}{% endcomponent %}`, nil)
+	if want := `<div class="card"><h2>Empty</h2></div>`; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestComponentTagNested(t *testing.T) {
+	env := newTestEnvironment(map[string]string{
+		"box":   `[{{ slot }}|{{ slots.label }}]`,
+		"badge": `({{ slot }})`,
+	}, RegisterComponentTags)
+
+	source := `{% component 'box' %}{% slot label %}outer{% endslot %}{% component 'badge' %}inner{% slot label %}lost{% endslot %}{% endcomponent %}{% endcomponent %}`
+	if got, want := renderTestTemplate(t, env, source, nil), "[(inner)|outer]"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestComponentTagErrors(t *testing.T) {
+	env := newTestEnvironment(nil, RegisterComponentTags)
+	tests := map[string]string{
+		`{% slot 'footer' %}x{% endslot %}`:           "slot tag must be used inside a component",
+		`{% component 'missing' %}{% endcomponent %}`: "Liquid error",
+	}
+	for source, want := range tests {
+		got := renderTestTemplate(t, env, source, nil)
+		if !strings.Contains(got, want) {
+			t.Errorf("Render(%q) = %q, want it to contain %q", source, got, want)
+		}
+	}
+
+	if _, err := liquid.ParseTemplate(`{% component 'card' %}{% slot a b %}{% endslot %}{% endcomponent %}`, &liquid.TemplateOptions{Environment: env}); err == nil {
+		t.Error("Expected a syntax error for an invalid slot name")
+	}
+}
+
+func TestComponentTagsCompiledRoundTrip(t *testing.T) {
+	env := newTestEnvironment(map[string]string{"card": `<{{ title }}:{{ slot }}:{{ slots.footer }}>`}, RegisterComponentTags)
+	options := &liquid.TemplateOptions{Environment: env}
+	template, err := liquid.ParseTemplate(`{% component 'card', title: name %}body{% slot 'footer' %}foot{% endslot %}{% endcomponent %}`, options)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	data, err := template.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+	loaded, err := liquid.LoadCompiled(data, options)
+	if err != nil {
+		t.Fatalf("LoadCompiled() error = %v", err)
+	}
+	if got := loaded.Render(map[string]interface{}{"name": "Ann"}, nil); got != "<Ann:body:foot>" {
+		t.Errorf("Render() = %q", got)
+	}
+}
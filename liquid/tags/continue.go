@@ -17,7 +17,17 @@ func NewContinueTag(tagName, markup string, parseContext liquid.ParseContextInte
 }
 
 // RenderToOutputBuffer renders the continue tag by pushing a continue interrupt.
-func (c *ContinueTag) RenderToOutputBuffer(context liquid.TagContext, output *string) {
+func (c *ContinueTag) RenderToOutputBuffer(context liquid.TagContext, output *liquid.OutputBuffer) {
 	interrupt := liquid.NewContinueInterrupt()
 	context.PushInterrupt(interrupt)
 }
+
+// MarshalNode writes the tag for compiled templates.
+func (c *ContinueTag) MarshalNode(enc *liquid.NodeEncoder) {
+	enc.EncodeTag(c.Tag)
+}
+
+// decodeContinueTag rebuilds a ContinueTag written by MarshalNode.
+func decodeContinueTag(dec *liquid.NodeDecoder) (interface{}, error) {
+	return &ContinueTag{Tag: dec.DecodeTag()}, dec.Err()
+}
@@ -14,8 +14,8 @@ func TestContinueTag(t *testing.T) {
 	}
 
 	ctx := liquid.NewContext()
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 
 	if !ctx.Interrupt() {
 		t.Error("Expected interrupt to be set")
@@ -48,7 +48,7 @@ func (c *CycleTag) Named() bool {
 }
 
 // RenderToOutputBuffer renders the cycle tag.
-func (c *CycleTag) RenderToOutputBuffer(context liquid.TagContext, output *string) {
+func (c *CycleTag) RenderToOutputBuffer(context liquid.TagContext, output *liquid.OutputBuffer) {
 	ctx := context.Context().(*liquid.Context)
 	registers := ctx.Registers()
 
@@ -108,7 +108,7 @@ func (c *CycleTag) RenderToOutputBuffer(context liquid.TagContext, output *strin
 	} else {
 		valStr = liquid.ToS(val, nil)
 	}
-	*output += valStr
+	output.WriteString(valStr)
 
 	// Increment iteration
 	iter++
@@ -177,3 +177,25 @@ func (c *CycleTag) variablesFromString(markup string, parseContext liquid.ParseC
 
 	return variables
 }
+
+// ParseTreeChildren returns the cycled values.
+func (c *CycleTag) ParseTreeChildren() []interface{} {
+	return c.variables
+}
+
+// MarshalNode writes the tag for compiled templates.
+func (c *CycleTag) MarshalNode(enc *liquid.NodeEncoder) {
+	enc.EncodeTag(c.Tag)
+	enc.Encode(c.variables)
+	enc.Encode(c.name)
+	enc.WriteBool(c.isNamed)
+}
+
+// decodeCycleTag rebuilds a CycleTag written by MarshalNode.
+func decodeCycleTag(dec *liquid.NodeDecoder) (interface{}, error) {
+	tag := &CycleTag{Tag: dec.DecodeTag()}
+	tag.variables = dec.DecodeArray()
+	tag.name = dec.Decode()
+	tag.isNamed = dec.ReadBool()
+	return tag, dec.Err()
+}
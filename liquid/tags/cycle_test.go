@@ -22,20 +22,20 @@ func TestCycleTag(t *testing.T) {
 	}
 
 	ctx := liquid.NewContext()
-	var output string
+	output := liquid.NewOutputBuffer()
 
 	// First call should output first value
-	tag.RenderToOutputBuffer(ctx, &output)
+	tag.RenderToOutputBuffer(ctx, output)
 	// Output should be "one" (the first literal string value)
-	if output != "one" {
-		t.Errorf("Expected \"one\", got %q", output)
+	if output.String() != "one" {
+		t.Errorf("Expected \"one\", got %q", output.String())
 	}
 
 	// Second call should output second value
-	output = ""
-	tag.RenderToOutputBuffer(ctx, &output)
-	if output != "two" {
-		t.Errorf("Expected \"two\", got %q", output)
+	output.Reset()
+	tag.RenderToOutputBuffer(ctx, output)
+	if output.String() != "two" {
+		t.Errorf("Expected \"two\", got %q", output.String())
 	}
 }
 
@@ -115,23 +115,23 @@ func TestCycleTagRenderToOutputBufferWithArray(t *testing.T) {
 	}
 
 	ctx := liquid.NewContext()
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
-	if output != "one" {
-		t.Errorf("Expected 'one', got %q", output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
+	if output.String() != "one" {
+		t.Errorf("Expected 'one', got %q", output.String())
 	}
 
 	// Test cycle wraps around
-	output = ""
-	tag.RenderToOutputBuffer(ctx, &output)
-	if output != "two" {
-		t.Errorf("Expected 'two', got %q", output)
+	output.Reset()
+	tag.RenderToOutputBuffer(ctx, output)
+	if output.String() != "two" {
+		t.Errorf("Expected 'two', got %q", output.String())
 	}
 
-	output = ""
-	tag.RenderToOutputBuffer(ctx, &output)
-	if output != "one" {
-		t.Errorf("Expected 'one' (wrapped), got %q", output)
+	output.Reset()
+	tag.RenderToOutputBuffer(ctx, output)
+	if output.String() != "one" {
+		t.Errorf("Expected 'one' (wrapped), got %q", output.String())
 	}
 }
 
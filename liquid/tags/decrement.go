@@ -26,7 +26,7 @@ func (d *DecrementTag) VariableName() string {
 }
 
 // RenderToOutputBuffer renders the decrement tag.
-func (d *DecrementTag) RenderToOutputBuffer(context liquid.TagContext, output *string) {
+func (d *DecrementTag) RenderToOutputBuffer(context liquid.TagContext, output *liquid.OutputBuffer) {
 	// Get counter environment (first environment)
 	ctx := context.Context().(*liquid.Context)
 	environments := ctx.Scopes()
@@ -54,5 +54,23 @@ func (d *DecrementTag) RenderToOutputBuffer(context liquid.TagContext, output *s
 	// Decrement first, then output
 	intValue--
 	counterEnv[d.variableName] = intValue
-	*output += liquid.ToS(intValue, nil)
+	output.WriteString(liquid.ToS(intValue, nil))
+}
+
+// VariableScope reports the counter variable.
+func (d *DecrementTag) VariableScope() *liquid.VariableScope {
+	return &liquid.VariableScope{Assigns: []liquid.ScopedVariable{{Name: d.variableName}}}
+}
+
+// MarshalNode writes the tag for compiled templates.
+func (d *DecrementTag) MarshalNode(enc *liquid.NodeEncoder) {
+	enc.EncodeTag(d.Tag)
+	enc.WriteString(d.variableName)
+}
+
+// decodeDecrementTag rebuilds a DecrementTag written by MarshalNode.
+func decodeDecrementTag(dec *liquid.NodeDecoder) (interface{}, error) {
+	tag := &DecrementTag{Tag: dec.DecodeTag()}
+	tag.variableName = dec.ReadString()
+	return tag, dec.Err()
 }
@@ -39,7 +39,7 @@ func NewDocTag(tagName, markup string, parseContext liquid.ParseContextInterface
 }
 
 // RenderToOutputBuffer renders the doc tag (does nothing - docs don't render).
-func (d *DocTag) RenderToOutputBuffer(context liquid.TagContext, output *string) {
+func (d *DocTag) RenderToOutputBuffer(context liquid.TagContext, output *liquid.OutputBuffer) {
 	// Docs don't render anything
 	_ = context // no-op to register coverage
 }
@@ -114,3 +114,16 @@ func (d *DocTag) Parse(tokenizer *liquid.Tokenizer) error {
 func (d *DocTag) RaiseTagNeverClosed() error {
 	return liquid.NewSyntaxError("'" + d.BlockName() + "' tag was never closed")
 }
+
+// MarshalNode writes the tag for compiled templates.
+func (d *DocTag) MarshalNode(enc *liquid.NodeEncoder) {
+	enc.EncodeBlock(d.Block)
+	enc.WriteString(d.body)
+}
+
+// decodeDocTag rebuilds a DocTag written by MarshalNode.
+func decodeDocTag(dec *liquid.NodeDecoder) (interface{}, error) {
+	tag := &DocTag{Block: dec.DecodeBlock()}
+	tag.body = dec.ReadString()
+	return tag, dec.Err()
+}
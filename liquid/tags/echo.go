@@ -19,8 +19,26 @@ func NewEchoTag(tagName, markup string, parseContext liquid.ParseContextInterfac
 }
 
 // RenderToOutputBuffer renders the echo tag by rendering the variable.
-func (e *EchoTag) RenderToOutputBuffer(context liquid.TagContext, output *string) {
+func (e *EchoTag) RenderToOutputBuffer(context liquid.TagContext, output *liquid.OutputBuffer) {
 	// Render the variable and append to output
 	val := e.variable.Render(context)
-	*output += liquid.ToS(val, nil)
+	output.WriteString(liquid.ToS(val, nil))
+}
+
+// ParseTreeChildren returns the echoed variable.
+func (e *EchoTag) ParseTreeChildren() []interface{} {
+	return []interface{}{e.variable}
+}
+
+// MarshalNode writes the tag for compiled templates.
+func (e *EchoTag) MarshalNode(enc *liquid.NodeEncoder) {
+	enc.EncodeTag(e.Tag)
+	enc.Encode(e.variable)
+}
+
+// decodeEchoTag rebuilds an EchoTag written by MarshalNode.
+func decodeEchoTag(dec *liquid.NodeDecoder) (interface{}, error) {
+	tag := &EchoTag{Tag: dec.DecodeTag()}
+	tag.variable = dec.DecodeVariable()
+	return tag, dec.Err()
 }
@@ -15,10 +15,10 @@ func TestEchoTag(t *testing.T) {
 
 	ctx := liquid.NewContext()
 	ctx.Set("test", "value")
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 
-	if output != "value" {
-		t.Errorf("Expected 'value', got %q", output)
+	if output.String() != "value" {
+		t.Errorf("Expected 'value', got %q", output.String())
 	}
 }
@@ -8,20 +8,24 @@ import (
 )
 
 var (
-	// forSyntax matches: variable in collection [reversed]
+	// forSyntax matches: variable[, value] in collection [reversed]
 	// VariableSegment is a single character pattern, so we use + after it
 	// QuotedFragment already has +, so we don't add another
-	forSyntax = regexp.MustCompile(`^(` + liquid.VariableSegment.String() + `+)\s+in\s+(` + liquid.QuotedFragment.String() + `)\s*(reversed)?`)
+	forSyntax = regexp.MustCompile(`^(` + liquid.VariableSegment.String() + `+)(?:\s*,\s*(` + liquid.VariableSegment.String() + `+))?\s+in\s+(` + liquid.QuotedFragment.String() + `)\s*(reversed)?`)
+	// forInsertionOrder matches the insertion_order flag after the collection
+	forInsertionOrder = regexp.MustCompile(`(?:^|\s)insertion_order(?:\s|$)`)
 )
 
 // ForTag represents a for loop tag.
 type ForTag struct {
 	*liquid.Block
 	variableName   string
+	valueName      string      // Value variable of for key, value in hash, or ""
 	collectionName interface{} // Expression
 	limit          interface{} // Expression or nil
 	from           interface{} // Expression, :continue, or nil
 	reversed       bool
+	insertionOrder bool   // Iterate hashes in insertion order rather than by key
 	name           string // "#{variable_name}-#{collection_name}"
 	forBlock       *liquid.BlockBody
 	elseBlock      *liquid.BlockBody
@@ -57,14 +61,21 @@ func (f *ForTag) parseMarkup(markup string, parseContext liquid.ParseContextInte
 	}
 
 	f.variableName = matches[1]
-	collectionNameStr := matches[2]
-	if len(matches) > 3 && matches[3] == "reversed" {
+	f.valueName = matches[2]
+	collectionNameStr := matches[3]
+	if matches[4] == "reversed" {
 		f.reversed = true
 	}
+	flags := liquid.TagAttributes.ReplaceAllString(markup[len(matches[0]):], "")
+	f.insertionOrder = forInsertionOrder.MatchString(flags)
 
 	// Parse collection name as expression
 	f.collectionName = parseContext.ParseExpression(collectionNameStr)
-	f.name = f.variableName + "-" + collectionNameStr
+	if f.valueName != "" {
+		f.name = f.variableName + "," + f.valueName + "-" + collectionNameStr
+	} else {
+		f.name = f.variableName + "-" + collectionNameStr
+	}
 
 	// Parse attributes (limit, offset)
 	attributeMatches := liquid.TagAttributes.FindAllStringSubmatch(markup, -1)
@@ -190,9 +201,19 @@ func (f *ForTag) parseBody(tokenizer *liquid.Tokenizer, body *liquid.BlockBody)
 }
 
 // RenderToOutputBuffer renders the for tag.
-func (f *ForTag) RenderToOutputBuffer(context liquid.TagContext, output *string) {
+func (f *ForTag) RenderToOutputBuffer(context liquid.TagContext, output *liquid.OutputBuffer) {
 	segment := f.collectionSegment(context)
 
+	// Key and value loops need a hash, or an array of [key, value] pairs
+	if f.valueName != "" {
+		for _, item := range segment {
+			if pair, ok := item.([]interface{}); !ok || len(pair) != 2 {
+				output.WriteString(context.HandleError(liquid.NewArgumentError("for loop with key and value requires a hash or [key, value] pairs"), f.LineNumber()))
+				return
+			}
+		}
+	}
+
 	if len(segment) == 0 {
 		f.renderElse(context, output)
 	} else {
@@ -246,6 +267,11 @@ func (f *ForTag) collectionSegment(context liquid.TagContext) []interface{} {
 		return []interface{}{}
 	}
 
+	// Hashes iterate as [key, value] pairs
+	if entries, ok := liquid.HashEntries(collection, f.insertionOrder); ok {
+		collection = entries
+	}
+
 	// Convert Range to array if needed
 	if r, ok := collection.(*liquid.Range); ok {
 		// Convert range to array
@@ -286,7 +312,7 @@ func (f *ForTag) collectionSegment(context liquid.TagContext) []interface{} {
 }
 
 // renderSegment renders the segment.
-func (f *ForTag) renderSegment(context liquid.TagContext, output *string, segment []interface{}) {
+func (f *ForTag) renderSegment(context liquid.TagContext, output *liquid.OutputBuffer, segment []interface{}) {
 	registers := context.Registers()
 
 	// Get or create for_stack
@@ -329,8 +355,17 @@ func (f *ForTag) renderSegment(context liquid.TagContext, output *string, segmen
 		// Iterate over segment
 	forLoop:
 		for _, item := range segment {
-			// Set variable
-			ctx.Set(f.variableName, item)
+			// Stop if the render has been canceled
+			ctx.CheckCanceled(f.LineNumber())
+
+			// Set variable, or key and value
+			if f.valueName != "" {
+				pair := item.([]interface{})
+				ctx.Set(f.variableName, pair[0])
+				ctx.Set(f.valueName, pair[1])
+			} else {
+				ctx.Set(f.variableName, item)
+			}
 
 			// Render for block
 			f.forBlock.RenderToOutputBuffer(context, output)
@@ -357,7 +392,7 @@ func (f *ForTag) renderSegment(context liquid.TagContext, output *string, segmen
 }
 
 // renderElse renders the else block if collection is empty.
-func (f *ForTag) renderElse(context liquid.TagContext, output *string) {
+func (f *ForTag) renderElse(context liquid.TagContext, output *liquid.OutputBuffer) {
 	if f.elseBlock != nil {
 		f.elseBlock.RenderToOutputBuffer(context, output)
 	}
@@ -368,6 +403,11 @@ func (f *ForTag) VariableName() string {
 	return f.variableName
 }
 
+// ValueName returns the value variable name of for key, value loops, or "".
+func (f *ForTag) ValueName() string {
+	return f.valueName
+}
+
 // CollectionName returns the collection name expression.
 func (f *ForTag) CollectionName() interface{} {
 	return f.collectionName
@@ -382,3 +422,60 @@ func (f *ForTag) Limit() interface{} {
 func (f *ForTag) From() interface{} {
 	return f.from
 }
+
+// ParseTreeChildren returns the loop expressions followed by the for and else blocks.
+func (f *ForTag) ParseTreeChildren() []interface{} {
+	return []interface{}{f.collectionName, f.limit, f.from, f.forBlock, f.elseBlock}
+}
+
+// VariableScope reports the loop variables and forloop, visible in the for block only.
+func (f *ForTag) VariableScope() *liquid.VariableScope {
+	if f.valueName != "" {
+		return &liquid.VariableScope{
+			Locals: []liquid.ScopedVariable{
+				{Name: f.variableName},
+				{Name: f.valueName, Source: f.collectionName, Iterates: true},
+				{Name: "forloop"},
+			},
+			Body: []interface{}{f.forBlock},
+		}
+	}
+	return &liquid.VariableScope{
+		Locals: []liquid.ScopedVariable{
+			{Name: f.variableName, Source: f.collectionName, Iterates: true},
+			{Name: "forloop"},
+		},
+		Body: []interface{}{f.forBlock},
+	}
+}
+
+// MarshalNode writes the tag for compiled templates.
+func (f *ForTag) MarshalNode(enc *liquid.NodeEncoder) {
+	enc.EncodeBlock(f.Block)
+	enc.WriteString(f.variableName)
+	enc.WriteString(f.valueName)
+	enc.Encode(f.collectionName)
+	enc.Encode(f.limit)
+	enc.Encode(f.from)
+	enc.WriteBool(f.reversed)
+	enc.WriteBool(f.insertionOrder)
+	enc.WriteString(f.name)
+	enc.Encode(f.forBlock)
+	enc.Encode(f.elseBlock)
+}
+
+// decodeForTag rebuilds a ForTag written by MarshalNode.
+func decodeForTag(dec *liquid.NodeDecoder) (interface{}, error) {
+	tag := &ForTag{Block: dec.DecodeBlock()}
+	tag.variableName = dec.ReadString()
+	tag.valueName = dec.ReadString()
+	tag.collectionName = dec.Decode()
+	tag.limit = dec.Decode()
+	tag.from = dec.Decode()
+	tag.reversed = dec.ReadBool()
+	tag.insertionOrder = dec.ReadBool()
+	tag.name = dec.ReadString()
+	tag.forBlock = dec.DecodeBlockBody()
+	tag.elseBlock = dec.DecodeBlockBody()
+	return tag, dec.Err()
+}
@@ -1,6 +1,7 @@
 package tags
 
 import (
+	"context"
 	"testing"
 
 	"github.com/Notifuse/liquidgo/liquid"
@@ -37,11 +38,11 @@ func TestForTagSimpleLoop(t *testing.T) {
 
 	ctx := liquid.NewContext()
 	ctx.Set("array", []interface{}{1, 2, 3})
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 
-	if output != "1 2 3 " {
-		t.Errorf("Expected output '1 2 3 ', got %q", output)
+	if output.String() != "1 2 3 " {
+		t.Errorf("Expected output '1 2 3 ', got %q", output.String())
 	}
 }
 
@@ -61,11 +62,11 @@ func TestForTagEmptyCollection(t *testing.T) {
 
 	ctx := liquid.NewContext()
 	ctx.Set("array", []interface{}{})
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 
-	if output != "empty" {
-		t.Errorf("Expected output 'empty', got %q", output)
+	if output.String() != "empty" {
+		t.Errorf("Expected output 'empty', got %q", output.String())
 	}
 }
 
@@ -85,11 +86,11 @@ func TestForTagReversed(t *testing.T) {
 
 	ctx := liquid.NewContext()
 	ctx.Set("array", []interface{}{1, 2, 3})
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 
-	if output != "3 2 1 " {
-		t.Errorf("Expected output '3 2 1 ', got %q", output)
+	if output.String() != "3 2 1 " {
+		t.Errorf("Expected output '3 2 1 ', got %q", output.String())
 	}
 }
 
@@ -109,11 +110,11 @@ func TestForTagWithLimit(t *testing.T) {
 
 	ctx := liquid.NewContext()
 	ctx.Set("array", []interface{}{1, 2, 3, 4, 5})
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 
-	if output != "1 2 " {
-		t.Errorf("Expected output '1 2 ', got %q", output)
+	if output.String() != "1 2 " {
+		t.Errorf("Expected output '1 2 ', got %q", output.String())
 	}
 }
 
@@ -133,11 +134,11 @@ func TestForTagWithOffset(t *testing.T) {
 
 	ctx := liquid.NewContext()
 	ctx.Set("array", []interface{}{1, 2, 3, 4, 5})
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 
-	if output != "3 4 " {
-		t.Errorf("Expected output '3 4 ', got %q", output)
+	if output.String() != "3 4 " {
+		t.Errorf("Expected output '3 4 ', got %q", output.String())
 	}
 }
 
@@ -287,14 +288,14 @@ func TestForTagRenderSegment(t *testing.T) {
 
 	ctx := liquid.NewContext()
 	ctx.Set("array", []interface{}{1, 2, 3})
-	var output string
+	output := liquid.NewOutputBuffer()
 
 	// Test renderSegment
 	segment := []interface{}{1, 2, 3}
-	tag.renderSegment(ctx, &output, segment)
+	tag.renderSegment(ctx, output, segment)
 	expected := "1 2 3 "
-	if output != expected {
-		t.Errorf("Expected %q, got %q", expected, output)
+	if output.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, output.String())
 	}
 }
 
@@ -631,13 +632,13 @@ func TestForTagRenderSegmentInvalidForStack(t *testing.T) {
 	registers := ctx.Registers()
 	registers.Set("for_stack", "not_a_slice")
 
-	var output string
+	output := liquid.NewOutputBuffer()
 	segment := []interface{}{1, 2, 3}
-	tag.renderSegment(ctx, &output, segment)
+	tag.renderSegment(ctx, output, segment)
 	// Should handle gracefully and create new stack
 	expected := "1 2 3 "
-	if output != expected {
-		t.Errorf("Expected %q, got %q", expected, output)
+	if output.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, output.String())
 	}
 }
 
@@ -665,13 +666,13 @@ func TestForTagRenderSegmentWithParentLoop(t *testing.T) {
 	forStack := []*liquid.ForloopDrop{parentLoop}
 	registers.Set("for_stack", forStack)
 
-	var output string
+	output := liquid.NewOutputBuffer()
 	segment := []interface{}{1, 2, 3}
-	tag.renderSegment(ctx, &output, segment)
+	tag.renderSegment(ctx, output, segment)
 	// Should use parent loop
 	expected := "1 2 3 "
-	if output != expected {
-		t.Errorf("Expected %q, got %q", expected, output)
+	if output.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, output.String())
 	}
 
 	// Verify stack was popped
@@ -703,14 +704,14 @@ func TestForTagRenderSegmentWithBreakInterrupt(t *testing.T) {
 	ctx := liquid.NewContext()
 	ctx.Set("array", []interface{}{1, 2, 3})
 
-	var output string
+	output := liquid.NewOutputBuffer()
 	segment := []interface{}{1, 2, 3}
-	tag.renderSegment(ctx, &output, segment)
+	tag.renderSegment(ctx, output, segment)
 	// Should break after rendering item 2 and detecting break in if statement
 	// Output: "1 " (item 1 + space) + "2" (item 2, then break before space)
 	expected := "1 2"
-	if output != expected {
-		t.Errorf("Expected %q, got %q", expected, output)
+	if output.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, output.String())
 	}
 }
 
@@ -734,13 +735,13 @@ func TestForTagRenderSegmentWithContinueInterrupt(t *testing.T) {
 	ctx := liquid.NewContext()
 	ctx.Set("array", []interface{}{1, 2, 3})
 
-	var output string
+	output := liquid.NewOutputBuffer()
 	segment := []interface{}{1, 2, 3}
-	tag.renderSegment(ctx, &output, segment)
+	tag.renderSegment(ctx, output, segment)
 	// Should skip item 2, output should contain "1" and "3" but not "2"
 	expected := "1 3 "
-	if output != expected {
-		t.Errorf("Expected %q, got %q", expected, output)
+	if output.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, output.String())
 	}
 }
 
@@ -766,9 +767,9 @@ func TestForTagRenderSegmentStackPopping(t *testing.T) {
 	initialStack := []*liquid.ForloopDrop{}
 	registers.Set("for_stack", initialStack)
 
-	var output string
+	output := liquid.NewOutputBuffer()
 	segment := []interface{}{1, 2}
-	tag.renderSegment(ctx, &output, segment)
+	tag.renderSegment(ctx, output, segment)
 
 	// Verify stack was popped back to initial state
 	finalStack := registers.Get("for_stack")
@@ -778,3 +779,71 @@ func TestForTagRenderSegmentStackPopping(t *testing.T) {
 		}
 	}
 }
+
+// cancelAtFilter cancels the render once it sees a given item
+type cancelAtFilter struct {
+	cancel context.CancelFunc
+}
+
+func (f *cancelAtFilter) CancelAt(input interface{}, at interface{}) interface{} {
+	if input == at {
+		f.cancel()
+	}
+	return input
+}
+
+// Test that a canceled render stops the loop before the next iteration
+func TestForTagRenderCanceled(t *testing.T) {
+	env := liquid.NewEnvironment()
+	RegisterStandardTags(env)
+	tmpl, err := liquid.ParseTemplate("{% for item in array %}{{ item | cancel_at: 2 }}{% endfor %}", &liquid.TemplateOptions{Environment: env})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+
+	goCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ctx := liquid.BuildContext(liquid.ContextConfig{Environment: env, GoContext: goCtx})
+	ctx.AddFilters([]interface{}{&cancelAtFilter{cancel: cancel}})
+	ctx.Set("array", []interface{}{1, 2, 3, 4})
+
+	output := liquid.NewOutputBuffer()
+	func() {
+		defer func() {
+			if _, ok := recover().(*liquid.CanceledError); !ok {
+				t.Error("Expected render to stop with a CanceledError")
+			}
+		}()
+		tmpl.Root().RenderToOutputBuffer(ctx, output)
+	}()
+
+	if output.String() != "12" {
+		t.Errorf("Expected loop to stop after item 2, got %q", output.String())
+	}
+}
+
+func TestForTagKeyValue(t *testing.T) {
+	pc := liquid.NewParseContext(liquid.ParseContextOptions{})
+	tag, err := NewForTag("for", "key, value in hash reversed limit: 2", pc)
+	if err != nil {
+		t.Fatalf("NewForTag() error = %v", err)
+	}
+	if tag.VariableName() != "key" || tag.ValueName() != "value" {
+		t.Errorf("Expected variables key and value, got %q and %q", tag.VariableName(), tag.ValueName())
+	}
+
+	tokenizer := pc.NewTokenizer("{{ forloop.index }}:{{ key }}={{ value }} {% endfor %}", false, nil, false)
+	if err := tag.Parse(tokenizer); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	ctx := liquid.NewContext()
+	ctx.Set("hash", map[string]interface{}{"b": 2, "a": 1, "c": 3})
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
+
+	if output.String() != "1:b=2 2:a=1 " {
+		t.Errorf("Expected output '1:b=2 2:a=1 ', got %q", output.String())
+	}
+}
@@ -0,0 +1,37 @@
+package tags
+
+import (
+	"testing"
+
+	"github.com/Notifuse/liquidgo/liquid"
+)
+
+// newTestEnvironment returns an environment with the standard tags and the
+// ones added by register, reading partials from templates when given.
+func newTestEnvironment(templates map[string]string, register ...func(*liquid.Environment)) *liquid.Environment {
+	env := liquid.NewEnvironment()
+	RegisterStandardTags(env)
+	for _, fn := range register {
+		fn(env)
+	}
+	if templates != nil {
+		env.SetFileSystem(&mapFileSystem{templates: templates})
+	}
+	return env
+}
+
+// parseTestTemplate parses source with env and line numbers, failing the test on error.
+func parseTestTemplate(t *testing.T, env *liquid.Environment, source string) *liquid.Template {
+	t.Helper()
+	template, err := liquid.ParseTemplate(source, &liquid.TemplateOptions{Environment: env, LineNumbers: true})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	return template
+}
+
+// renderTestTemplate parses source with env and renders it with assigns.
+func renderTestTemplate(t *testing.T, env *liquid.Environment, source string, assigns map[string]interface{}) string {
+	t.Helper()
+	return parseTestTemplate(t, env, source).Render(assigns, nil)
+}
@@ -10,6 +10,8 @@ import (
 var (
 	ifSyntax           = regexp.MustCompile(`(` + liquid.QuotedFragment.String() + `)\s*([=!<>a-z_]+)?\s*(` + liquid.QuotedFragment.String() + `)?`)
 	ifBooleanOperators = []string{"and", "or"}
+	ifGroupingSyntax   = regexp.MustCompile(`[()]|(?:^|[\s(])not[\s(]`)
+	ifRangeSyntax      = regexp.MustCompile(`^\(\s*[^\s()]+\s*\.\.\s*[^\s()]+\s*\)`)
 )
 
 // ConditionBlock represents a condition with its attachment.
@@ -200,7 +202,7 @@ func (i *IfTag) pushBlock(tagName, markup string) error {
 }
 
 // RenderToOutputBuffer renders the if tag.
-func (i *IfTag) RenderToOutputBuffer(context liquid.TagContext, output *string) {
+func (i *IfTag) RenderToOutputBuffer(context liquid.TagContext, output *liquid.OutputBuffer) {
 	// Get the underlying Context which implements ConditionContext
 	ctx := context.Context().(*liquid.Context)
 
@@ -218,7 +220,7 @@ func (i *IfTag) RenderToOutputBuffer(context liquid.TagContext, output *string)
 				return
 			}
 			errorMsg := context.HandleError(err, i.LineNumber())
-			*output += errorMsg
+			output.WriteString(errorMsg)
 			return
 		}
 
@@ -253,6 +255,16 @@ func (i *IfTag) Blank() bool {
 func parseIfCondition(markup string, parseContext liquid.ParseContextInterface) (*liquid.Condition, error) {
 	markup = strings.TrimSpace(markup)
 
+	// Parentheses and not build a condition tree. Lax mode keeps parsing them
+	// as before, so that existing templates don't change meaning.
+	if ifGroupingSyntax.MatchString(markup) {
+		switch parseContext.ErrorMode() {
+		case "strict", "rigid", "strict2", "warn":
+			p := &conditionParser{markup: markup, parseContext: parseContext}
+			return p.parse()
+		}
+	}
+
 	// Split by 'and' and 'or' operators, keeping track of which operator was used
 	// We need to parse from right to left like Ruby does
 	parts := splitByBooleanOperators(markup)
@@ -288,6 +300,191 @@ func parseIfCondition(markup string, parseContext liquid.ParseContextInterface)
 	return condition, nil
 }
 
+// conditionParser parses conditions with parenthesized groups and not. As in
+// conditions without them, and and or are right-associative: a and b or c
+// means a and (b or c).
+type conditionParser struct {
+	markup       string
+	pos          int
+	parseContext liquid.ParseContextInterface
+}
+
+// parse parses the whole markup.
+func (p *conditionParser) parse() (*liquid.Condition, error) {
+	condition, err := p.condition()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpace(); p.pos < len(p.markup) {
+		return nil, p.syntaxError("Unexpected character " + string(p.markup[p.pos]))
+	}
+	return condition, nil
+}
+
+// condition parses terms joined by and and or.
+func (p *conditionParser) condition() (*liquid.Condition, error) {
+	condition, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	for _, op := range ifBooleanOperators {
+		if !p.keyword(op) {
+			continue
+		}
+		p.pos += len(op)
+		right, err := p.condition()
+		if err != nil {
+			return nil, err
+		}
+		if op == "or" {
+			condition.Or(right)
+		} else {
+			condition.And(right)
+		}
+		break
+	}
+	return condition, nil
+}
+
+// term parses a not term, a parenthesized group or a comparison. The
+// returned condition is never chained, so that it can be.
+func (p *conditionParser) term() (*liquid.Condition, error) {
+	p.skipSpace()
+	if p.keyword("not") && p.notOperand() {
+		p.pos += len("not")
+		condition, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		return liquid.NewNotCondition(condition), nil
+	}
+
+	if p.pos < len(p.markup) && p.markup[p.pos] == '(' && !ifRangeSyntax.MatchString(p.markup[p.pos:]) {
+		end := p.closingParen(p.pos)
+		if end < 0 {
+			return nil, p.syntaxError("Unbalanced parentheses")
+		}
+		// Parentheses followed by an operator, e.g. (a + b) * c > 10, belong
+		// to the comparison
+		if rest := strings.TrimSpace(p.markup[end+1:]); rest == "" || rest[0] == ')' || startsWithKeyword(rest, "and") || startsWithKeyword(rest, "or") {
+			p.pos++
+			condition, err := p.condition()
+			if err != nil {
+				return nil, err
+			}
+			if p.skipSpace(); p.pos != end {
+				return nil, p.syntaxError("Unbalanced parentheses")
+			}
+			p.pos++
+			if condition.ChildCondition() != nil {
+				condition = liquid.NewCondition(condition, "", nil)
+			}
+			return condition, nil
+		}
+	}
+
+	return p.comparison()
+}
+
+// comparison parses the markup up to the next and, or or closing parenthesis
+// as a single condition.
+func (p *conditionParser) comparison() (*liquid.Condition, error) {
+	start := p.pos
+	depth := 0
+scan:
+	for p.pos < len(p.markup) {
+		switch c := p.markup[p.pos]; c {
+		case '\'', '"':
+			if end := strings.IndexByte(p.markup[p.pos+1:], c); end >= 0 {
+				p.pos += end + 1
+			}
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			if depth == 0 {
+				break scan
+			}
+			depth--
+		case ' ', '\t', '\n', '\r':
+			if depth == 0 && (startsWithKeyword(p.markup[p.pos+1:], "and") || startsWithKeyword(p.markup[p.pos+1:], "or")) {
+				break scan
+			}
+		}
+		p.pos++
+	}
+	if depth > 0 {
+		return nil, p.syntaxError("Unbalanced parentheses")
+	}
+
+	expr := strings.TrimSpace(p.markup[start:p.pos])
+	if expr == "" {
+		return nil, p.syntaxError("Syntax Error in 'if' - Valid syntax: if [expression]")
+	}
+	return parseSingleCondition(expr, p.parseContext)
+}
+
+// notOperand reports whether the not at the current position negates an
+// operand, rather than being a variable named not.
+func (p *conditionParser) notOperand() bool {
+	rest := strings.TrimSpace(p.markup[p.pos+len("not"):])
+	if rest == "" || strings.IndexByte(")=!<>", rest[0]) >= 0 {
+		return false
+	}
+	return !startsWithKeyword(rest, "and") && !startsWithKeyword(rest, "or") && !startsWithKeyword(rest, "contains")
+}
+
+// closingParen returns the position of the parenthesis closing the one at
+// open, or -1.
+func (p *conditionParser) closingParen(open int) int {
+	depth := 0
+	for i := open; i < len(p.markup); i++ {
+		switch c := p.markup[i]; c {
+		case '\'', '"':
+			if end := strings.IndexByte(p.markup[i+1:], c); end >= 0 {
+				i += end + 1
+			}
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// keyword reports whether the markup continues with the given keyword.
+func (p *conditionParser) keyword(word string) bool {
+	return startsWithKeyword(p.markup[p.pos:], word)
+}
+
+func (p *conditionParser) skipSpace() {
+	for p.pos < len(p.markup) && strings.IndexByte(" \t\n\r", p.markup[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *conditionParser) syntaxError(message string) error {
+	err := liquid.NewSyntaxError(message)
+	err.Err.MarkupContext = "in \"" + p.markup + "\""
+	if lineNumber := p.parseContext.LineNumber(); lineNumber != nil {
+		ln := *lineNumber
+		err.Err.LineNumber = &ln
+	}
+	return err
+}
+
+// startsWithKeyword reports whether s starts with word followed by a space,
+// a parenthesis or the end of s.
+func startsWithKeyword(s, word string) bool {
+	if !strings.HasPrefix(s, word) {
+		return false
+	}
+	return len(s) == len(word) || strings.IndexByte(" \t\n\r(", s[len(word)]) >= 0
+}
+
 type conditionPart struct {
 	expr   string
 	nextOp string // "and" or "or" - the operator that follows this expression
@@ -349,6 +546,15 @@ func parseSingleCondition(expr string, parseContext liquid.ParseContextInterface
 	}
 
 	matches := ifSyntax.FindStringSubmatch(expr)
+	// ifSyntax stops at spaces, so operations such as a + b > 10 are parsed whole
+	if len(matches) > 0 && matches[0] != expr {
+		if operation, ok := parseContext.ParseExpression(expr).(*liquid.Operation); ok {
+			if err := validateExpression(expr, parseContext); err != nil {
+				return nil, err
+			}
+			return liquid.NewCondition(operation, "", nil), nil
+		}
+	}
 	if len(matches) == 0 {
 		// Validate expression syntax in strict/warn mode
 		if err := validateExpression(expr, parseContext); err != nil {
@@ -375,6 +581,8 @@ func parseSingleCondition(expr string, parseContext liquid.ParseContextInterface
 		switch operator {
 		case "==", "!=", "<>", "<", ">", ">=", "<=", "contains":
 			valid = true
+		default:
+			valid = parseContext.Environment() != nil && parseContext.Environment().Operator(operator) != nil
 		}
 
 		if !valid {
@@ -404,11 +612,6 @@ func parseSingleCondition(expr string, parseContext liquid.ParseContextInterface
 func validateExpression(expr string, parseContext liquid.ParseContextInterface) error {
 	mode := parseContext.ErrorMode()
 	if mode == "strict" || mode == "warn" {
-		// Check for parentheses which are not allowed in Liquid conditions
-		if strings.Contains(expr, "(") || strings.Contains(expr, ")") {
-			return liquid.NewSyntaxError("Liquid syntax error: parentheses are not allowed in Liquid conditions")
-		}
-
 		p := parseContext.NewParser(expr)
 		if err := p.Error(); err != nil {
 			if syntaxErr, ok := err.(*liquid.SyntaxError); ok {
@@ -423,3 +626,37 @@ func validateExpression(expr string, parseContext liquid.ParseContextInterface)
 	}
 	return nil
 }
+
+// ParseTreeChildren returns the conditions, each holding its block.
+func (i *IfTag) ParseTreeChildren() []interface{} {
+	children := make([]interface{}, 0, len(i.blocks))
+	for _, block := range i.blocks {
+		children = append(children, block)
+	}
+	return children
+}
+
+// MarshalNode writes the tag for compiled templates.
+func (i *IfTag) MarshalNode(enc *liquid.NodeEncoder) {
+	enc.EncodeBlock(i.Block)
+	enc.WriteInt(len(i.blocks))
+	for _, block := range i.blocks {
+		enc.Encode(block)
+	}
+}
+
+// decodeIfTag rebuilds an IfTag written by MarshalNode.
+func decodeIfTag(dec *liquid.NodeDecoder) (interface{}, error) {
+	tag := &IfTag{Block: dec.DecodeBlock()}
+	count := dec.ReadInt()
+	tag.blocks = make([]ConditionBlock, 0, count)
+	for j := 0; j < count && dec.Err() == nil; j++ {
+		block, ok := dec.Decode().(ConditionBlock)
+		if !ok {
+			dec.Fail(liquid.NewCompiledTemplateError("corrupt compiled " + tag.TagName() + " tag"))
+			break
+		}
+		tag.blocks = append(tag.blocks, block)
+	}
+	return tag, dec.Err()
+}
@@ -36,11 +36,11 @@ func TestIfTagSimpleCondition(t *testing.T) {
 	}
 
 	ctx := liquid.NewContext()
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 
-	if output != "content " {
-		t.Errorf("Expected output 'content ', got %q", output)
+	if output.String() != "content " {
+		t.Errorf("Expected output 'content ', got %q", output.String())
 	}
 }
 
@@ -59,11 +59,11 @@ func TestIfTagFalseCondition(t *testing.T) {
 	}
 
 	ctx := liquid.NewContext()
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 
-	if output != "" {
-		t.Errorf("Expected empty output, got %q", output)
+	if output.String() != "" {
+		t.Errorf("Expected empty output, got %q", output.String())
 	}
 }
 
@@ -86,11 +86,11 @@ func TestIfTagWithElse(t *testing.T) {
 	}
 
 	ctx := liquid.NewContext()
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 
-	if output != " else content " {
-		t.Errorf("Expected output ' else content ', got %q", output)
+	if output.String() != " else content " {
+		t.Errorf("Expected output ' else content ', got %q", output.String())
 	}
 }
 
@@ -113,11 +113,11 @@ func TestIfTagWithElsif(t *testing.T) {
 	}
 
 	ctx := liquid.NewContext()
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 
-	if output != "elsif" {
-		t.Errorf("Expected output 'elsif', got %q", output)
+	if output.String() != "elsif" {
+		t.Errorf("Expected output 'elsif', got %q", output.String())
 	}
 }
 
@@ -240,12 +240,12 @@ func TestIfTagRenderToOutputBuffer(t *testing.T) {
 	}
 
 	ctx := liquid.NewContext()
-	var output string
+	output := liquid.NewOutputBuffer()
 
 	// Test with true condition
-	tag.RenderToOutputBuffer(ctx, &output)
-	if output != "content " {
-		t.Errorf("Expected 'content ', got %q", output)
+	tag.RenderToOutputBuffer(ctx, output)
+	if output.String() != "content " {
+		t.Errorf("Expected 'content ', got %q", output.String())
 	}
 
 	// Test with false condition and else
@@ -254,10 +254,10 @@ func TestIfTagRenderToOutputBuffer(t *testing.T) {
 	if err := tag2.Parse(tokenizer2); err != nil {
 		t.Fatalf("tag2.Parse() error = %v", err)
 	}
-	output2 := ""
-	tag2.RenderToOutputBuffer(ctx, &output2)
-	if output2 != "else content " {
-		t.Errorf("Expected 'else content ', got %q", output2)
+	output2 := liquid.NewOutputBuffer()
+	tag2.RenderToOutputBuffer(ctx, output2)
+	if output2.String() != "else content " {
+		t.Errorf("Expected 'else content ', got %q", output2.String())
 	}
 
 	// Test with error in evaluation
@@ -266,12 +266,12 @@ func TestIfTagRenderToOutputBuffer(t *testing.T) {
 	if err := tag3.Parse(tokenizer3); err != nil {
 		t.Fatalf("tag3.Parse() error = %v", err)
 	}
-	output3 := ""
+	output3 := liquid.NewOutputBuffer()
 	ctx3 := liquid.NewContext()
 	// Set var to something that causes error
-	tag3.RenderToOutputBuffer(ctx3, &output3)
+	tag3.RenderToOutputBuffer(ctx3, output3)
 	// Should handle error gracefully
-	_ = output3
+	_ = output3.String()
 }
 
 func TestIfTagParseIfCondition(t *testing.T) {
@@ -597,12 +597,12 @@ func TestIfTagRenderToOutputBufferEvaluateError(t *testing.T) {
 	}
 
 	ctx := liquid.NewContext()
-	var output string
+	output := liquid.NewOutputBuffer()
 
 	// Render - if var doesn't exist, Evaluate might return error
-	tag.RenderToOutputBuffer(ctx, &output)
+	tag.RenderToOutputBuffer(ctx, output)
 	// Should handle error gracefully
-	_ = output
+	_ = output.String()
 }
 
 // Test RenderToOutputBuffer with false condition
@@ -621,11 +621,11 @@ func TestIfTagRenderToOutputBufferFalseCondition(t *testing.T) {
 	}
 
 	ctx := liquid.NewContext()
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 	// Should not render anything for false condition
-	if output != "" {
-		t.Errorf("Expected empty output for false condition, got %q", output)
+	if output.String() != "" {
+		t.Errorf("Expected empty output for false condition, got %q", output.String())
 	}
 }
 
@@ -645,11 +645,11 @@ func TestIfTagRenderToOutputBufferEmptyStringCondition(t *testing.T) {
 	}
 
 	ctx := liquid.NewContext()
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 	// Empty string should be falsy
-	if output != "" {
-		t.Errorf("Expected empty output for empty string condition, got %q", output)
+	if output.String() != "" {
+		t.Errorf("Expected empty output for empty string condition, got %q", output.String())
 	}
 }
 
@@ -669,11 +669,11 @@ func TestIfTagRenderToOutputBufferNilCondition(t *testing.T) {
 	}
 
 	ctx := liquid.NewContext()
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 	// Nil should be falsy
-	if output != "" {
-		t.Errorf("Expected empty output for nil condition, got %q", output)
+	if output.String() != "" {
+		t.Errorf("Expected empty output for nil condition, got %q", output.String())
 	}
 }
 
@@ -691,10 +691,10 @@ func TestIfTagRenderToOutputBufferNonBlockBodyAttachment(t *testing.T) {
 	tag.blocks = []ConditionBlock{condition}
 
 	ctx := liquid.NewContext()
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 	// Should handle gracefully - attachment won't render but no error
-	_ = output
+	_ = output.String()
 }
 
 // Test NewIfTag with error in parseIfCondition
@@ -754,10 +754,10 @@ func TestIfTagParseWithMultipleElsif(t *testing.T) {
 	}
 
 	ctx := liquid.NewContext()
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
-	if output != "elsif2" {
-		t.Errorf("Expected output 'elsif2', got %q", output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
+	if output.String() != "elsif2" {
+		t.Errorf("Expected output 'elsif2', got %q", output.String())
 	}
 }
 
@@ -777,12 +777,12 @@ func TestIfTagMultipleElsif(t *testing.T) {
 	}
 
 	ctx := liquid.NewContext()
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 
 	// Should render first elsif block that evaluates to true
-	if output != "elsif1 content " {
-		t.Logf("Note: output is %q (may render first matching elsif)", output)
+	if output.String() != "elsif1 content " {
+		t.Logf("Note: output is %q (may render first matching elsif)", output.String())
 	}
 }
 
@@ -811,13 +811,13 @@ func TestIfTagComplexNestedConditions(t *testing.T) {
 	}
 
 	ctx := liquid.NewContext()
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 
 	// Should render outer content but not inner
 	expected := "outer  outer end "
-	if output != expected {
-		t.Errorf("Expected %q, got %q", expected, output)
+	if output.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, output.String())
 	}
 }
 
@@ -841,11 +841,11 @@ func TestIfTagWithOrCondition(t *testing.T) {
 	ctx.Set("a", false)
 	ctx.Set("b", true)
 
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 
-	if output != "YES " {
-		t.Errorf("Expected 'YES ', got %q", output)
+	if output.String() != "YES " {
+		t.Errorf("Expected 'YES ', got %q", output.String())
 	}
 }
 
@@ -869,11 +869,11 @@ func TestIfTagWithAndCondition(t *testing.T) {
 	ctx.Set("a", true)
 	ctx.Set("b", true)
 
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 
-	if output != "YES " {
-		t.Errorf("Expected 'YES ', got %q", output)
+	if output.String() != "YES " {
+		t.Errorf("Expected 'YES ', got %q", output.String())
 	}
 
 	// Test: a and b (a=true, b=false) should be false
@@ -885,11 +885,11 @@ func TestIfTagWithAndCondition(t *testing.T) {
 	ctx2.Set("a", true)
 	ctx2.Set("b", false)
 
-	var output2 string
-	tag2.RenderToOutputBuffer(ctx2, &output2)
+	output2 := liquid.NewOutputBuffer()
+	tag2.RenderToOutputBuffer(ctx2, output2)
 
-	if output2 != "" {
-		t.Errorf("Expected empty output, got %q", output2)
+	if output2.String() != "" {
+		t.Errorf("Expected empty output, got %q", output2.String())
 	}
 }
 
@@ -913,10 +913,82 @@ func TestIfTagWithComplexCondition(t *testing.T) {
 	ctx.Set("a", 0)
 	ctx.Set("b", 2)
 
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 
-	if output != "YES " {
-		t.Errorf("Expected 'YES ', got %q", output)
+	if output.String() != "YES " {
+		t.Errorf("Expected 'YES ', got %q", output.String())
+	}
+}
+
+// TestParseIfConditionGrouping tests parentheses and not in conditions
+func TestParseIfConditionGrouping(t *testing.T) {
+	assigns := map[string]interface{}{"a": true, "b": false, "c": false, "n": 7}
+	tests := []struct {
+		markup string
+		want   bool
+	}{
+		{"(a or b) and c", false},
+		{"a or (b and c)", true},
+		{"a or b and c", true},
+		{"not a", false},
+		{"not b and a", true},
+		{"not (a and b)", true},
+		{"not (a or b) or c", false},
+		{"((a))", true},
+		{"not not a", true},
+		{"not n == 7", false},
+		{"(n + 1) * 2 == 16 and a", true},
+		{"(1..5) or b", true},
+		{"(b) or 'x (y' == 'x (y'", true},
+		{"not == nil", true},
+	}
+	for _, mode := range []string{"strict", "rigid", "warn"} {
+		pc := liquid.NewParseContext(liquid.ParseContextOptions{ErrorMode: mode})
+		for _, tt := range tests {
+			condition, err := parseIfCondition(tt.markup, pc)
+			if err != nil {
+				t.Errorf("%s: parseIfCondition(%q) error = %v", mode, tt.markup, err)
+				continue
+			}
+			ctx := liquid.NewContext()
+			for k, v := range assigns {
+				ctx.Set(k, v)
+			}
+			if got, err := condition.Evaluate(ctx); err != nil || got != tt.want {
+				t.Errorf("%s: Evaluate(%q) = %v, %v, want %v", mode, tt.markup, got, err, tt.want)
+			}
+		}
+	}
+}
+
+// TestParseIfConditionLaxKeepsLegacyParsing tests that lax mode doesn't group
+// parentheses, so that existing templates keep their meaning
+func TestParseIfConditionLaxKeepsLegacyParsing(t *testing.T) {
+	pc := liquid.NewParseContext(liquid.ParseContextOptions{ErrorMode: "lax"})
+	condition, err := parseIfCondition("(a == 1 or b == 3) and c == 4", pc)
+	if err != nil {
+		t.Fatalf("parseIfCondition() error = %v", err)
+	}
+	ctx := liquid.NewContext()
+	ctx.Set("a", 1)
+	ctx.Set("b", 2)
+	ctx.Set("c", 3)
+	if got, err := condition.Evaluate(ctx); err != nil || got != true {
+		t.Errorf("Evaluate() = %v, %v, want the legacy result true", got, err)
+	}
+}
+
+// TestParseIfConditionGroupingErrors tests malformed groups in strict and lax mode
+func TestParseIfConditionGroupingErrors(t *testing.T) {
+	for _, markup := range []string{"(a or b", "a or b)", "(a or b) and", "()"} {
+		strict := liquid.NewParseContext(liquid.ParseContextOptions{ErrorMode: "strict"})
+		if _, err := parseIfCondition(markup, strict); err == nil {
+			t.Errorf("strict: parseIfCondition(%q) expected an error", markup)
+		}
+		lax := liquid.NewParseContext(liquid.ParseContextOptions{ErrorMode: "lax"})
+		if _, err := parseIfCondition(markup, lax); err != nil {
+			t.Errorf("lax: parseIfCondition(%q) error = %v", markup, err)
+		}
 	}
 }
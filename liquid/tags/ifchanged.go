@@ -19,10 +19,11 @@ func NewIfchangedTag(tagName, markup string, parseContext liquid.ParseContextInt
 
 // RenderToOutputBuffer renders the ifchanged tag.
 // Only renders if the block output is different from the last rendered output.
-func (i *IfchangedTag) RenderToOutputBuffer(context liquid.TagContext, output *string) {
+func (i *IfchangedTag) RenderToOutputBuffer(context liquid.TagContext, output *liquid.OutputBuffer) {
 	// Render block body to temporary buffer
-	blockOutput := ""
-	i.Block.RenderToOutputBuffer(context, &blockOutput)
+	buffer := liquid.NewOutputBuffer()
+	i.Block.RenderToOutputBuffer(context, buffer)
+	blockOutput := buffer.String()
 
 	// Get registers
 	registers := context.Registers()
@@ -38,6 +39,16 @@ func (i *IfchangedTag) RenderToOutputBuffer(context liquid.TagContext, output *s
 	// Only output if different from last output
 	if blockOutput != lastOutput {
 		registers.Set("ifchanged", blockOutput)
-		*output += blockOutput
+		output.WriteString(blockOutput)
 	}
 }
+
+// MarshalNode writes the tag for compiled templates.
+func (i *IfchangedTag) MarshalNode(enc *liquid.NodeEncoder) {
+	enc.EncodeBlock(i.Block)
+}
+
+// decodeIfchangedTag rebuilds an IfchangedTag written by MarshalNode.
+func decodeIfchangedTag(dec *liquid.NodeDecoder) (interface{}, error) {
+	return &IfchangedTag{Block: dec.DecodeBlock()}, dec.Err()
+}
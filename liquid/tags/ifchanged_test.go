@@ -32,12 +32,12 @@ func TestIfchangedTagRendersWhenChanged(t *testing.T) {
 	}
 
 	ctx := liquid.NewContext()
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 
 	// First render should output content
-	if output != "content " {
-		t.Errorf("Expected output 'content ', got %q", output)
+	if output.String() != "content " {
+		t.Errorf("Expected output 'content ', got %q", output.String())
 	}
 }
 
@@ -56,19 +56,19 @@ func TestIfchangedTagDoesNotRenderWhenUnchanged(t *testing.T) {
 	}
 
 	ctx := liquid.NewContext()
-	var output string
+	output := liquid.NewOutputBuffer()
 
 	// First render
-	tag.RenderToOutputBuffer(ctx, &output)
-	if output != "content " {
-		t.Errorf("Expected output 'content ', got %q", output)
+	tag.RenderToOutputBuffer(ctx, output)
+	if output.String() != "content " {
+		t.Errorf("Expected output 'content ', got %q", output.String())
 	}
 
 	// Second render with same content should not output
-	output = ""
-	tag.RenderToOutputBuffer(ctx, &output)
-	if output != "" {
-		t.Errorf("Expected empty output on second render, got %q", output)
+	output.Reset()
+	tag.RenderToOutputBuffer(ctx, output)
+	if output.String() != "" {
+		t.Errorf("Expected empty output on second render, got %q", output.String())
 	}
 }
 
@@ -87,26 +87,26 @@ func TestIfchangedTagRendersWhenContentChanges(t *testing.T) {
 	}
 
 	ctx := liquid.NewContext()
-	var output string
+	output := liquid.NewOutputBuffer()
 
 	// First render with item=1
 	ctx.Set("item", 1)
-	tag.RenderToOutputBuffer(ctx, &output)
-	if output != "1 " {
-		t.Errorf("Expected output '1 ', got %q", output)
+	tag.RenderToOutputBuffer(ctx, output)
+	if output.String() != "1 " {
+		t.Errorf("Expected output '1 ', got %q", output.String())
 	}
 
 	// Second render with same item=1 should not output
-	output = ""
-	tag.RenderToOutputBuffer(ctx, &output)
-	if output != "" {
-		t.Errorf("Expected empty output, got %q", output)
+	output.Reset()
+	tag.RenderToOutputBuffer(ctx, output)
+	if output.String() != "" {
+		t.Errorf("Expected empty output, got %q", output.String())
 	}
 
 	// Third render with item=2 should output
 	ctx.Set("item", 2)
-	tag.RenderToOutputBuffer(ctx, &output)
-	if output != "2 " {
-		t.Errorf("Expected output '2 ', got %q", output)
+	tag.RenderToOutputBuffer(ctx, output)
+	if output.String() != "2 " {
+		t.Errorf("Expected output '2 ', got %q", output.String())
 	}
 }
@@ -3,6 +3,7 @@ package tags
 import (
 	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/Notifuse/liquidgo/liquid"
@@ -64,15 +65,7 @@ func (i *IncludeTag) parseMarkup(markup string, parseContext liquid.ParseContext
 	}
 
 	// Parse attributes
-	attributeMatches := liquid.TagAttributes.FindAllStringSubmatch(markup, -1)
-	for _, match := range attributeMatches {
-		if len(match) >= 3 {
-			key := strings.TrimSpace(match[1])
-			value := strings.TrimSpace(match[2])
-			// Let ParseExpression handle quoted strings correctly
-			i.attributes[key] = parseContext.ParseExpression(value)
-		}
-	}
+	i.attributes = parseTagAttributes(markup, parseContext)
 
 	return nil
 }
@@ -84,7 +77,7 @@ func (i *IncludeTag) Parse(tokenizer *liquid.Tokenizer) error {
 }
 
 // RenderToOutputBuffer renders the include tag.
-func (i *IncludeTag) RenderToOutputBuffer(context liquid.TagContext, output *string) {
+func (i *IncludeTag) RenderToOutputBuffer(context liquid.TagContext, output *liquid.OutputBuffer) {
 	// Evaluate template name
 	templateName := context.Evaluate(i.templateNameExpr)
 	templateNameStr, ok := templateName.(string)
@@ -100,7 +93,7 @@ func (i *IncludeTag) RenderToOutputBuffer(context liquid.TagContext, output *str
 			msg = "include tag requires a string template name"
 		}
 		errorMsg := context.HandleError(liquid.NewArgumentError(msg), i.LineNumber())
-		*output += errorMsg
+		output.WriteString(errorMsg)
 		return
 	}
 
@@ -108,7 +101,7 @@ func (i *IncludeTag) RenderToOutputBuffer(context liquid.TagContext, output *str
 	partial, err := liquid.LoadPartial(templateNameStr, context, i.ParseContext())
 	if err != nil {
 		errorMsg := context.HandleError(err, i.LineNumber())
-		*output += errorMsg
+		output.WriteString(errorMsg)
 		return
 	}
 
@@ -116,7 +109,7 @@ func (i *IncludeTag) RenderToOutputBuffer(context liquid.TagContext, output *str
 	partialTemplate, ok := partial.(*liquid.Template)
 	if !ok {
 		errorMsg := context.HandleError(liquid.NewFileSystemError("partial is not a template"), i.LineNumber())
-		*output += errorMsg
+		output.WriteString(errorMsg)
 		return
 	}
 
@@ -143,7 +136,7 @@ func (i *IncludeTag) RenderToOutputBuffer(context liquid.TagContext, output *str
 	ctx, ok := context.(*liquid.Context)
 	if !ok {
 		errorMsg := context.HandleError(liquid.NewInternalError("context is not a liquid.Context"), i.LineNumber())
-		*output += errorMsg
+		output.WriteString(errorMsg)
 		return
 	}
 
@@ -219,3 +212,97 @@ func (i *IncludeTag) AliasName() string {
 func (i *IncludeTag) Attributes() map[string]interface{} {
 	return i.attributes
 }
+
+// ParseTreeChildren returns the template name, variable and attribute expressions.
+func (i *IncludeTag) ParseTreeChildren() []interface{} {
+	children := []interface{}{i.templateNameExpr, i.variableNameExpr}
+	return append(children, sortedAttributeValues(i.attributes)...)
+}
+
+// VariableScope reports the included partial. It shares the caller's scope,
+// so its assigns remain visible after the include.
+func (i *IncludeTag) VariableScope() *liquid.VariableScope {
+	return &liquid.VariableScope{
+		Partial: partialReference(i.templateNameExpr, i.variableNameExpr, i.aliasName, i.attributes, false, false),
+	}
+}
+
+// MarshalNode writes the tag for compiled templates.
+func (i *IncludeTag) MarshalNode(enc *liquid.NodeEncoder) {
+	enc.EncodeTag(i.Tag)
+	enc.Encode(i.templateNameExpr)
+	enc.Encode(i.variableNameExpr)
+	enc.WriteString(i.aliasName)
+	enc.Encode(i.attributes)
+}
+
+// decodeIncludeTag rebuilds an IncludeTag written by MarshalNode.
+func decodeIncludeTag(dec *liquid.NodeDecoder) (interface{}, error) {
+	tag := &IncludeTag{Tag: dec.DecodeTag()}
+	tag.templateNameExpr = dec.Decode()
+	tag.variableNameExpr = dec.Decode()
+	tag.aliasName = dec.ReadString()
+	tag.attributes = dec.DecodeMap()
+	return tag, dec.Err()
+}
+
+// partialReference describes the partial rendered by an include or render tag
+// for static analysis.
+func partialReference(templateNameExpr, variableNameExpr interface{}, aliasName string, attributes map[string]interface{}, iterates, isolated bool) *liquid.PartialReference {
+	partial := &liquid.PartialReference{Isolated: isolated}
+	if name, ok := templateNameExpr.(string); ok {
+		partial.Name = name
+	}
+
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		partial.Variables = append(partial.Variables, liquid.ScopedVariable{Name: key, Source: attributes[key]})
+	}
+
+	variableName := aliasName
+	if variableName == "" && partial.Name != "" {
+		parts := strings.Split(partial.Name, "/")
+		variableName = parts[len(parts)-1]
+	}
+	if variableName != "" && (variableNameExpr != nil || !isolated) {
+		partial.Variables = append(partial.Variables, liquid.ScopedVariable{
+			Name:     variableName,
+			Source:   variableNameExpr,
+			Iterates: iterates,
+		})
+	}
+	return partial
+}
+
+// parseTagAttributes parses the "key: value" attributes of include, render
+// and component markup into expressions.
+func parseTagAttributes(markup string, parseContext liquid.ParseContextInterface) map[string]interface{} {
+	attributes := make(map[string]interface{})
+	for _, match := range liquid.TagAttributes.FindAllStringSubmatch(markup, -1) {
+		if len(match) >= 3 {
+			key := strings.TrimSpace(match[1])
+			value := strings.TrimSpace(match[2])
+			// Let ParseExpression handle quoted strings correctly
+			attributes[key] = parseContext.ParseExpression(value)
+		}
+	}
+	return attributes
+}
+
+// sortedAttributeValues returns the attribute expressions ordered by name.
+func sortedAttributeValues(attributes map[string]interface{}) []interface{} {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	values := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		values = append(values, attributes[key])
+	}
+	return values
+}
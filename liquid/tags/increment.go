@@ -26,7 +26,7 @@ func (i *IncrementTag) VariableName() string {
 }
 
 // RenderToOutputBuffer renders the increment tag.
-func (i *IncrementTag) RenderToOutputBuffer(context liquid.TagContext, output *string) {
+func (i *IncrementTag) RenderToOutputBuffer(context liquid.TagContext, output *liquid.OutputBuffer) {
 	// Get counter environment (first environment)
 	ctx := context.Context().(*liquid.Context)
 	environments := ctx.Scopes()
@@ -52,6 +52,24 @@ func (i *IncrementTag) RenderToOutputBuffer(context liquid.TagContext, output *s
 	}
 
 	// Output current value, then increment
-	*output += liquid.ToS(intValue, nil)
+	output.WriteString(liquid.ToS(intValue, nil))
 	counterEnv[i.variableName] = intValue + 1
 }
+
+// VariableScope reports the counter variable.
+func (i *IncrementTag) VariableScope() *liquid.VariableScope {
+	return &liquid.VariableScope{Assigns: []liquid.ScopedVariable{{Name: i.variableName}}}
+}
+
+// MarshalNode writes the tag for compiled templates.
+func (i *IncrementTag) MarshalNode(enc *liquid.NodeEncoder) {
+	enc.EncodeTag(i.Tag)
+	enc.WriteString(i.variableName)
+}
+
+// decodeIncrementTag rebuilds an IncrementTag written by MarshalNode.
+func decodeIncrementTag(dec *liquid.NodeDecoder) (interface{}, error) {
+	tag := &IncrementTag{Tag: dec.DecodeTag()}
+	tag.variableName = dec.ReadString()
+	return tag, dec.Err()
+}
@@ -33,7 +33,7 @@ func NewInlineCommentTag(tagName, markup string, parseContext liquid.ParseContex
 }
 
 // RenderToOutputBuffer renders nothing for inline comments.
-func (i *InlineCommentTag) RenderToOutputBuffer(context liquid.TagContext, output *string) {
+func (i *InlineCommentTag) RenderToOutputBuffer(context liquid.TagContext, output *liquid.OutputBuffer) {
 	// Do nothing - comments don't render
 	_ = context // no-op to register coverage
 }
@@ -42,3 +42,13 @@ func (i *InlineCommentTag) RenderToOutputBuffer(context liquid.TagContext, outpu
 func (i *InlineCommentTag) Blank() bool {
 	return true
 }
+
+// MarshalNode writes the tag for compiled templates.
+func (i *InlineCommentTag) MarshalNode(enc *liquid.NodeEncoder) {
+	enc.EncodeTag(i.Tag)
+}
+
+// decodeInlineCommentTag rebuilds an InlineCommentTag written by MarshalNode.
+func decodeInlineCommentTag(dec *liquid.NodeDecoder) (interface{}, error) {
+	return &InlineCommentTag{Tag: dec.DecodeTag()}, dec.Err()
+}
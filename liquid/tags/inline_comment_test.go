@@ -21,11 +21,11 @@ func TestInlineCommentTag(t *testing.T) {
 	}
 
 	ctx := liquid.NewContext()
-	var output string
-	tag.RenderToOutputBuffer(ctx, &output)
+	output := liquid.NewOutputBuffer()
+	tag.RenderToOutputBuffer(ctx, output)
 
-	if output != "" {
-		t.Errorf("Expected empty output, got %q", output)
+	if output.String() != "" {
+		t.Errorf("Expected empty output, got %q", output.String())
 	}
 }
 
@@ -68,12 +68,12 @@ func TestInlineCommentTagRenderToOutputBuffer(t *testing.T) {
 	}
 
 	ctx := liquid.NewContext()
-	var output string
+	output := liquid.NewOutputBuffer()
 	// Explicitly test RenderToOutputBuffer
-	tag.RenderToOutputBuffer(ctx, &output)
+	tag.RenderToOutputBuffer(ctx, output)
 
 	// Inline comment should render nothing
-	if output != "" {
-		t.Errorf("Expected empty output from RenderToOutputBuffer, got %q", output)
+	if output.String() != "" {
+		t.Errorf("Expected empty output from RenderToOutputBuffer, got %q", output.String())
 	}
 }
@@ -0,0 +1,379 @@
+package tags
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/Notifuse/liquidgo/liquid"
+)
+
+var (
+	layoutSyntax = regexp.MustCompile(`^\s*(` + liquid.QuotedString.String() + `)\s*$`)
+	blockSyntax  = regexp.MustCompile(`^\s*([\w\-]+)\s*$`)
+)
+
+// layoutBlocksRegister is the register holding the block overrides of the
+// layout being rendered.
+const layoutBlocksRegister = "layout_blocks"
+
+// layoutBlock is a block definition and the template it comes from.
+type layoutBlock struct {
+	tag      *BlockTag
+	template string
+}
+
+// layoutBlocks maps each block name to its definitions, from the most derived
+// template to the layout that renders it.
+type layoutBlocks map[string][]layoutBlock
+
+// LayoutTag makes the template render inside a layout: {% layout 'base' %}.
+// The rest of the template is parsed as the tag body; only its block tags are
+// used, to override the blocks of the layout. Content before the layout tag
+// renders as usual, so variables assigned there are visible to the layout.
+type LayoutTag struct {
+	*liquid.Tag
+	templateName string
+	body         *liquid.BlockBody
+}
+
+// NewLayoutTag creates a new LayoutTag.
+func NewLayoutTag(tagName, markup string, parseContext liquid.ParseContextInterface) (*LayoutTag, error) {
+	matches := layoutSyntax.FindStringSubmatch(markup)
+	if len(matches) == 0 {
+		return nil, liquid.NewSyntaxError("Syntax Error in 'layout' - Valid syntax: layout '[template]'")
+	}
+	if parseContext.Depth() > 0 {
+		return nil, liquid.NewSyntaxError("layout tag must be at the top level of the template")
+	}
+
+	name, _ := parseContext.ParseExpression(matches[1]).(string)
+	return &LayoutTag{
+		Tag:          liquid.NewTag(tagName, markup, parseContext),
+		templateName: name,
+	}, nil
+}
+
+// Parse parses the rest of the template as the body of the layout tag.
+func (l *LayoutTag) Parse(tokenizer *liquid.Tokenizer) error {
+	parseContext := l.ParseContext()
+	l.body = liquid.NewBlockBody()
+
+	parseContext.IncrementDepth()
+	defer parseContext.DecrementDepth()
+
+	unknownTagHandler := func(tagName, markup string) bool {
+		if tagName == "" {
+			return false
+		}
+		var err error = liquid.NewSyntaxError("Unknown tag '" + tagName + "'")
+		if strings.HasPrefix(tagName, "end") || tagName == "else" {
+			err = liquid.NewSyntaxError("Unexpected '" + tagName + "' tag after layout")
+		}
+		if parseContext.ErrorMode() == "warn" {
+			parseContext.AddWarning(err)
+			return true
+		}
+		panic(err)
+	}
+	return l.body.Parse(tokenizer, parseContext, unknownTagHandler)
+}
+
+// TemplateName returns the name of the layout.
+func (l *LayoutTag) TemplateName() string {
+	return l.templateName
+}
+
+// Nodelist returns the nodes following the layout tag.
+func (l *LayoutTag) Nodelist() []interface{} {
+	if l.body == nil {
+		return []interface{}{}
+	}
+	return l.body.Nodelist()
+}
+
+// Blank returns false since the layout renders in place of the template.
+func (l *LayoutTag) Blank() bool {
+	return false
+}
+
+// RenderToOutputBuffer loads the layout chain through the partial cache,
+// checks it and renders the outermost layout with the block overrides.
+func (l *LayoutTag) RenderToOutputBuffer(context liquid.TagContext, output *liquid.OutputBuffer) {
+	ctx, ok := context.(*liquid.Context)
+	if !ok {
+		output.WriteString(context.HandleError(liquid.NewInternalError("context is not a liquid.Context"), l.LineNumber()))
+		return
+	}
+
+	callerName := ctx.TemplateName()
+	root, rootName, blocks, err := l.resolveLayouts(ctx)
+	ctx.SetTemplateName(callerName)
+	if err != nil {
+		output.WriteString(context.HandleError(err, l.LineNumber()))
+		return
+	}
+
+	registers := ctx.Registers()
+	previous := registers.Get(layoutBlocksRegister)
+	registers.Set(layoutBlocksRegister, blocks)
+	ctx.SetTemplateName(rootName)
+
+	root.RenderToOutputBuffer(ctx, output)
+
+	ctx.SetTemplateName(callerName)
+	if previous != nil {
+		registers.Set(layoutBlocksRegister, previous)
+	} else {
+		registers.Delete(layoutBlocksRegister)
+	}
+}
+
+// layoutLevel is a template of the layout chain and the blocks it defines.
+type layoutLevel struct {
+	name      string
+	blocks    []*BlockTag // All the blocks, including the ones nested in other blocks
+	overrides []*BlockTag // The blocks that are not nested in another block
+}
+
+// unnamedTemplate stands for a template without a name in layout errors.
+const unnamedTemplate = "(template)"
+
+// displayName returns the name of a template for layout errors.
+func displayName(name string) string {
+	if name == "" {
+		return unnamedTemplate
+	}
+	return name
+}
+
+func newLayoutLevel(name string, node interface{}) layoutLevel {
+	return layoutLevel{name: name, blocks: collectBlockTags(node, true), overrides: collectBlockTags(node, false)}
+}
+
+// resolveLayouts loads the layouts of the chain starting at l and returns the
+// outermost one, its name and the block overrides of the chain.
+func (l *LayoutTag) resolveLayouts(ctx *liquid.Context) (*liquid.Template, string, layoutBlocks, error) {
+	levels := []layoutLevel{newLayoutLevel(ctx.TemplateName(), l)}
+	chain := []string{ctx.TemplateName()}
+	current := l
+	names := []string{displayName(ctx.TemplateName())}
+
+	for {
+		name := liquid.ResolveParentTemplateName(current.templateName, ctx.TemplateName())
+		for _, seen := range chain {
+			if seen == name {
+				err := liquid.NewSyntaxError("Layout cycle: " + strings.Join(append(names, displayName(name)), " -> "))
+				err.Err.TemplateName = ctx.TemplateName()
+				err.Err.LineNumber = current.LineNumber()
+				return nil, "", nil, err
+			}
+		}
+		chain = append(chain, name)
+		names = append(names, displayName(name))
+
+		partial, err := liquid.LoadPartial(name, ctx, current.ParseContext())
+		if err != nil {
+			return nil, "", nil, err
+		}
+		layout, ok := partial.(*liquid.Template)
+		if !ok || layout.Root() == nil {
+			return nil, "", nil, liquid.NewFileSystemError("layout is not a template")
+		}
+		ctx.SetTemplateName(name)
+
+		current = findLayoutTag(layout.Root().Nodelist())
+		if current == nil {
+			levels = append(levels, newLayoutLevel(name, layout.Root()))
+			blocks, err := layoutBlockOverrides(levels)
+			return layout, name, blocks, err
+		}
+		levels = append(levels, newLayoutLevel(name, current))
+	}
+}
+
+// layoutBlockOverrides checks the blocks of a layout chain and groups them by
+// name. A block that is not nested in another block overrides a block of the
+// layouts, so one of them must define it.
+func layoutBlockOverrides(levels []layoutLevel) (layoutBlocks, error) {
+	blocks := make(layoutBlocks)
+	for _, level := range levels {
+		seen := make(map[string]bool)
+		for _, tag := range level.blocks {
+			if seen[tag.name] {
+				return nil, layoutBlockError("Block '"+tag.name+"' is defined more than once in '"+displayName(level.name)+"'", level.name, tag)
+			}
+			seen[tag.name] = true
+			blocks[tag.name] = append(blocks[tag.name], layoutBlock{tag: tag, template: level.name})
+		}
+	}
+
+	defined := make(map[string]bool)
+	for i := len(levels) - 1; i >= 0; i-- {
+		if i < len(levels)-1 {
+			for _, tag := range levels[i].overrides {
+				if !defined[tag.name] {
+					return nil, layoutBlockError("Block '"+tag.name+"' in '"+displayName(levels[i].name)+"' is not defined by layout '"+displayName(levels[i+1].name)+"'", levels[i].name, tag)
+				}
+			}
+		}
+		for _, tag := range levels[i].blocks {
+			defined[tag.name] = true
+		}
+	}
+	return blocks, nil
+}
+
+func layoutBlockError(message, templateName string, tag *BlockTag) *liquid.SyntaxError {
+	err := liquid.NewSyntaxError(message)
+	err.Err.TemplateName = templateName
+	err.Err.LineNumber = tag.LineNumber()
+	return err
+}
+
+// findLayoutTag returns the layout tag among the top-level nodes of a template.
+func findLayoutTag(nodes []interface{}) *LayoutTag {
+	for _, node := range nodes {
+		if layout, ok := node.(*LayoutTag); ok {
+			return layout
+		}
+	}
+	return nil
+}
+
+// collectBlockTags returns the block tags under node, in template order.
+// Blocks nested in other blocks are only included when nested is true.
+func collectBlockTags(node interface{}, nested bool) []*BlockTag {
+	var blocks []*BlockTag
+	for _, child := range liquid.ForParseTreeVisitor(node, nil).Children() {
+		block, ok := child.(*BlockTag)
+		if ok {
+			blocks = append(blocks, block)
+		}
+		if !ok || nested {
+			blocks = append(blocks, collectBlockTags(child, nested)...)
+		}
+	}
+	return blocks
+}
+
+// VariableScope reports the layout, which renders in the template's scope.
+func (l *LayoutTag) VariableScope() *liquid.VariableScope {
+	return &liquid.VariableScope{Partial: &liquid.PartialReference{Name: l.templateName}}
+}
+
+// MarshalNode writes the tag for compiled templates.
+func (l *LayoutTag) MarshalNode(enc *liquid.NodeEncoder) {
+	enc.EncodeTag(l.Tag)
+	enc.WriteString(l.templateName)
+	enc.Encode(l.body)
+}
+
+// decodeLayoutTag rebuilds a LayoutTag written by MarshalNode.
+func decodeLayoutTag(dec *liquid.NodeDecoder) (interface{}, error) {
+	tag := &LayoutTag{Tag: dec.DecodeTag()}
+	tag.templateName = dec.ReadString()
+	tag.body = dec.DecodeBlockBody()
+	return tag, dec.Err()
+}
+
+// BlockTag is a named block of a layout: {% block name %}default{% endblock %}.
+// A template using the layout overrides it by defining a block with the same
+// name; inside the override, {{ block.super }} renders the overridden content.
+type BlockTag struct {
+	*liquid.Block
+	name string
+}
+
+// NewBlockTag creates a new BlockTag.
+func NewBlockTag(tagName, markup string, parseContext liquid.ParseContextInterface) (*BlockTag, error) {
+	matches := blockSyntax.FindStringSubmatch(markup)
+	if len(matches) == 0 {
+		return nil, liquid.NewSyntaxError("Syntax Error in 'block' - Valid syntax: block [name]")
+	}
+	return &BlockTag{
+		Block: liquid.NewBlock(tagName, markup, parseContext),
+		name:  matches[1],
+	}, nil
+}
+
+// Name returns the block name.
+func (b *BlockTag) Name() string {
+	return b.name
+}
+
+// Blank returns false: an override may render content even if the default is blank.
+func (b *BlockTag) Blank() bool {
+	return false
+}
+
+// RenderToOutputBuffer renders the most derived definition of the block.
+func (b *BlockTag) RenderToOutputBuffer(context liquid.TagContext, output *liquid.OutputBuffer) {
+	ctx, ok := context.(*liquid.Context)
+	if !ok {
+		b.Block.RenderToOutputBuffer(context, output)
+		return
+	}
+	blocks, _ := ctx.Registers().Get(layoutBlocksRegister).(layoutBlocks)
+	definitions := blocks[b.name]
+	if len(definitions) == 0 {
+		definitions = []layoutBlock{{tag: b, template: ctx.TemplateName()}}
+	}
+	renderLayoutBlock(ctx, definitions, output)
+}
+
+// renderLayoutBlock renders the first definition with a "block" variable
+// whose super renders the next one.
+func renderLayoutBlock(ctx *liquid.Context, definitions []layoutBlock, output *liquid.OutputBuffer) {
+	definition := definitions[0]
+	drop := &BlockDrop{Drop: liquid.NewDrop(), name: definition.tag.name}
+	if len(definitions) > 1 {
+		drop.super = func() string {
+			superOutput := liquid.NewOutputBuffer()
+			renderLayoutBlock(ctx, definitions[1:], superOutput)
+			return superOutput.String()
+		}
+	}
+
+	callerName := ctx.TemplateName()
+	ctx.SetTemplateName(definition.template)
+	ctx.Stack(map[string]interface{}{"block": drop}, func() {
+		if body := definition.tag.Body(); body != nil {
+			body.RenderToOutputBuffer(ctx, output)
+		}
+	})
+	ctx.SetTemplateName(callerName)
+}
+
+// MarshalNode writes the tag for compiled templates.
+func (b *BlockTag) MarshalNode(enc *liquid.NodeEncoder) {
+	enc.EncodeBlock(b.Block)
+	enc.WriteString(b.name)
+}
+
+// decodeBlockTag rebuilds a BlockTag written by MarshalNode.
+func decodeBlockTag(dec *liquid.NodeDecoder) (interface{}, error) {
+	tag := &BlockTag{Block: dec.DecodeBlock()}
+	tag.name = dec.ReadString()
+	return tag, dec.Err()
+}
+
+// BlockDrop is the "block" variable inside a layout block.
+type BlockDrop struct {
+	*liquid.Drop
+	name  string
+	super func() string
+}
+
+// Name returns the block name.
+func (d *BlockDrop) Name() string {
+	return d.name
+}
+
+// Super renders the definition of the block this one overrides, or returns
+// "" when there is none.
+func (d *BlockDrop) Super() string {
+	if d.super == nil {
+		return ""
+	}
+	return d.super()
+}
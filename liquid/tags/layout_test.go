@@ -0,0 +1,130 @@
+package tags
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Notifuse/liquidgo/liquid"
+)
+
+func TestLayoutTagOverridesBlocks(t *testing.T) {
+	env := newTestEnvironment(map[string]string{
+		"base": `<title>{% block title %}Shop{% endblock %}</title><main>{% block content %}empty{% endblock %}</main>`,
+	}, RegisterLayoutTags)
+
+	got := renderTestTemplate(t, env, `{% assign name = "Ann" %}{% layout 'base' %}ignored{% block content %}Hi {{ name }}{% endblock %}ignored`, nil)
+	if want := "<title>Shop</title><main>Hi Ann</main>"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestLayoutTagMultiLevelWithSuper(t *testing.T) {
+	env := newTestEnvironment(map[string]string{
+		"base":    `[{% block title %}Shop{% endblock %}|{% block content %}{% endblock %}]`,
+		"section": `{% layout 'base' %}{% block title %}{{ block.super }} - News{% endblock %}{% block content %}<aside>{% block sidebar %}links{% endblock %}</aside>{% endblock %}`,
+	}, RegisterLayoutTags)
+
+	got := renderTestTemplate(t, env, `{% layout 'section' %}{% block title %}{{ block.super }} - {{ block.name }}{% endblock %}{% block sidebar %}{{ block.super }}+more{% endblock %}`, nil)
+	if want := "[Shop - News - title|<aside>links+more</aside>]"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestBlockTagWithoutLayout(t *testing.T) {
+	env := newTestEnvironment(nil, RegisterLayoutTags)
+	got := renderTestTemplate(t, env, `{% block title %}Shop{{ block.super }}{% endblock %}`, nil)
+	if got != "Shop" {
+		t.Errorf("Render() = %q, want %q", got, "Shop")
+	}
+}
+
+func TestLayoutTagErrors(t *testing.T) {
+	env := newTestEnvironment(map[string]string{
+		"base":  `{% block content %}{% endblock %}`,
+		"loopa": `{% layout 'loopb' %}`,
+		"loopb": `{% layout 'loopa' %}`,
+		"dupes": `{% block content %}{% endblock %}{% block content %}{% endblock %}`,
+	}, RegisterLayoutTags)
+
+	tests := []struct {
+		name, source, want string
+	}{
+		{"missing block", "{% layout 'base' %}\n{% block sidebar %}x{% endblock %}", "Liquid syntax error (page line 2): Block 'sidebar' in 'page' is not defined by layout 'base'"},
+		{"cycle", `{% layout 'loopa' %}`, "Layout cycle: page -> loopa -> loopb -> loopa"},
+		{"self", `{% layout 'page' %}`, "Layout cycle: page -> page"},
+		{"duplicate", `{% layout 'dupes' %}`, "Block 'content' is defined more than once in 'dupes'"},
+		{"missing layout", `{% layout 'nope' %}`, "Liquid error (page line 1)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := parseTestTemplate(t, env, tt.source)
+			template.SetName("page")
+			if got := template.Render(nil, nil); !strings.Contains(got, tt.want) {
+				t.Errorf("Render() = %q, want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLayoutTagErrorsInUnnamedTemplate(t *testing.T) {
+	env := newTestEnvironment(map[string]string{
+		"base":  `{% block content %}{% endblock %}`,
+		"loopa": `{% layout 'loopb' %}`,
+		"loopb": `{% layout 'loopa' %}`,
+	}, RegisterLayoutTags)
+
+	tests := []struct {
+		name, source, want string
+	}{
+		{"missing block", "{% layout 'base' %}{% block sidebar %}x{% endblock %}", "Block 'sidebar' in '(template)' is not defined by layout 'base'"},
+		{"cycle", `{% layout 'loopa' %}`, "Layout cycle: (template) -> loopa -> loopb -> loopa"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderTestTemplate(t, env, tt.source, nil); !strings.Contains(got, tt.want) {
+				t.Errorf("Render() = %q, want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLayoutTagSyntaxErrors(t *testing.T) {
+	env := newTestEnvironment(nil, RegisterLayoutTags)
+	sources := map[string]string{
+		"variable name": `{% layout base %}`,
+		"nested":        `{% if true %}{% layout 'base' %}{% endif %}`,
+		"stray end tag": `{% layout 'base' %}{% endif %}`,
+		"block name":    `{% block %}{% endblock %}`,
+		"unclosed":      `{% block title %}`,
+	}
+	for name, source := range sources {
+		t.Run(name, func(t *testing.T) {
+			_, err := liquid.ParseTemplate(source, &liquid.TemplateOptions{Environment: env})
+			if _, ok := err.(*liquid.SyntaxError); !ok {
+				t.Errorf("Expected SyntaxError, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLayoutTagCompiledRoundTrip(t *testing.T) {
+	env := newTestEnvironment(map[string]string{
+		"base": `<{% block content %}default{% endblock %}>`,
+	}, RegisterLayoutTags)
+	options := &liquid.TemplateOptions{Environment: env}
+	template, err := liquid.ParseTemplate(`{% layout 'base' %}{% block content %}{{ block.super }}!{% endblock %}`, options)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	data, err := template.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+	loaded, err := liquid.LoadCompiled(data, options)
+	if err != nil {
+		t.Fatalf("LoadCompiled() error = %v", err)
+	}
+	if got := loaded.Render(nil, nil); got != "<default!>" {
+		t.Errorf("Render() = %q, want %q", got, "<default!>")
+	}
+}
@@ -0,0 +1,409 @@
+package tags
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/Notifuse/liquidgo/liquid"
+)
+
+var (
+	macroSyntax    = regexp.MustCompile(`^\s*([\w\-]+)\s*(?:\((.*)\))?\s*$`)
+	callSyntax     = regexp.MustCompile(`^\s*([\w\-]+(?:\.[\w\-]+)?)(.*)$`)
+	importSyntax   = regexp.MustCompile(`^\s*(` + liquid.QuotedString.String() + `)(?:\s+as\s+([\w\-]+))?\s*$`)
+	macroArgument  = regexp.MustCompile(`(?:(\w[\w\-]*)\s*:\s*)?(` + liquid.QuotedFragment.String() + `)`)
+	macroParamName = regexp.MustCompile(`^[\w\-]+$`)
+)
+
+// macrosRegister is the register holding the macros defined or imported during a render.
+const macrosRegister = "macros"
+
+// macroDefinition is a macro and the template defining it.
+type macroDefinition struct {
+	tag      *MacroTag
+	template string
+}
+
+// MacroParameter is a parameter of a macro.
+type MacroParameter struct {
+	Name string
+	// Default is the expression used when no argument is given, or nil.
+	Default interface{}
+}
+
+// MacroTag defines a reusable piece of markup:
+// {% macro button(label, url, color: 'blue') %}...{% endmacro %}.
+// The macro is defined when the tag renders and is then rendered with {% call %}.
+type MacroTag struct {
+	*liquid.Block
+	name       string
+	parameters []MacroParameter
+}
+
+// NewMacroTag creates a new MacroTag.
+func NewMacroTag(tagName, markup string, parseContext liquid.ParseContextInterface) (*MacroTag, error) {
+	matches := macroSyntax.FindStringSubmatch(markup)
+	if len(matches) == 0 {
+		return nil, liquid.NewSyntaxError("Syntax Error in 'macro' - Valid syntax: macro name(param, param: default)")
+	}
+
+	positional, keywords, err := parseMacroArguments(matches[2], parseContext)
+	if err != nil {
+		return nil, err
+	}
+	parameters := make([]MacroParameter, 0, len(positional)+len(keywords))
+	for _, param := range positional {
+		name, ok := param.(*liquid.VariableLookup)
+		if !ok || len(name.Lookups()) > 0 || !macroParamName.MatchString(fmt.Sprint(name.Name())) {
+			return nil, liquid.NewSyntaxError("Syntax Error in 'macro' - parameters must be names")
+		}
+		parameters = append(parameters, MacroParameter{Name: fmt.Sprint(name.Name())})
+	}
+	for _, keyword := range keywords {
+		parameters = append(parameters, MacroParameter{Name: keyword.name, Default: keyword.value})
+	}
+
+	return &MacroTag{
+		Block:      liquid.NewBlock(tagName, markup, parseContext),
+		name:       matches[1],
+		parameters: parameters,
+	}, nil
+}
+
+// Name returns the macro name.
+func (m *MacroTag) Name() string {
+	return m.name
+}
+
+// Parameters returns the macro parameters in declaration order.
+func (m *MacroTag) Parameters() []MacroParameter {
+	return m.parameters
+}
+
+// Blank returns true since defining a macro renders nothing.
+func (m *MacroTag) Blank() bool {
+	return true
+}
+
+// RenderToOutputBuffer defines the macro for the rest of the render.
+func (m *MacroTag) RenderToOutputBuffer(context liquid.TagContext, output *liquid.OutputBuffer) {
+	if ctx, ok := context.(*liquid.Context); ok {
+		contextMacros(ctx)[m.name] = &macroDefinition{tag: m, template: ctx.TemplateName()}
+	}
+}
+
+// ParseTreeChildren returns the default values and the body of the macro.
+func (m *MacroTag) ParseTreeChildren() []interface{} {
+	children := []interface{}{}
+	for _, param := range m.parameters {
+		children = append(children, param.Default)
+	}
+	return append(children, m.Nodelist()...)
+}
+
+// VariableScope reports the parameters, which are the only variables the body sees.
+func (m *MacroTag) VariableScope() *liquid.VariableScope {
+	scope := &liquid.VariableScope{Body: m.Nodelist()}
+	for _, param := range m.parameters {
+		scope.Locals = append(scope.Locals, liquid.ScopedVariable{Name: param.Name})
+	}
+	return scope
+}
+
+// render renders the macro body in an isolated subcontext of ctx, where only
+// the parameters are defined. Arguments are evaluated in ctx.
+func (m *MacroTag) render(ctx *liquid.Context, template string, args []interface{}, kwargs []macroKeyword, output *liquid.OutputBuffer) error {
+	if len(args) > len(m.parameters) {
+		return liquid.NewArgumentError(fmt.Sprintf("macro '%s' takes %d arguments (%d given)", m.name, len(m.parameters), len(args)))
+	}
+
+	values := make(map[string]interface{}, len(m.parameters))
+	for i, arg := range args {
+		values[m.parameters[i].Name] = ctx.Evaluate(arg)
+	}
+	for _, kwarg := range kwargs {
+		if !m.hasParameter(kwarg.name) {
+			return liquid.NewArgumentError(fmt.Sprintf("macro '%s' has no parameter '%s'", m.name, kwarg.name))
+		}
+		if _, ok := values[kwarg.name]; ok {
+			return liquid.NewArgumentError(fmt.Sprintf("macro '%s' got several values for parameter '%s'", m.name, kwarg.name))
+		}
+		values[kwarg.name] = ctx.Evaluate(kwarg.value)
+	}
+	for _, param := range m.parameters {
+		if _, ok := values[param.Name]; !ok && param.Default != nil {
+			values[param.Name] = ctx.Evaluate(param.Default)
+		}
+	}
+
+	inner := ctx.NewIsolatedSubcontext()
+	inner.SetTemplateName(template)
+	inner.SetPartial(true)
+	inner.Registers().Set(macrosRegister, contextMacros(ctx))
+	for _, param := range m.parameters {
+		inner.Set(param.Name, values[param.Name])
+	}
+	if body := m.Body(); body != nil {
+		body.RenderToOutputBuffer(inner, output)
+	}
+	return nil
+}
+
+func (m *MacroTag) hasParameter(name string) bool {
+	for _, param := range m.parameters {
+		if param.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalNode writes the tag for compiled templates.
+func (m *MacroTag) MarshalNode(enc *liquid.NodeEncoder) {
+	enc.EncodeBlock(m.Block)
+	enc.WriteString(m.name)
+	enc.WriteInt(len(m.parameters))
+	for _, param := range m.parameters {
+		enc.WriteString(param.Name)
+		enc.Encode(param.Default)
+	}
+}
+
+// decodeMacroTag rebuilds a MacroTag written by MarshalNode.
+func decodeMacroTag(dec *liquid.NodeDecoder) (interface{}, error) {
+	tag := &MacroTag{Block: dec.DecodeBlock()}
+	tag.name = dec.ReadString()
+	count := dec.ReadInt()
+	for i := 0; i < count && dec.Err() == nil; i++ {
+		param := MacroParameter{Name: dec.ReadString()}
+		param.Default = dec.Decode()
+		tag.parameters = append(tag.parameters, param)
+	}
+	return tag, dec.Err()
+}
+
+// CallTag renders a macro: {% call button 'Buy' product.url, color: 'red' %}.
+// Macros imported with an alias are called by their qualified name, e.g. forms.button.
+type CallTag struct {
+	*liquid.Tag
+	macroName string
+	args      []interface{}
+	kwargs    []macroKeyword
+}
+
+// macroKeyword is a keyword argument or a parameter with a default value.
+type macroKeyword struct {
+	name  string
+	value interface{}
+}
+
+// NewCallTag creates a new CallTag.
+func NewCallTag(tagName, markup string, parseContext liquid.ParseContextInterface) (*CallTag, error) {
+	matches := callSyntax.FindStringSubmatch(markup)
+	if len(matches) == 0 {
+		return nil, liquid.NewSyntaxError("Syntax Error in 'call' - Valid syntax: call macro [arguments]")
+	}
+	args, kwargs, err := parseMacroArguments(matches[2], parseContext)
+	if err != nil {
+		return nil, err
+	}
+	return &CallTag{
+		Tag:       liquid.NewTag(tagName, markup, parseContext),
+		macroName: matches[1],
+		args:      args,
+		kwargs:    kwargs,
+	}, nil
+}
+
+// MacroName returns the name of the called macro.
+func (c *CallTag) MacroName() string {
+	return c.macroName
+}
+
+// RenderToOutputBuffer renders the macro with the arguments of the tag.
+func (c *CallTag) RenderToOutputBuffer(context liquid.TagContext, output *liquid.OutputBuffer) {
+	ctx, ok := context.(*liquid.Context)
+	if !ok {
+		output.WriteString(context.HandleError(liquid.NewInternalError("context is not a liquid.Context"), c.LineNumber()))
+		return
+	}
+
+	definition := contextMacros(ctx)[c.macroName]
+	if definition == nil {
+		output.WriteString(context.HandleError(liquid.NewArgumentError("Unknown macro '"+c.macroName+"'"), c.LineNumber()))
+		return
+	}
+	if err := definition.tag.render(ctx, definition.template, c.args, c.kwargs, output); err != nil {
+		output.WriteString(context.HandleError(err, c.LineNumber()))
+	}
+}
+
+// ParseTreeChildren returns the argument expressions.
+func (c *CallTag) ParseTreeChildren() []interface{} {
+	children := append([]interface{}{}, c.args...)
+	for _, kwarg := range c.kwargs {
+		children = append(children, kwarg.value)
+	}
+	return children
+}
+
+// MarshalNode writes the tag for compiled templates.
+func (c *CallTag) MarshalNode(enc *liquid.NodeEncoder) {
+	enc.EncodeTag(c.Tag)
+	enc.WriteString(c.macroName)
+	enc.Encode(c.args)
+	enc.WriteInt(len(c.kwargs))
+	for _, kwarg := range c.kwargs {
+		enc.WriteString(kwarg.name)
+		enc.Encode(kwarg.value)
+	}
+}
+
+// decodeCallTag rebuilds a CallTag written by MarshalNode.
+func decodeCallTag(dec *liquid.NodeDecoder) (interface{}, error) {
+	tag := &CallTag{Tag: dec.DecodeTag()}
+	tag.macroName = dec.ReadString()
+	tag.args = dec.DecodeArray()
+	count := dec.ReadInt()
+	for i := 0; i < count && dec.Err() == nil; i++ {
+		kwarg := macroKeyword{name: dec.ReadString()}
+		kwarg.value = dec.Decode()
+		tag.kwargs = append(tag.kwargs, kwarg)
+	}
+	return tag, dec.Err()
+}
+
+// ImportTag defines the macros of another template, loaded through the file
+// system: {% import 'forms' %} or {% import 'forms' as forms %}, which
+// prefixes their names with "forms.".
+type ImportTag struct {
+	*liquid.Tag
+	templateName string
+	alias        string
+}
+
+// NewImportTag creates a new ImportTag.
+func NewImportTag(tagName, markup string, parseContext liquid.ParseContextInterface) (*ImportTag, error) {
+	matches := importSyntax.FindStringSubmatch(markup)
+	if len(matches) == 0 {
+		return nil, liquid.NewSyntaxError("Syntax Error in 'import' - Valid syntax: import '[template]' (as name)")
+	}
+	name, _ := parseContext.ParseExpression(matches[1]).(string)
+	return &ImportTag{
+		Tag:          liquid.NewTag(tagName, markup, parseContext),
+		templateName: name,
+		alias:        matches[2],
+	}, nil
+}
+
+// TemplateName returns the name of the imported template.
+func (i *ImportTag) TemplateName() string {
+	return i.templateName
+}
+
+// Alias returns the prefix of the imported macros, or "".
+func (i *ImportTag) Alias() string {
+	return i.alias
+}
+
+// Blank returns true since importing renders nothing.
+func (i *ImportTag) Blank() bool {
+	return true
+}
+
+// RenderToOutputBuffer defines the macros of the imported template. The
+// template itself is not rendered.
+func (i *ImportTag) RenderToOutputBuffer(context liquid.TagContext, output *liquid.OutputBuffer) {
+	ctx, ok := context.(*liquid.Context)
+	if !ok {
+		output.WriteString(context.HandleError(liquid.NewInternalError("context is not a liquid.Context"), i.LineNumber()))
+		return
+	}
+
+	partial, err := liquid.LoadPartial(i.templateName, ctx, i.ParseContext())
+	if err != nil {
+		output.WriteString(context.HandleError(err, i.LineNumber()))
+		return
+	}
+	template, ok := partial.(*liquid.Template)
+	if !ok || template.Root() == nil {
+		output.WriteString(context.HandleError(liquid.NewFileSystemError("partial is not a template"), i.LineNumber()))
+		return
+	}
+
+	name := template.Name()
+	if name == "" {
+		name = i.templateName
+	}
+	macros := contextMacros(ctx)
+	for _, macro := range collectMacroTags(template.Root()) {
+		qualified := macro.name
+		if i.alias != "" {
+			qualified = i.alias + "." + macro.name
+		}
+		macros[qualified] = &macroDefinition{tag: macro, template: name}
+	}
+}
+
+// VariableScope reports the imported template.
+func (i *ImportTag) VariableScope() *liquid.VariableScope {
+	return &liquid.VariableScope{Partial: &liquid.PartialReference{Name: i.templateName, Isolated: true}}
+}
+
+// MarshalNode writes the tag for compiled templates.
+func (i *ImportTag) MarshalNode(enc *liquid.NodeEncoder) {
+	enc.EncodeTag(i.Tag)
+	enc.WriteString(i.templateName)
+	enc.WriteString(i.alias)
+}
+
+// decodeImportTag rebuilds an ImportTag written by MarshalNode.
+func decodeImportTag(dec *liquid.NodeDecoder) (interface{}, error) {
+	tag := &ImportTag{Tag: dec.DecodeTag()}
+	tag.templateName = dec.ReadString()
+	tag.alias = dec.ReadString()
+	return tag, dec.Err()
+}
+
+// contextMacros returns the macros of the render, creating the register if needed.
+func contextMacros(ctx *liquid.Context) map[string]*macroDefinition {
+	registers := ctx.Registers()
+	macros, ok := registers.Get(macrosRegister).(map[string]*macroDefinition)
+	if !ok {
+		macros = make(map[string]*macroDefinition)
+		registers.Set(macrosRegister, macros)
+	}
+	return macros
+}
+
+// collectMacroTags returns the macros defined under node, not counting the
+// ones nested in other macros.
+func collectMacroTags(node interface{}) []*MacroTag {
+	var macros []*MacroTag
+	for _, child := range liquid.ForParseTreeVisitor(node, nil).Children() {
+		if macro, ok := child.(*MacroTag); ok {
+			macros = append(macros, macro)
+			continue
+		}
+		macros = append(macros, collectMacroTags(child)...)
+	}
+	return macros
+}
+
+// parseMacroArguments parses "a, b, key: value" into positional and keyword
+// expressions. Keyword arguments must follow the positional ones.
+func parseMacroArguments(markup string, parseContext liquid.ParseContextInterface) ([]interface{}, []macroKeyword, error) {
+	var args []interface{}
+	var kwargs []macroKeyword
+	for _, match := range macroArgument.FindAllStringSubmatch(markup, -1) {
+		if match[1] == "" {
+			if len(kwargs) > 0 {
+				return nil, nil, liquid.NewSyntaxError("Syntax Error - positional argument '" + match[2] + "' follows keyword arguments")
+			}
+			args = append(args, parseContext.ParseExpression(match[2]))
+			continue
+		}
+		kwargs = append(kwargs, macroKeyword{name: match[1], value: parseContext.ParseExpression(match[2])})
+	}
+	return args, kwargs, nil
+}
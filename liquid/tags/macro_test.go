@@ -0,0 +1,129 @@
+package tags
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Notifuse/liquidgo/liquid"
+)
+
+func TestNewMacroTag(t *testing.T) {
+	pc := liquid.NewParseContext(liquid.ParseContextOptions{})
+	tag, err := NewMacroTag("macro", "button(label, url, color: 'blue')", pc)
+	if err != nil {
+		t.Fatalf("NewMacroTag() error = %v", err)
+	}
+	if tag.Name() != "button" {
+		t.Errorf("Name() = %q, want button", tag.Name())
+	}
+	params := tag.Parameters()
+	if len(params) != 3 || params[0].Name != "label" || params[1].Name != "url" || params[2].Name != "color" || params[2].Default != "blue" {
+		t.Errorf("Parameters() = %+v", params)
+	}
+
+	if tag, err := NewMacroTag("macro", "divider", pc); err != nil || len(tag.Parameters()) != 0 {
+		t.Errorf("NewMacroTag(divider) = %+v, %v", tag, err)
+	}
+	for _, markup := range []string{"", "button(1)", "button(a.b)", "button(a: 1, b)"} {
+		if _, err := NewMacroTag("macro", markup, pc); err == nil {
+			t.Errorf("NewMacroTag(%q) expected error", markup)
+		}
+	}
+}
+
+func TestMacroCall(t *testing.T) {
+	env := newTestEnvironment(nil, RegisterMacroTags)
+	source := `{% macro button(label, url, color: 'blue') %}<a href="{{ url }}" class="{{ color }}">{{ label }}{{ secret }}</a>{% endmacro %}` +
+		`{% call button 'Buy' product.url %}|{% call button "Sell", "/sell", color: product.color %}|{% call button url: "/x", label: "X" %}`
+	assigns := map[string]interface{}{
+		"secret":  "leak",
+		"product": map[string]interface{}{"url": "/buy", "color": "red"},
+	}
+
+	want := `<a href="/buy" class="blue">Buy</a>|<a href="/sell" class="red">Sell</a>|<a href="/x" class="blue">X</a>`
+	if got := renderTestTemplate(t, env, source, assigns); got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestMacroCallsOtherMacros(t *testing.T) {
+	env := newTestEnvironment(nil, RegisterMacroTags)
+	source := `{% macro item(n) %}<li>{{ n }}</li>{% endmacro %}` +
+		`{% macro list(items) %}<ul>{% for n in items %}{% call item n %}{% endfor %}</ul>{% endmacro %}` +
+		`{% call list numbers %}`
+	assigns := map[string]interface{}{"numbers": []interface{}{1, 2}}
+
+	if got := renderTestTemplate(t, env, source, assigns); got != "<ul><li>1</li><li>2</li></ul>" {
+		t.Errorf("Render() = %q", got)
+	}
+}
+
+func TestMacroCallErrors(t *testing.T) {
+	env := newTestEnvironment(nil, RegisterMacroTags)
+	definition := `{% macro badge(text) %}{{ text }}{% endmacro %}`
+	tests := map[string]string{
+		`{% call missing %}`:              "Unknown macro 'missing'",
+		`{% call badge 1, 2 %}`:           "macro 'badge' takes 1 arguments (2 given)",
+		`{% call badge size: 1 %}`:        "macro 'badge' has no parameter 'size'",
+		`{% call badge "a", text: "b" %}`: "macro 'badge' got several values for parameter 'text'",
+	}
+	for source, want := range tests {
+		got := renderTestTemplate(t, env, definition+source, nil)
+		if !strings.Contains(got, want) {
+			t.Errorf("Render(%q) = %q, want it to contain %q", source, got, want)
+		}
+	}
+
+	if _, err := liquid.ParseTemplate(`{% call badge text: "a", "b" %}`, &liquid.TemplateOptions{Environment: env}); err == nil {
+		t.Error("Expected a syntax error for a positional argument after a keyword argument")
+	}
+}
+
+func TestMacroRecursionIsLimited(t *testing.T) {
+	env := newTestEnvironment(nil, RegisterMacroTags)
+	got := renderTestTemplate(t, env, `{% macro loop(n) %}{% call loop n %}{% endmacro %}{% call loop 1 %}`, nil)
+	if !strings.Contains(got, "Liquid error") {
+		t.Errorf("Render() = %q, want a stack level error", got)
+	}
+}
+
+func TestImportTag(t *testing.T) {
+	env := newTestEnvironment(map[string]string{
+		"forms": `ignored{% macro input(name, type: 'text') %}<input name="{{ name }}" type="{{ type }}">{% endmacro %}` +
+			`{% if false %}{% macro hidden(name) %}{% call input name, type: 'hidden' %}{% endmacro %}{% endif %}`,
+	}, RegisterMacroTags)
+
+	got := renderTestTemplate(t, env, `{% import 'forms' %}{% call input 'email' %}{% import 'forms' as f %}{% call f.hidden 'token' %}`, nil)
+	want := `<input name="email" type="text"><input name="token" type="hidden">`
+	if got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+
+	if got := renderTestTemplate(t, env, `{% import 'nope' %}`, nil); !strings.Contains(got, "Liquid error") {
+		t.Errorf("Render() = %q, want an error for a missing template", got)
+	}
+	if _, err := liquid.ParseTemplate(`{% import forms %}`, &liquid.TemplateOptions{Environment: env}); err == nil {
+		t.Error("Expected a syntax error for an unquoted template name")
+	}
+}
+
+func TestMacroTagsCompiledRoundTrip(t *testing.T) {
+	env := newTestEnvironment(map[string]string{"forms": `{% macro label(text) %}<label>{{ text }}</label>{% endmacro %}`}, RegisterMacroTags)
+	options := &liquid.TemplateOptions{Environment: env}
+	source := `{% macro greet(name, greeting: "Hi") %}{{ greeting }} {{ name }}{% endmacro %}{% call greet user %} {% import 'forms' as forms %}{% call forms.label 'x' %}`
+	template, err := liquid.ParseTemplate(source, options)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	data, err := template.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+	loaded, err := liquid.LoadCompiled(data, options)
+	if err != nil {
+		t.Fatalf("LoadCompiled() error = %v", err)
+	}
+	if got := loaded.Render(map[string]interface{}{"user": "Ann"}, nil); got != "Hi Ann <label>x</label>" {
+		t.Errorf("Render() = %q", got)
+	}
+}
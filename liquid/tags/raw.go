@@ -76,8 +76,8 @@ func (r *RawTag) Parse(tokenizer *liquid.Tokenizer) error {
 }
 
 // RenderToOutputBuffer renders the raw tag.
-func (r *RawTag) RenderToOutputBuffer(context liquid.TagContext, output *string) {
-	*output += r.body
+func (r *RawTag) RenderToOutputBuffer(context liquid.TagContext, output *liquid.OutputBuffer) {
+	output.WriteString(r.body)
 }
 
 // Nodelist returns the nodelist (just the body as a string).
@@ -89,3 +89,16 @@ func (r *RawTag) Nodelist() []interface{} {
 func (r *RawTag) Blank() bool {
 	return r.body == ""
 }
+
+// MarshalNode writes the tag for compiled templates.
+func (r *RawTag) MarshalNode(enc *liquid.NodeEncoder) {
+	enc.EncodeBlock(r.Block)
+	enc.WriteString(r.body)
+}
+
+// decodeRawTag rebuilds a RawTag written by MarshalNode.
+func decodeRawTag(dec *liquid.NodeDecoder) (interface{}, error) {
+	tag := &RawTag{Block: dec.DecodeBlock()}
+	tag.body = dec.ReadString()
+	return tag, dec.Err()
+}
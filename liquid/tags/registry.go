@@ -75,4 +75,92 @@ func RegisterStandardTags(env *liquid.Environment) {
 	env.RegisterTag("render", TagConstructor(func(tagName, markup string, parseContext liquid.ParseContextInterface) (interface{}, error) {
 		return NewRenderTag(tagName, markup, parseContext)
 	}))
+
+	registerStandardTagDecoders(env)
+}
+
+// RegisterLayoutTags registers the layout and block tags, which let templates
+// extend a layout loaded through the environment's file system.
+func RegisterLayoutTags(env *liquid.Environment) {
+	env.RegisterTag("layout", TagConstructor(func(tagName, markup string, parseContext liquid.ParseContextInterface) (interface{}, error) {
+		return NewLayoutTag(tagName, markup, parseContext)
+	}))
+	env.RegisterTag("block", TagConstructor(func(tagName, markup string, parseContext liquid.ParseContextInterface) (interface{}, error) {
+		return NewBlockTag(tagName, markup, parseContext)
+	}))
+	env.RegisterTagDecoder("layout", decodeLayoutTag)
+	env.RegisterTagDecoder("block", decodeBlockTag)
+}
+
+// RegisterMacroTags registers the macro, call and import tags, which define
+// reusable markup inline or in templates loaded through the file system.
+func RegisterMacroTags(env *liquid.Environment) {
+	env.RegisterTag("macro", TagConstructor(func(tagName, markup string, parseContext liquid.ParseContextInterface) (interface{}, error) {
+		return NewMacroTag(tagName, markup, parseContext)
+	}))
+	env.RegisterTag("call", TagConstructor(func(tagName, markup string, parseContext liquid.ParseContextInterface) (interface{}, error) {
+		return NewCallTag(tagName, markup, parseContext)
+	}))
+	env.RegisterTag("import", TagConstructor(func(tagName, markup string, parseContext liquid.ParseContextInterface) (interface{}, error) {
+		return NewImportTag(tagName, markup, parseContext)
+	}))
+	env.RegisterTagDecoder("macro", decodeMacroTag)
+	env.RegisterTagDecoder("call", decodeCallTag)
+	env.RegisterTagDecoder("import", decodeImportTag)
+}
+
+// RegisterComponentTags registers the component and slot tags, which render
+// a partial loaded through the file system with blocks of caller content.
+func RegisterComponentTags(env *liquid.Environment) {
+	env.RegisterTag("component", TagConstructor(func(tagName, markup string, parseContext liquid.ParseContextInterface) (interface{}, error) {
+		return NewComponentTag(tagName, markup, parseContext)
+	}))
+	env.RegisterTag("slot", TagConstructor(func(tagName, markup string, parseContext liquid.ParseContextInterface) (interface{}, error) {
+		return NewSlotTag(tagName, markup, parseContext)
+	}))
+	env.RegisterTagDecoder("component", decodeComponentTag)
+	env.RegisterTagDecoder("slot", decodeSlotTag)
+}
+
+// RegisterCacheTag registers the cache tag, which stores the output of its
+// body in the fragment store of the environment or of the render.
+func RegisterCacheTag(env *liquid.Environment) {
+	env.RegisterTag("cache", TagConstructor(func(tagName, markup string, parseContext liquid.ParseContextInterface) (interface{}, error) {
+		return NewCacheTag(tagName, markup, parseContext)
+	}))
+	env.RegisterTagDecoder("cache", decodeCacheTag)
+}
+
+// RegisterTryTag registers the try tag, which renders a rescue block instead
+// of the output of a body that raised an error.
+func RegisterTryTag(env *liquid.Environment) {
+	env.RegisterTag("try", TagConstructor(func(tagName, markup string, parseContext liquid.ParseContextInterface) (interface{}, error) {
+		return NewTryTag(tagName, markup, parseContext)
+	}))
+	env.RegisterTagDecoder("try", decodeTryTag)
+}
+
+// registerStandardTagDecoders registers the decoders LoadCompiled uses to
+// rebuild the standard tags from a compiled template.
+func registerStandardTagDecoders(env *liquid.Environment) {
+	env.RegisterTagDecoder("assign", decodeAssignTag)
+	env.RegisterTagDecoder("echo", decodeEchoTag)
+	env.RegisterTagDecoder("increment", decodeIncrementTag)
+	env.RegisterTagDecoder("decrement", decodeDecrementTag)
+	env.RegisterTagDecoder("break", decodeBreakTag)
+	env.RegisterTagDecoder("continue", decodeContinueTag)
+	env.RegisterTagDecoder("cycle", decodeCycleTag)
+	env.RegisterTagDecoder("comment", decodeCommentTag)
+	env.RegisterTagDecoder("raw", decodeRawTag)
+	env.RegisterTagDecoder("#", decodeInlineCommentTag)
+	env.RegisterTagDecoder("doc", decodeDocTag)
+	env.RegisterTagDecoder("capture", decodeCaptureTag)
+	env.RegisterTagDecoder("if", decodeIfTag)
+	env.RegisterTagDecoder("unless", decodeUnlessTag)
+	env.RegisterTagDecoder("for", decodeForTag)
+	env.RegisterTagDecoder("ifchanged", decodeIfchangedTag)
+	env.RegisterTagDecoder("case", decodeCaseTag)
+	env.RegisterTagDecoder("tablerow", decodeTableRowTag)
+	env.RegisterTagDecoder("include", decodeIncludeTag)
+	env.RegisterTagDecoder("render", decodeRenderTag)
 }
@@ -69,15 +69,7 @@ func (r *RenderTag) parseMarkup(markup string, parseContext liquid.ParseContextI
 	}
 
 	// Parse attributes
-	attributeMatches := liquid.TagAttributes.FindAllStringSubmatch(markup, -1)
-	for _, match := range attributeMatches {
-		if len(match) >= 3 {
-			key := strings.TrimSpace(match[1])
-			value := strings.TrimSpace(match[2])
-			// Let ParseExpression handle quoted strings correctly
-			r.attributes[key] = parseContext.ParseExpression(value)
-		}
-	}
+	r.attributes = parseTagAttributes(markup, parseContext)
 
 	return nil
 }
@@ -89,7 +81,7 @@ func (r *RenderTag) Parse(tokenizer *liquid.Tokenizer) error {
 }
 
 // RenderToOutputBuffer renders the render tag.
-func (r *RenderTag) RenderToOutputBuffer(context liquid.TagContext, output *string) {
+func (r *RenderTag) RenderToOutputBuffer(context liquid.TagContext, output *liquid.OutputBuffer) {
 	// Evaluate template name
 	template := context.Evaluate(r.templateNameExpr)
 
@@ -97,7 +89,7 @@ func (r *RenderTag) RenderToOutputBuffer(context liquid.TagContext, output *stri
 	ctx, ok := context.(*liquid.Context)
 	if !ok {
 		errorMsg := context.HandleError(liquid.NewInternalError("context is not a liquid.Context"), r.LineNumber())
-		*output += errorMsg
+		output.WriteString(errorMsg)
 		return
 	}
 
@@ -109,7 +101,7 @@ func (r *RenderTag) RenderToOutputBuffer(context liquid.TagContext, output *stri
 	templateNameStr, ok := template.(string)
 	if !ok {
 		errorMsg := context.HandleError(liquid.NewArgumentError("render tag requires a string template name"), r.LineNumber())
-		*output += errorMsg
+		output.WriteString(errorMsg)
 		return
 	}
 
@@ -117,13 +109,13 @@ func (r *RenderTag) RenderToOutputBuffer(context liquid.TagContext, output *stri
 	partialInterface, err := liquid.LoadPartial(templateNameStr, context, r.ParseContext())
 	if err != nil {
 		errorMsg := context.HandleError(err, r.LineNumber())
-		*output += errorMsg
+		output.WriteString(errorMsg)
 		return
 	}
 	partial, ok = partialInterface.(*liquid.Template)
 	if !ok {
 		errorMsg := context.HandleError(liquid.NewFileSystemError("partial is not a template"), r.LineNumber())
-		*output += errorMsg
+		output.WriteString(errorMsg)
 		return
 	}
 	templateName = partial.Name()
@@ -239,3 +231,40 @@ func (r *RenderTag) Attributes() map[string]interface{} {
 func (r *RenderTag) IsForLoop() bool {
 	return r.isForLoop
 }
+
+// ParseTreeChildren returns the template name, variable and attribute expressions.
+func (r *RenderTag) ParseTreeChildren() []interface{} {
+	children := []interface{}{r.templateNameExpr, r.variableNameExpr}
+	return append(children, sortedAttributeValues(r.attributes)...)
+}
+
+// VariableScope reports the rendered partial, which only sees the variables
+// passed to it.
+func (r *RenderTag) VariableScope() *liquid.VariableScope {
+	partial := partialReference(r.templateNameExpr, r.variableNameExpr, r.aliasName, r.attributes, r.isForLoop, true)
+	if r.isForLoop {
+		partial.Variables = append(partial.Variables, liquid.ScopedVariable{Name: "forloop"})
+	}
+	return &liquid.VariableScope{Partial: partial}
+}
+
+// MarshalNode writes the tag for compiled templates.
+func (r *RenderTag) MarshalNode(enc *liquid.NodeEncoder) {
+	enc.EncodeTag(r.Tag)
+	enc.Encode(r.templateNameExpr)
+	enc.Encode(r.variableNameExpr)
+	enc.WriteString(r.aliasName)
+	enc.Encode(r.attributes)
+	enc.WriteBool(r.isForLoop)
+}
+
+// decodeRenderTag rebuilds a RenderTag written by MarshalNode.
+func decodeRenderTag(dec *liquid.NodeDecoder) (interface{}, error) {
+	tag := &RenderTag{Tag: dec.DecodeTag()}
+	tag.templateNameExpr = dec.Decode()
+	tag.variableNameExpr = dec.Decode()
+	tag.aliasName = dec.ReadString()
+	tag.attributes = dec.DecodeMap()
+	tag.isForLoop = dec.ReadBool()
+	return tag, dec.Err()
+}
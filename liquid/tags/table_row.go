@@ -104,11 +104,11 @@ func (t *TableRowTag) Attributes() map[string]interface{} {
 }
 
 // RenderToOutputBuffer renders the table_row tag.
-func (t *TableRowTag) RenderToOutputBuffer(context liquid.TagContext, output *string) {
+func (t *TableRowTag) RenderToOutputBuffer(context liquid.TagContext, output *liquid.OutputBuffer) {
 	// Evaluate collection
 	collection := context.Evaluate(t.collectionName)
 	if collection == nil {
-		*output += "<tr class=\"row1\">\n</tr>\n"
+		output.WriteString("<tr class=\"row1\">\n</tr>\n")
 		return
 	}
 
@@ -120,7 +120,7 @@ func (t *TableRowTag) RenderToOutputBuffer(context liquid.TagContext, output *st
 			offsetInt, err := liquid.ToInteger(offsetValue)
 			if err != nil {
 				errorMsg := context.HandleError(liquid.NewArgumentError("invalid integer"), nil)
-				*output += errorMsg
+				output.WriteString(errorMsg)
 				return
 			}
 			from = offsetInt
@@ -135,7 +135,7 @@ func (t *TableRowTag) RenderToOutputBuffer(context liquid.TagContext, output *st
 			limitInt, err := liquid.ToInteger(limitValue)
 			if err != nil {
 				errorMsg := context.HandleError(liquid.NewArgumentError("invalid integer"), nil)
-				*output += errorMsg
+				output.WriteString(errorMsg)
 				return
 			}
 			toVal := from + limitInt
@@ -155,7 +155,7 @@ func (t *TableRowTag) RenderToOutputBuffer(context liquid.TagContext, output *st
 			colsInt, err := liquid.ToInteger(colsValue)
 			if err != nil {
 				errorMsg := context.HandleError(liquid.NewArgumentError("invalid integer"), nil)
-				*output += errorMsg
+				output.WriteString(errorMsg)
 				return
 			}
 			cols = colsInt
@@ -167,7 +167,7 @@ func (t *TableRowTag) RenderToOutputBuffer(context liquid.TagContext, output *st
 	}
 
 	// Start first row
-	*output += "<tr class=\"row1\">\n"
+	output.WriteString("<tr class=\"row1\">\n")
 
 	// Get underlying Context for Stack
 	ctx := context.Context().(*liquid.Context)
@@ -180,17 +180,20 @@ func (t *TableRowTag) RenderToOutputBuffer(context liquid.TagContext, output *st
 
 		// Iterate over segment
 		for _, item := range segment {
+			// Stop if the render has been canceled
+			ctx.CheckCanceled(t.LineNumber())
+
 			// Set variable
 			ctx.Set(t.variableName, item)
 
 			// Output <td> tag
-			*output += fmt.Sprintf("<td class=\"col%d\">", tablerowloop.Col())
+			output.WriteString(fmt.Sprintf("<td class=\"col%d\">", tablerowloop.Col()))
 
 			// Render block body (equivalent to Ruby's 'super')
 			t.Block.RenderToOutputBuffer(context, output)
 
 			// Close </td>
-			*output += "</td>"
+			output.WriteString("</td>")
 
 			// Handle interrupts
 			if ctx.Interrupt() {
@@ -202,7 +205,7 @@ func (t *TableRowTag) RenderToOutputBuffer(context liquid.TagContext, output *st
 
 			// Check if we need to close row and start new one
 			if tablerowloop.ColLast() && !tablerowloop.Last() {
-				*output += fmt.Sprintf("</tr>\n<tr class=\"row%d\">", tablerowloop.Row()+1)
+				output.WriteString(fmt.Sprintf("</tr>\n<tr class=\"row%d\">", tablerowloop.Row()+1))
 			}
 
 			// Increment loop
@@ -211,5 +214,40 @@ func (t *TableRowTag) RenderToOutputBuffer(context liquid.TagContext, output *st
 	})
 
 	// Close last row
-	*output += "</tr>\n"
+	output.WriteString("</tr>\n")
+}
+
+// ParseTreeChildren returns the loop expressions followed by the body.
+func (t *TableRowTag) ParseTreeChildren() []interface{} {
+	children := []interface{}{t.collectionName}
+	children = append(children, sortedAttributeValues(t.attributes)...)
+	return append(children, t.Body())
+}
+
+// VariableScope reports the loop variable and tablerowloop, visible in the body only.
+func (t *TableRowTag) VariableScope() *liquid.VariableScope {
+	return &liquid.VariableScope{
+		Locals: []liquid.ScopedVariable{
+			{Name: t.variableName, Source: t.collectionName, Iterates: true},
+			{Name: "tablerowloop"},
+		},
+		Body: []interface{}{t.Body()},
+	}
+}
+
+// MarshalNode writes the tag for compiled templates.
+func (t *TableRowTag) MarshalNode(enc *liquid.NodeEncoder) {
+	enc.EncodeBlock(t.Block)
+	enc.WriteString(t.variableName)
+	enc.Encode(t.collectionName)
+	enc.Encode(t.attributes)
+}
+
+// decodeTableRowTag rebuilds a TableRowTag written by MarshalNode.
+func decodeTableRowTag(dec *liquid.NodeDecoder) (interface{}, error) {
+	tag := &TableRowTag{Block: dec.DecodeBlock()}
+	tag.variableName = dec.ReadString()
+	tag.collectionName = dec.Decode()
+	tag.attributes = dec.DecodeMap()
+	return tag, dec.Err()
 }
@@ -0,0 +1,251 @@
+package tags
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/Notifuse/liquidgo/liquid"
+)
+
+var rescueSyntax = regexp.MustCompile(`^\s*(` + liquid.VariableSegment.String() + `*)\s*$`)
+
+// TryTag renders a fallback when its body raises an error:
+// {% try %}...{% rescue err %}fallback{% endtry %}.
+// The output of the failed body is discarded, so no error message reaches the
+// output even in lax mode, and the error is exposed to the rescue block as an
+// ErrorDrop. The error is still handled by the context, so it is listed in
+// Context.Errors(). Cancellation and resource limit errors are not rescued.
+type TryTag struct {
+	*liquid.Block
+	body         *liquid.BlockBody
+	rescueBody   *liquid.BlockBody // nil without rescue
+	variableName string            // Name of the rescued error or ""
+}
+
+// NewTryTag creates a new TryTag.
+func NewTryTag(tagName, markup string, parseContext liquid.ParseContextInterface) (*TryTag, error) {
+	if strings.TrimSpace(markup) != "" {
+		return nil, liquid.NewSyntaxError("Syntax Error in 'try' - Valid syntax: try")
+	}
+	return &TryTag{
+		Block: liquid.NewBlock(tagName, markup, parseContext),
+		body:  liquid.NewBlockBody(),
+	}, nil
+}
+
+// Parse parses the body and the optional rescue block.
+func (t *TryTag) Parse(tokenizer *liquid.Tokenizer) error {
+	parseContext := t.ParseContext()
+	if parseContext.Depth() >= 100 {
+		return liquid.NewStackLevelError("Nesting too deep")
+	}
+	parseContext.IncrementDepth()
+	defer parseContext.DecrementDepth()
+
+	body := t.body
+	for body != nil {
+		current := body
+		body = nil
+		unknownTagHandler := func(tagName, markup string) bool {
+			switch {
+			case tagName == t.BlockDelimiter():
+				return false
+			case tagName == "":
+				panic(liquid.NewSyntaxError("'" + t.BlockName() + "' tag was never closed"))
+			case tagName == "rescue" && t.rescueBody == nil:
+				matches := rescueSyntax.FindStringSubmatch(markup)
+				if len(matches) == 0 {
+					panic(liquid.NewSyntaxError("Syntax Error in 'rescue' - Valid syntax: rescue [variable]"))
+				}
+				t.variableName = matches[1]
+				t.rescueBody = liquid.NewBlockBody()
+				body = t.rescueBody
+				return false
+			case tagName == "rescue":
+				panic(liquid.NewSyntaxError("'try' tag expects a single 'rescue' tag"))
+			}
+			panic(t.UnknownTag(tagName, markup, tokenizer))
+		}
+		if err := current.Parse(tokenizer, parseContext, unknownTagHandler); err != nil {
+			return err
+		}
+	}
+
+	if t.Blank() {
+		t.body.RemoveBlankStrings()
+		if t.rescueBody != nil {
+			t.rescueBody.RemoveBlankStrings()
+		}
+	}
+	return nil
+}
+
+// Nodelist returns the body and the rescue block.
+func (t *TryTag) Nodelist() []interface{} {
+	if t.rescueBody != nil {
+		return []interface{}{t.body, t.rescueBody}
+	}
+	return []interface{}{t.body}
+}
+
+// VariableName returns the name of the rescued error variable, or "".
+func (t *TryTag) VariableName() string {
+	return t.variableName
+}
+
+// Blank returns true when both the body and the rescue block are blank.
+func (t *TryTag) Blank() bool {
+	return t.body.Blank() && (t.rescueBody == nil || t.rescueBody.Blank())
+}
+
+// tryRescue carries the error handled by the context out of a try body.
+type tryRescue struct {
+	err error
+}
+
+// RenderToOutputBuffer renders the body, or the rescue block if the body
+// raised an error.
+func (t *TryTag) RenderToOutputBuffer(context liquid.TagContext, output *liquid.OutputBuffer) {
+	ctx, ok := context.(*liquid.Context)
+	if !ok {
+		t.body.RenderToOutputBuffer(context, output)
+		return
+	}
+
+	bodyOutput := liquid.NewOutputBuffer()
+	rescued := t.renderBody(ctx, bodyOutput)
+	if rescued == nil {
+		output.WriteString(bodyOutput.String())
+		return
+	}
+	if t.rescueBody == nil {
+		return
+	}
+	if t.variableName == "" {
+		t.rescueBody.RenderToOutputBuffer(ctx, output)
+		return
+	}
+	ctx.Stack(map[string]interface{}{t.variableName: NewErrorDrop(rescued)}, func() {
+		t.rescueBody.RenderToOutputBuffer(ctx, output)
+	})
+}
+
+// renderBody renders the body with an exception renderer that stops it at
+// the first error, and returns that error.
+func (t *TryTag) renderBody(ctx *liquid.Context, output *liquid.OutputBuffer) (rescued error) {
+	previous := ctx.ExceptionRenderer()
+	ctx.SetExceptionRenderer(func(err error) interface{} {
+		switch err.(type) {
+		case *liquid.MemoryError, *liquid.CanceledError:
+			return previous(err)
+		}
+		panic(&tryRescue{err: err})
+	})
+	defer func() {
+		ctx.SetExceptionRenderer(previous)
+		if r := recover(); r != nil {
+			rescue, ok := r.(*tryRescue)
+			if !ok {
+				panic(r)
+			}
+			rescued = rescue.err
+		}
+	}()
+
+	t.body.RenderToOutputBuffer(ctx, output)
+	return nil
+}
+
+// ParseTreeChildren returns the body and the rescue block.
+func (t *TryTag) ParseTreeChildren() []interface{} {
+	return []interface{}{t.body, t.rescueBody}
+}
+
+// VariableScope reports the rescued error, visible in the rescue block only.
+func (t *TryTag) VariableScope() *liquid.VariableScope {
+	if t.variableName == "" {
+		return &liquid.VariableScope{}
+	}
+	return &liquid.VariableScope{
+		Locals: []liquid.ScopedVariable{{Name: t.variableName}},
+		Body:   []interface{}{t.rescueBody},
+	}
+}
+
+// MarshalNode writes the tag for compiled templates.
+func (t *TryTag) MarshalNode(enc *liquid.NodeEncoder) {
+	enc.EncodeBlock(t.Block)
+	enc.Encode(t.body)
+	enc.Encode(t.rescueBody)
+	enc.WriteString(t.variableName)
+}
+
+// decodeTryTag rebuilds a TryTag written by MarshalNode.
+func decodeTryTag(dec *liquid.NodeDecoder) (interface{}, error) {
+	tag := &TryTag{Block: dec.DecodeBlock()}
+	tag.body = dec.DecodeBlockBody()
+	tag.rescueBody = dec.DecodeBlockBody()
+	tag.variableName = dec.ReadString()
+	return tag, dec.Err()
+}
+
+// ErrorDrop is the error rescued by a try tag.
+type ErrorDrop struct {
+	*liquid.Drop
+	err error
+}
+
+// NewErrorDrop creates an ErrorDrop for err.
+func NewErrorDrop(err error) *ErrorDrop {
+	return &ErrorDrop{Drop: liquid.NewDrop(), err: err}
+}
+
+// Message returns the error message, without the "Liquid error" prefix.
+func (d *ErrorDrop) Message() string {
+	if e := liquidErrorDetails(d.err); e != nil {
+		return e.Message
+	}
+	return d.err.Error()
+}
+
+// Type returns the name of the error type, e.g. "ArgumentError".
+func (d *ErrorDrop) Type() string {
+	t := reflect.TypeOf(d.err)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// LineNumber returns the line of the error, or nil when unknown.
+func (d *ErrorDrop) LineNumber() interface{} {
+	if e := liquidErrorDetails(d.err); e != nil && e.LineNumber != nil {
+		return *e.LineNumber
+	}
+	return nil
+}
+
+// TemplateName returns the name of the template that raised the error.
+func (d *ErrorDrop) TemplateName() string {
+	if e := liquidErrorDetails(d.err); e != nil {
+		return e.TemplateName
+	}
+	return ""
+}
+
+// String returns the full error message, as rendered in lax mode.
+func (d *ErrorDrop) String() string {
+	return d.err.Error()
+}
+
+// liquidErrorDetails returns the details of a Liquid error, or nil.
+func liquidErrorDetails(err error) *liquid.Error {
+	switch e := err.(type) {
+	case liquid.LiquidError:
+		return e.GetError()
+	case *liquid.Error:
+		return e
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package tags
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Notifuse/liquidgo/liquid"
+)
+
+func TestTryTagRescuesErrors(t *testing.T) {
+	env := newTestEnvironment(nil, RegisterTryTag)
+	strict := &liquid.RenderOptions{StrictFilters: true}
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{`a{% try %}b{{ 'x' | nope }}c{% rescue err %}[{{ err.type }}|{{ err.message }}|{{ err.line_number }}]{% endtry %}d`, "a[UndefinedFilter|undefined filter nope|1]d"},
+		{"{% try %}\n{% include 'x' %}{% rescue %}fallback{% endtry %}", "fallback"},
+		{`{% try %}{{ 'x' | nope }}{% endtry %}`, ""},
+		{`{% try %}ok{% rescue %}fallback{% endtry %}`, "ok"},
+		{`{% try %}{% try %}{{ 'x' | nope }}{% rescue %}inner{% endtry %}{{ 'x' | nope }}{% rescue e %}outer{% endtry %}`, "outer"},
+		{`{% try %}{{ 'x' | nope }}{% rescue e %}{{ e }}{% endtry %}`, "Liquid error (line 1): undefined filter nope"},
+	}
+	for _, tt := range tests {
+		template := parseTestTemplate(t, env, tt.source)
+		if got := template.Render(nil, strict); got != tt.want {
+			t.Errorf("Render(%q) = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestTryTagRecordsErrors(t *testing.T) {
+	env := newTestEnvironment(nil, RegisterTryTag)
+	template := parseTestTemplate(t, env, `{% try %}{% include 'a' %}{% rescue %}fallback{% endtry %}{% try %}{% include 'b' %}{% endtry %}`)
+
+	result, err := template.Execute(nil, &liquid.RenderOptions{RethrowErrors: true})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result.Output != "fallback" {
+		t.Errorf("Output = %q, want fallback", result.Output)
+	}
+	if len(result.Errors) != 2 || !strings.Contains(result.Errors[0].Error(), "does not allow includes") {
+		t.Errorf("Errors = %v, want the two rescued errors", result.Errors)
+	}
+}
+
+func TestTryTagRescuesPartialErrors(t *testing.T) {
+	env := newTestEnvironment(map[string]string{"broken": `before{% include 'missing' %}after`}, RegisterTryTag)
+	template := parseTestTemplate(t, env, `{% try %}{% render 'broken' %}{% rescue err %}{{ err.template_name }}{% endtry %}`)
+	if got := template.Render(nil, nil); got != "broken" {
+		t.Errorf("Render() = %q, want broken", got)
+	}
+}
+
+func TestTryTagDoesNotRescueResourceLimits(t *testing.T) {
+	env := newTestEnvironment(nil, RegisterTryTag)
+	template := parseTestTemplate(t, env, `{% try %}0123456789{% rescue %}fallback{% endtry %}`)
+	limit := 5
+	template.SetResourceLimits(liquid.NewResourceLimits(liquid.ResourceLimitsConfig{RenderLengthLimit: &limit}))
+	if got := template.Render(nil, nil); !strings.Contains(got, "Memory limits exceeded") {
+		t.Errorf("Render() = %q, want a memory limit error", got)
+	}
+}
+
+func TestTryTagSyntaxErrors(t *testing.T) {
+	env := newTestEnvironment(nil, RegisterTryTag)
+	for _, source := range []string{
+		`{% try x %}{% endtry %}`,
+		`{% try %}`,
+		`{% try %}{% rescue a b %}{% endtry %}`,
+		`{% try %}{% rescue %}{% rescue %}{% endtry %}`,
+		`{% try %}{% else %}{% endtry %}`,
+	} {
+		if _, err := liquid.ParseTemplate(source, &liquid.TemplateOptions{Environment: env}); err == nil {
+			t.Errorf("ParseTemplate(%q) expected error", source)
+		}
+	}
+}
+
+func TestTryTagCompiledRoundTrip(t *testing.T) {
+	env := newTestEnvironment(nil, RegisterTryTag)
+	options := &liquid.TemplateOptions{Environment: env}
+	template, err := liquid.ParseTemplate(`{% try %}{{ 'x' | nope }}{% rescue err %}{{ err.type }}{% endtry %}`, options)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	data, err := template.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() error = %v", err)
+	}
+	loaded, err := liquid.LoadCompiled(data, options)
+	if err != nil {
+		t.Fatalf("LoadCompiled() error = %v", err)
+	}
+	if got := loaded.Render(nil, &liquid.RenderOptions{StrictFilters: true}); got != "UndefinedFilter" {
+		t.Errorf("Render() = %q", got)
+	}
+}
@@ -36,7 +36,7 @@ func NewUnlessTag(tagName, markup string, parseContext liquid.ParseContextInterf
 }
 
 // RenderToOutputBuffer renders the unless tag (negated if).
-func (u *UnlessTag) RenderToOutputBuffer(context liquid.TagContext, output *string) {
+func (u *UnlessTag) RenderToOutputBuffer(context liquid.TagContext, output *liquid.OutputBuffer) {
 	// Get the underlying Context which implements ConditionContext
 	ctx := context.Context().(*liquid.Context)
 
@@ -47,7 +47,7 @@ func (u *UnlessTag) RenderToOutputBuffer(context liquid.TagContext, output *stri
 		if err != nil {
 			// Handle error
 			errorMsg := context.HandleError(err, nil)
-			*output += errorMsg
+			output.WriteString(errorMsg)
 			return
 		}
 
@@ -74,3 +74,12 @@ func (u *UnlessTag) RenderToOutputBuffer(context liquid.TagContext, output *stri
 		}
 	}
 }
+
+// decodeUnlessTag rebuilds an UnlessTag written by MarshalNode.
+func decodeUnlessTag(dec *liquid.NodeDecoder) (interface{}, error) {
+	ifTag, err := decodeIfTag(dec)
+	if err != nil {
+		return nil, err
+	}
+	return &UnlessTag{IfTag: ifTag.(*IfTag)}, nil
+}
@@ -1,6 +1,8 @@
 package liquid
 
 import (
+	"context"
+	"io"
 	"sync"
 	"unicode/utf8"
 )
@@ -265,129 +267,255 @@ func (t *Template) Errors() []error {
 //   - Filters: array with local filters
 //   - Registers: hash with register variables. Those can be accessed from
 //     filters and tags and might be useful to integrate liquid more with its host application
-func (t *Template) Render(assigns interface{}, options *RenderOptions) (output string) {
+func (t *Template) Render(assigns interface{}, options *RenderOptions) string {
+	return t.renderString(nil, assigns, options)
+}
+
+// RenderTo renders the template with the given assigns directly to w.
+// Output is streamed through a buffered writer as nodes render instead of
+// being built up in memory. Render errors are handled exactly like Render;
+// the returned error only reports failures of w itself.
+func (t *Template) RenderTo(w io.Writer, assigns interface{}, options *RenderOptions) error {
 	if t.root == nil {
-		return ""
+		return nil
 	}
 
-	context := t.buildContext(assigns, options)
+	output := NewStreamingOutputBuffer(w)
+	t.render(nil, assigns, options, output)
+	return output.Flush()
+}
 
-	// Track whether we should merge back state (only when we create the context, not when user passes one)
-	_, userProvidedContext := assigns.(*Context)
+// RenderContext renders the template like Render, but stops as soon as ctx
+// is canceled or its deadline passes. Cancellation is checked between nodes,
+// on every for and tablerow iteration and before every filter call.
+//
+// A canceled render is reported as a CanceledError in Errors(), carrying the
+// line number and template name where rendering stopped. Drops and filters
+// can reach ctx through (*Context).GoContext.
+func (t *Template) RenderContext(ctx context.Context, assigns interface{}, options *RenderOptions) string {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return t.renderString(ctx, assigns, options)
+}
 
-	// Create a cloned ResourceLimits for this render to avoid race conditions
-	// when the same template is rendered concurrently
-	if !userProvidedContext {
-		if ctx, ok := context.(*Context); ok {
-			ctx.SetResourceLimits(cloneResourceLimits(t.resourceLimits))
+// Execute renders the template with the given assigns and returns the outcome
+// of this render only. Unlike Render, it leaves the template untouched: errors,
+// final assigns and resource usage are reported in the RenderResult instead of
+// being stored on the template, so concurrent renders don't see each other's state.
+//
+// The returned error is non-nil when the render runs in rethrow mode
+// (RenderOptions.RethrowErrors or after RenderBang) and fails, or when
+// StrictVariables or StrictFilters is set and the render recorded an error.
+// It is the first error of the render; all of them are in RenderResult.Errors.
+//
+// When assigns is a *Context, the options apply to it for this render only.
+// RenderOptions.Output is ignored.
+func (t *Template) Execute(assigns interface{}, options *RenderOptions) (result *RenderResult, err error) {
+	result = &RenderResult{Assigns: map[string]interface{}{}}
+	if t.root == nil {
+		return result, nil
+	}
+
+	if caller, ok := assigns.(*Context); ok {
+		// Leave the caller's context as it was once this render is done
+		defer saveContextOptions(caller, options).restore(caller)
+		if t.rethrowErrors {
+			caller.SetExceptionRenderer(func(err error) interface{} {
+				panic(err)
+			})
 		}
 	}
+	ctx := t.prepareContext(nil, assigns, options, true)
+	if t.profiling && ctx.Profiler() == nil {
+		ctx.SetProfiler(NewProfiler())
+	}
 
-	// Reset resource usage for this render
-	context.ResourceLimits().Reset()
+	output := NewOutputBuffer()
+	func() {
+		// In rethrow mode the first error escapes the render as a panic
+		defer func() {
+			if r := recover(); r != nil {
+				e, ok := r.(error)
+				if !ok {
+					panic(r)
+				}
+				err = e
+			}
+		}()
+		t.renderRecovering(ctx, output)
+	}()
 
-	// Handle profiling
-	if t.profiling {
-		if ctx, ok := context.(*Context); ok && ctx.Profiler() == nil {
-			t.profiler = NewProfiler()
-			ctx.SetProfiler(t.profiler)
+	t.mu.Lock()
+	result.Warnings = append(result.Warnings, t.warnings...)
+	t.mu.Unlock()
+	result.Warnings = append(result.Warnings, ctx.Warnings()...)
+	result.Output = output.String()
+	result.Errors = uniqueErrors(ctx.Errors())
+	result.Assigns = finalAssigns(ctx)
+	result.Profiler = ctx.Profiler()
+	if rl := ctx.ResourceLimits(); rl != nil {
+		result.ResourceUsage = ResourceUsage{
+			RenderScore:  rl.RenderScore(),
+			AssignScore:  rl.AssignScore(),
+			RenderLength: output.Len(),
+			LimitReached: rl.Reached(),
 		}
 	}
 
-	// Cast to *Context to access TemplateName
-	if ctx, ok := context.(*Context); ok {
-		if ctx.TemplateName() == "" {
-			ctx.SetTemplateName(t.name)
-		}
+	if err == nil && len(result.Errors) > 0 && (ctx.StrictVariables() || ctx.StrictFilters()) {
+		err = result.Errors[0]
 	}
+	return result, err
+}
 
+// renderString renders the template in memory and returns the output.
+func (t *Template) renderString(goCtx context.Context, assigns interface{}, options *RenderOptions) string {
+	if t.root == nil {
+		return ""
+	}
+
+	output := NewOutputBuffer()
 	// Use output from options if provided
 	if options != nil && options.Output != nil {
-		output = *options.Output
+		output.WriteString(*options.Output)
+	}
+
+	t.render(goCtx, assigns, options, output)
+
+	// Update output in options if provided
+	if options != nil && options.Output != nil {
+		*options.Output = output.String()
+	}
+	return output.String()
+}
+
+// render renders the template into output, recovering from render errors.
+// goCtx, when non-nil, replaces the context.Context of the render context.
+// Errors, instance assigns and resource usage are merged back into the template.
+func (t *Template) render(goCtx context.Context, assigns interface{}, options *RenderOptions, output *OutputBuffer) {
+	ctx := t.prepareContext(goCtx, assigns, options, false)
+
+	// Track whether we should merge back state (only when we create the context, not when user passes one)
+	_, userProvidedContext := assigns.(*Context)
+
+	// Handle profiling
+	if t.profiling && ctx.Profiler() == nil {
+		t.profiler = NewProfiler()
+		ctx.SetProfiler(t.profiler)
+	}
+
+	defer func() {
+		// Update template state with mutex protection for thread-safe concurrent rendering
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		// Always capture errors from the render
+		t.errors = ctx.Errors()
+		// Only merge back instance assigns and resource limits when we created the context,
+		// not when user passed their own Context
+		if !userProvidedContext {
+			// Merge back instance assigns from the render scope to persist across renders
+			for k, v := range finalAssigns(ctx) {
+				t.instanceAssigns[k] = v
+			}
+			// Update template's resource limits from context's resource limits
+			if ctx.ResourceLimits() != nil && t.resourceLimits != nil {
+				ctxRL := ctx.ResourceLimits()
+				t.resourceLimits.assignScore = ctxRL.AssignScore()
+				t.resourceLimits.renderScore = ctxRL.RenderScore()
+				t.resourceLimits.reachedLimit = ctxRL.Reached()
+			}
+		}
+	}()
+
+	t.renderRecovering(ctx, output)
+}
+
+// prepareContext builds the Context for a render and resets its resource usage.
+func (t *Template) prepareContext(goCtx context.Context, assigns interface{}, options *RenderOptions, execute bool) *Context {
+	ctx := t.newContext(assigns, options, execute)
+	if goCtx != nil {
+		ctx.SetGoContext(goCtx)
+	}
+
+	// Create a cloned ResourceLimits for this render to avoid race conditions
+	// when the same template is rendered concurrently
+	if _, userProvidedContext := assigns.(*Context); !userProvidedContext {
+		ctx.SetResourceLimits(cloneResourceLimits(t.resourceLimits))
 	}
 
+	// Reset resource usage for this render
+	ctx.ResourceLimits().Reset()
+
+	if ctx.TemplateName() == "" {
+		ctx.SetTemplateName(t.name)
+	}
+	return ctx
+}
+
+// renderRecovering renders the document into output, replacing the output
+// with an error message if a Liquid error stops the render.
+func (t *Template) renderRecovering(ctx *Context, output *OutputBuffer) {
 	defer func() {
 		if r := recover(); r != nil {
 			// Handle Liquid errors by converting them to error messages
 			var err error
-			var handled bool
 
 			switch e := r.(type) {
 			case *MemoryError:
-				errorMsg := context.HandleError(e, nil)
-				output = errorMsg
-				if output == "" {
-					output = "Liquid error: Memory limits exceeded"
+				errorMsg := ctx.HandleError(e, nil)
+				if errorMsg == "" {
+					errorMsg = "Liquid error: Memory limits exceeded"
 				}
-				handled = true
+				replaceOutput(output, errorMsg)
+				return
 			case LiquidError:
 				err = e
 			case *Error:
 				err = e
-			case error:
-				// Non-Liquid errors should be wrapped as InternalError
-				err = NewInternalError("internal")
 			default:
-				// Non-error panics should be wrapped as InternalError
+				// Non-Liquid errors and non-error panics should be wrapped as InternalError
 				err = NewInternalError("internal")
 			}
 
-			if !handled && err != nil {
-				// Get context to handle the error
-				if ctx, ok := context.(*Context); ok {
-					errorMsg := ctx.HandleError(err, nil)
-					output = errorMsg
-					if output == "" {
-						output = "Liquid error: internal"
-					}
-				} else {
-					// Fallback if we can't get context
-					output = "Liquid error: internal error"
-				}
-			} else if !handled {
-				// Re-panic non-Liquid panics
-				panic(r)
+			errorMsg := ctx.HandleError(err, nil)
+			if errorMsg == "" {
+				errorMsg = "Liquid error: internal"
 			}
-		}
-		// Update template state with mutex protection for thread-safe concurrent rendering
-		if ctx, ok := context.(*Context); ok {
-			t.mu.Lock()
-			// Always capture errors from the render
-			t.errors = ctx.Errors()
-			// Only merge back instance assigns and resource limits when we created the context,
-			// not when user passed their own Context
-			if !userProvidedContext {
-				// Merge back instance assigns from the render scope to persist across renders
-				if len(ctx.Scopes()) > 0 {
-					lastScope := ctx.Scopes()[len(ctx.Scopes())-1]
-					for k, v := range lastScope {
-						if k != "__drop__" { // Don't persist internal drop reference
-							t.instanceAssigns[k] = v
-						}
-					}
-				}
-				// Update template's resource limits from context's resource limits
-				if ctx.ResourceLimits() != nil && t.resourceLimits != nil {
-					ctxRL := ctx.ResourceLimits()
-					t.resourceLimits.assignScore = ctxRL.AssignScore()
-					t.resourceLimits.renderScore = ctxRL.RenderScore()
-					t.resourceLimits.reachedLimit = ctxRL.Reached()
-				}
-			}
-			t.mu.Unlock()
-		}
-		// Update output in options if provided
-		if options != nil && options.Output != nil {
-			*options.Output = output
+			replaceOutput(output, errorMsg)
 		}
 	}()
 
-	t.root.RenderToOutputBuffer(context, &output)
-	return output
+	t.root.RenderToOutputBuffer(ctx, output)
+}
+
+// finalAssigns returns the assigns left in the outermost scope of ctx.
+func finalAssigns(ctx *Context) map[string]interface{} {
+	assigns := make(map[string]interface{})
+	scopes := ctx.Scopes()
+	if len(scopes) == 0 {
+		return assigns
+	}
+	for k, v := range scopes[len(scopes)-1] {
+		if k != "__drop__" { // Don't expose internal drop reference
+			assigns[k] = v
+		}
+	}
+	return assigns
+}
+
+// replaceOutput replaces the rendered output with an error message.
+// Output that has already been streamed cannot be taken back, so a streaming
+// buffer gets the message appended instead.
+func replaceOutput(output *OutputBuffer, message string) {
+	output.Reset()
+	output.WriteString(message)
 }
 
 // RenderOptions contains options for rendering a template.
 type RenderOptions struct {
+	// Output is prepended to the rendered output and receives the result.
+	// Execute ignores it and returns the output in RenderResult.Output.
 	Output            *string
 	Registers         map[string]interface{}
 	GlobalFilter      func(interface{}) interface{}
@@ -395,6 +523,7 @@ type RenderOptions struct {
 	Filters           []interface{}
 	StrictVariables   bool
 	StrictFilters     bool
+	RethrowErrors     bool
 }
 
 // RenderBang renders the template with rethrow_errors enabled.
@@ -404,7 +533,7 @@ func (t *Template) RenderBang(assigns interface{}, options *RenderOptions) strin
 }
 
 // RenderToOutputBuffer renders the template to the output buffer.
-func (t *Template) RenderToOutputBuffer(context TagContext, output *string) {
+func (t *Template) RenderToOutputBuffer(context TagContext, output *OutputBuffer) {
 	if t.root == nil {
 		return
 	}
@@ -426,24 +555,34 @@ func (t *Template) RenderToOutputBuffer(context TagContext, output *string) {
 					panic(r)
 				}
 			}
+			t.mu.Lock()
 			t.errors = ctx.Errors()
+			t.mu.Unlock()
 		}()
 
 		t.root.RenderToOutputBuffer(context, output)
 	} else {
 		// Fallback: use Render method
-		_ = t.Render(context, &RenderOptions{Output: output})
+		t.render(nil, context, nil, output)
 	}
 }
 
 // buildContext builds a Context from assigns and options.
 func (t *Template) buildContext(assigns interface{}, options *RenderOptions) TagContext {
+	return t.newContext(assigns, options, false)
+}
+
+// newContext builds a Context from assigns and options. When execute is set,
+// a drop passed as assigns isn't kept in the template's instance assigns, and
+// the rethrow renderer for a caller's Context is left to Execute, which only
+// installs it for that render.
+func (t *Template) newContext(assigns interface{}, options *RenderOptions, execute bool) *Context {
 	var ctx *Context
 
 	switch v := assigns.(type) {
 	case *Context:
 		ctx = v
-		if t.rethrowErrors {
+		if t.rethrowErrors && !execute {
 			ctx.SetExceptionRenderer(func(err error) interface{} {
 				panic(err)
 			})
@@ -513,9 +652,11 @@ func (t *Template) buildContext(assigns interface{}, options *RenderOptions) Tag
 			// by putting it in the outer scope with a special key
 			ctx.Scopes()[len(ctx.Scopes())-1]["__drop__"] = dropToStore
 			// Store in template's instance assigns for future renders (with mutex protection)
-			t.mu.Lock()
-			t.instanceAssigns["__drop__"] = dropToStore
-			t.mu.Unlock()
+			if !execute {
+				t.mu.Lock()
+				t.instanceAssigns["__drop__"] = dropToStore
+				t.mu.Unlock()
+			}
 		}
 	}
 
@@ -544,11 +685,68 @@ func (t *Template) buildContext(assigns interface{}, options *RenderOptions) Tag
 		if options.StrictFilters {
 			ctx.SetStrictFilters(true)
 		}
+		if options.RethrowErrors {
+			ctx.SetExceptionRenderer(func(err error) interface{} {
+				panic(err)
+			})
+		}
 	}
 
 	return ctx
 }
 
+// contextOptions holds the settings of a Context that RenderOptions change.
+type contextOptions struct {
+	exceptionRenderer func(error) interface{}
+	globalFilter      func(interface{}) interface{}
+	strainer          *StrainerTemplate
+	filters           []interface{}
+	registerKeys      []string               // Registers the options set
+	registers         map[string]interface{} // Their previous changed values
+	strictVariables   bool
+	strictFilters     bool
+}
+
+// saveContextOptions returns the settings of ctx that options would change.
+func saveContextOptions(ctx *Context, options *RenderOptions) contextOptions {
+	saved := contextOptions{
+		exceptionRenderer: ctx.exceptionRenderer,
+		globalFilter:      ctx.globalFilter,
+		strainer:          ctx.strainer,
+		filters:           ctx.filters,
+		strictVariables:   ctx.strictVariables,
+		strictFilters:     ctx.strictFilters,
+	}
+	if options != nil && options.Registers != nil {
+		saved.registers = make(map[string]interface{}, len(options.Registers))
+		for key := range options.Registers {
+			saved.registerKeys = append(saved.registerKeys, key)
+			if value, ok := ctx.registers.Changes()[key]; ok {
+				saved.registers[key] = value
+			}
+		}
+	}
+	return saved
+}
+
+// restore puts the saved settings back on ctx. Registers the options set are
+// reset to their previous value, or deleted when they had none.
+func (s contextOptions) restore(ctx *Context) {
+	ctx.exceptionRenderer = s.exceptionRenderer
+	ctx.globalFilter = s.globalFilter
+	ctx.strainer = s.strainer
+	ctx.filters = s.filters
+	ctx.strictVariables = s.strictVariables
+	ctx.strictFilters = s.strictFilters
+	for _, key := range s.registerKeys {
+		if value, ok := s.registers[key]; ok {
+			ctx.registers.Set(key, value)
+		} else {
+			ctx.registers.Delete(key)
+		}
+	}
+}
+
 // configureOptions configures parse options and returns a ParseContext.
 func (t *Template) configureOptions(options *TemplateOptions) ParseContextInterface {
 	if options == nil {
@@ -1,10 +1,14 @@
 package liquid
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestTemplateParse(t *testing.T) {
@@ -180,18 +184,18 @@ func TestTemplateRenderToOutputBuffer(t *testing.T) {
 	// Test with Context
 	ctx := NewContext()
 	ctx.Set("name", "world")
-	output := ""
-	template.RenderToOutputBuffer(ctx, &output)
-	if output != "Hello world" {
-		t.Errorf("Expected 'Hello world', got %q", output)
+	output := NewOutputBuffer()
+	template.RenderToOutputBuffer(ctx, output)
+	if output.String() != "Hello world" {
+		t.Errorf("Expected 'Hello world', got %q", output.String())
 	}
 
 	// Test with nil root
 	template2 := NewTemplate(&TemplateOptions{Environment: env})
-	output2 := ""
-	template2.RenderToOutputBuffer(ctx, &output2)
-	if output2 != "" {
-		t.Errorf("Expected empty output for nil root, got %q", output2)
+	output2 := NewOutputBuffer()
+	template2.RenderToOutputBuffer(ctx, output2)
+	if output2.String() != "" {
+		t.Errorf("Expected empty output for nil root, got %q", output2.String())
 	}
 
 	// Test with memory error recovery
@@ -202,19 +206,19 @@ func TestTemplateRenderToOutputBuffer(t *testing.T) {
 	}
 	ctx3 := NewContext()
 	ctx3.Set("name", "test")
-	var output3 string
-	template3.RenderToOutputBuffer(ctx3, &output3)
-	if output3 != "test" {
-		t.Errorf("Expected 'test', got %q", output3)
+	output3 := NewOutputBuffer()
+	template3.RenderToOutputBuffer(ctx3, output3)
+	if output3.String() != "test" {
+		t.Errorf("Expected 'test', got %q", output3.String())
 	}
 
 	// Test with fallback path - create a context from map
 	ctx4 := BuildContext(ContextConfig{
 		Environments: []map[string]interface{}{{"name": "test"}},
 	})
-	output4 := ""
-	template.RenderToOutputBuffer(ctx4, &output4)
-	if output4 == "" {
+	output4 := NewOutputBuffer()
+	template.RenderToOutputBuffer(ctx4, output4)
+	if output4.String() == "" {
 		t.Error("Expected non-empty output")
 	}
 }
@@ -671,17 +675,17 @@ func TestTemplateRenderToOutputBufferWithNonContext(t *testing.T) {
 		ctx:     NewContext(),
 	}
 
-	var output string
+	output := NewOutputBuffer()
 	// Should use fallback rendering path (Render method)
 	// Note: The fallback path may not fully evaluate variables, so we just verify it doesn't panic
-	template.RenderToOutputBuffer(customCtx, &output)
+	template.RenderToOutputBuffer(customCtx, output)
 
 	// The fallback path may produce partial output or empty output
 	// We're testing that it doesn't panic and handles non-Context gracefully
-	if len(output) == 0 {
+	if len(output.String()) == 0 {
 		t.Logf("Note: Fallback rendering path produced empty output (may be expected)")
 	} else {
-		t.Logf("Note: Fallback rendering path produced: %q", output)
+		t.Logf("Note: Fallback rendering path produced: %q", output.String())
 	}
 }
 
@@ -778,12 +782,12 @@ func TestTemplateRenderToOutputBufferNilRoot(t *testing.T) {
 	template := NewTemplate(&TemplateOptions{Environment: env})
 	// Don't parse, so root is nil
 
-	var output string
-	template.RenderToOutputBuffer(NewContext(), &output)
+	output := NewOutputBuffer()
+	template.RenderToOutputBuffer(NewContext(), output)
 
 	// Should not panic and output should be empty
-	if output != "" {
-		t.Errorf("Expected empty output for nil root, got %q", output)
+	if output.String() != "" {
+		t.Errorf("Expected empty output for nil root, got %q", output.String())
 	}
 }
 
@@ -806,9 +810,9 @@ func TestTemplateRenderToOutputBufferResourceLimitsReset(t *testing.T) {
 	initialRenderScore := rl.RenderScore()
 	initialAssignScore := rl.AssignScore()
 
-	var output string
+	output := NewOutputBuffer()
 	// RenderToOutputBuffer should reset resource limits
-	template.RenderToOutputBuffer(ctx, &output)
+	template.RenderToOutputBuffer(ctx, output)
 
 	// Resource limits should be reset
 	if rl.RenderScore() >= initialRenderScore {
@@ -834,8 +838,8 @@ func TestTemplateRenderToOutputBufferTemplateNameSetting(t *testing.T) {
 		t.Error("Expected empty template name initially")
 	}
 
-	var output string
-	template.RenderToOutputBuffer(ctx, &output)
+	output := NewOutputBuffer()
+	template.RenderToOutputBuffer(ctx, output)
 
 	// Template name should be set
 	if ctx.TemplateName() != "test_template.liquid" {
@@ -880,3 +884,368 @@ func TestTemplateConcurrentRender(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+// TestTemplateRenderTo tests streaming rendering to an io.Writer
+func TestTemplateRenderTo(t *testing.T) {
+	template, err := ParseTemplate("Hello {{ name }}!", nil)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+
+	var w bytes.Buffer
+	if err := template.RenderTo(&w, map[string]interface{}{"name": "world"}, nil); err != nil {
+		t.Fatalf("RenderTo() error = %v", err)
+	}
+	if w.String() != "Hello world!" {
+		t.Errorf("Expected 'Hello world!', got %q", w.String())
+	}
+
+	// A template without a root renders nothing
+	var empty bytes.Buffer
+	if err := NewTemplate(nil).RenderTo(&empty, nil, nil); err != nil {
+		t.Fatalf("RenderTo() error = %v", err)
+	}
+	if empty.String() != "" {
+		t.Errorf("Expected empty output, got %q", empty.String())
+	}
+}
+
+// TestTemplateRenderToRenderLengthLimit tests that render length limits apply to streamed output
+func TestTemplateRenderToRenderLengthLimit(t *testing.T) {
+	template, err := ParseTemplate("0123456789{{ name }}", nil)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	limit := 5
+	template.SetResourceLimits(NewResourceLimits(ResourceLimitsConfig{RenderLengthLimit: &limit}))
+
+	var w bytes.Buffer
+	if err := template.RenderTo(&w, map[string]interface{}{"name": "x"}, nil); err != nil {
+		t.Fatalf("RenderTo() error = %v", err)
+	}
+	if !strings.HasSuffix(w.String(), "Liquid error: Memory limits exceeded") {
+		t.Errorf("Expected memory error to be appended, got %q", w.String())
+	}
+	if !template.ResourceLimits().Reached() {
+		t.Error("Expected resource limits to be reached")
+	}
+}
+
+// cancelingFilter cancels the render's context.Context the first time it runs
+type cancelingFilter struct {
+	cancel context.CancelFunc
+}
+
+func (f *cancelingFilter) StopHere(ctx *Context, input interface{}) interface{} {
+	if ctx.GoContext().Err() == nil {
+		f.cancel()
+	}
+	return input
+}
+
+func TestTemplateRenderContext(t *testing.T) {
+	template, err := ParseTemplate("Hello {{ name }}!", nil)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+
+	result := template.RenderContext(context.Background(), map[string]interface{}{"name": "world"}, nil)
+	if result != "Hello world!" {
+		t.Errorf("Expected 'Hello world!', got %q", result)
+	}
+	if len(template.Errors()) != 0 {
+		t.Errorf("Expected no errors, got %v", template.Errors())
+	}
+}
+
+func TestTemplateRenderContextCanceled(t *testing.T) {
+	template, err := ParseTemplate("Hello {{ name }}!", nil)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result := template.RenderContext(ctx, map[string]interface{}{"name": "world"}, nil)
+	if result != "Liquid error: render canceled: context canceled" {
+		t.Errorf("Expected cancellation error, got %q", result)
+	}
+
+	errs := template.Errors()
+	if len(errs) != 1 {
+		t.Fatalf("Expected 1 error, got %d", len(errs))
+	}
+	var canceledErr *CanceledError
+	if !errors.As(errs[0], &canceledErr) {
+		t.Fatalf("Expected CanceledError, got %T", errs[0])
+	}
+	if !errors.Is(errs[0], context.Canceled) {
+		t.Error("Expected error to wrap context.Canceled")
+	}
+}
+
+func TestTemplateRenderContextDeadlineExceeded(t *testing.T) {
+	template, err := ParseTemplate("{{ name }}", nil)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	template.RenderContext(ctx, map[string]interface{}{"name": "world"}, nil)
+	errs := template.Errors()
+	if len(errs) != 1 || !errors.Is(errs[0], context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded error, got %v", errs)
+	}
+}
+
+// TestTemplateRenderContextStopsAtNode tests that a render stops at the node after cancellation
+// and reports its line number
+func TestTemplateRenderContextStopsAtNode(t *testing.T) {
+	template, err := ParseTemplate("{{ 'a' | stop_here }}\n{{ 'b' }}", &TemplateOptions{LineNumbers: true})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	template.SetName("page")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	result := template.RenderContext(ctx, nil, &RenderOptions{
+		Filters: []interface{}{&cancelingFilter{cancel: cancel}},
+	})
+	if result != "Liquid error (page line 2): render canceled: context canceled" {
+		t.Errorf("Expected cancellation error at line 2, got %q", result)
+	}
+}
+
+// TestTemplateRenderWithGoContextOnContext tests that Render honors a context.Context
+// set on a user-provided Context
+func TestTemplateRenderWithGoContextOnContext(t *testing.T) {
+	template, err := ParseTemplate("{{ name }}", nil)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+
+	goCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ctx := BuildContext(ContextConfig{GoContext: goCtx})
+	ctx.Set("name", "world")
+	template.Render(ctx, nil)
+
+	if len(ctx.Errors()) != 1 {
+		t.Fatalf("Expected 1 error, got %d", len(ctx.Errors()))
+	}
+	if _, ok := ctx.Errors()[0].(*CanceledError); !ok {
+		t.Errorf("Expected CanceledError, got %T", ctx.Errors()[0])
+	}
+}
+
+func TestTemplateExecute(t *testing.T) {
+	template, err := ParseTemplate("Hello {{ name }}!", nil)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+
+	result, err := template.Execute(map[string]interface{}{"name": "world"}, nil)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result.Output != "Hello world!" {
+		t.Errorf("Expected 'Hello world!', got %q", result.Output)
+	}
+	if len(result.Errors) != 0 {
+		t.Errorf("Expected no errors, got %v", result.Errors)
+	}
+	if result.ResourceUsage.RenderScore == 0 {
+		t.Error("Expected a render score")
+	}
+	if result.ResourceUsage.RenderLength != len("Hello world!") {
+		t.Errorf("Expected render length %d, got %d", len("Hello world!"), result.ResourceUsage.RenderLength)
+	}
+
+	// A template without a root renders nothing
+	result, err = NewTemplate(nil).Execute(nil, nil)
+	if err != nil || result.Output != "" {
+		t.Errorf("Expected empty result, got %q, %v", result.Output, err)
+	}
+}
+
+// TestTemplateExecuteLeavesTemplateUntouched tests that Execute doesn't store render state on the template
+func TestTemplateExecuteLeavesTemplateUntouched(t *testing.T) {
+	template, err := ParseTemplate("{{ 'x' | upcase }}{{ name | no_such_filter }}", nil)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+
+	result, err := template.Execute(nil, &RenderOptions{StrictFilters: true})
+	if err == nil {
+		t.Fatal("Expected an error in strict filters mode")
+	}
+	if _, ok := err.(*UndefinedFilter); !ok {
+		t.Errorf("Expected UndefinedFilter, got %T", err)
+	}
+	if len(result.Errors) != 1 {
+		t.Errorf("Expected 1 error in result, got %d", len(result.Errors))
+	}
+	if !strings.HasPrefix(result.Output, "X") {
+		t.Errorf("Expected output to keep rendering, got %q", result.Output)
+	}
+
+	if len(template.Errors()) != 0 {
+		t.Errorf("Expected template errors to be untouched, got %v", template.Errors())
+	}
+	if template.ResourceLimits().RenderScore() != 0 {
+		t.Errorf("Expected template render score to be untouched, got %d", template.ResourceLimits().RenderScore())
+	}
+}
+
+// TestTemplateExecuteThenRender tests that Execute with a drop or a Context doesn't change later renders
+func TestTemplateExecuteThenRender(t *testing.T) {
+	template, err := ParseTemplate("{{ __drop__ }}", nil)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	if _, err := template.Execute(NewDrop(), nil); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result := template.Render(nil, nil); result != "" {
+		t.Errorf("Expected Render to not see the drop given to Execute, got %q", result)
+	}
+
+	template, err = ParseTemplate("{{ 'x' | fail }}", nil)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	ctx := NewContext()
+	ctx.AddFilters([]interface{}{&failingFilter{}})
+	if _, err := template.Execute(ctx, &RenderOptions{RethrowErrors: true}); err == nil {
+		t.Fatal("Expected an error in rethrow mode")
+	}
+	if result := template.Render(ctx, nil); result != "Liquid error: failed on x" {
+		t.Errorf("Expected Render to keep the context's exception renderer, got %q", result)
+	}
+}
+
+// TestTemplateExecuteRestoresContextOptions tests that the options given to Execute
+// only apply to a caller's Context for that render
+func TestTemplateExecuteRestoresContextOptions(t *testing.T) {
+	template, err := ParseTemplate("{{ 'x' | fail }}", nil)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	ctx := NewContext()
+	ctx.Registers().Set("kept", "before")
+	_, err = template.Execute(ctx, &RenderOptions{
+		Filters:         []interface{}{&failingFilter{}},
+		GlobalFilter:    func(v interface{}) interface{} { return v },
+		Registers:       map[string]interface{}{"kept": "during", "added": true},
+		StrictVariables: true,
+		StrictFilters:   true,
+	})
+	if err == nil {
+		t.Fatal("Expected the filter to fail during Execute")
+	}
+
+	if ctx.StrictVariables() || ctx.StrictFilters() {
+		t.Error("Expected strict modes to be restored")
+	}
+	if ctx.GlobalFilter() != nil {
+		t.Error("Expected the global filter to be restored")
+	}
+	if got := ctx.Registers().Get("kept"); got != "before" {
+		t.Errorf("Registers().Get(kept) = %v, want before", got)
+	}
+	if ctx.Registers().HasKey("added") {
+		t.Error("Expected the added register to be removed")
+	}
+	if got := template.Render(ctx, nil); got != "x" {
+		t.Errorf("Expected the filters given to Execute to be dropped, got %q", got)
+	}
+}
+
+// failingFilter raises an ArgumentError when invoked
+type failingFilter struct{}
+
+func (f *failingFilter) Fail(input interface{}) interface{} {
+	panic(NewArgumentError("failed on " + ToS(input, nil)))
+}
+
+// TestTemplateExecuteLaxErrors tests that errors in lax mode are reported without an error return
+func TestTemplateExecuteLaxErrors(t *testing.T) {
+	template, err := ParseTemplate("{{ 'x' | fail }}", nil)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+
+	result, err := template.Execute(nil, &RenderOptions{Filters: []interface{}{&failingFilter{}}})
+	if err != nil {
+		t.Fatalf("Expected no error in lax mode, got %v", err)
+	}
+	if len(result.Errors) != 1 {
+		t.Fatalf("Expected 1 error in result, got %d", len(result.Errors))
+	}
+	if _, ok := result.Errors[0].(*ArgumentError); !ok {
+		t.Errorf("Expected ArgumentError, got %T", result.Errors[0])
+	}
+	if result.Output != "Liquid error: failed on x" {
+		t.Errorf("Expected inline error, got %q", result.Output)
+	}
+}
+
+// TestTemplateExecuteRethrowErrors tests that the first error is returned in rethrow mode
+func TestTemplateExecuteRethrowErrors(t *testing.T) {
+	template, err := ParseTemplate("before {{ 'x' | fail }} after", nil)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+
+	result, err := template.Execute(nil, &RenderOptions{
+		Filters:       []interface{}{&failingFilter{}},
+		RethrowErrors: true,
+	})
+	if _, ok := err.(*ArgumentError); !ok {
+		t.Fatalf("Expected ArgumentError, got %v", err)
+	}
+	if len(result.Errors) != 1 {
+		t.Errorf("Expected 1 error in result, got %d", len(result.Errors))
+	}
+	if strings.Contains(result.Output, "after") {
+		t.Errorf("Expected render to stop at the error, got %q", result.Output)
+	}
+}
+
+// TestTemplateExecuteConcurrent tests that concurrent executions report their own errors
+func TestTemplateExecuteConcurrent(t *testing.T) {
+	template, err := ParseTemplate("{{ name | fail }}", nil)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			options := &RenderOptions{}
+			if i%2 == 0 {
+				options.Filters = []interface{}{&failingFilter{}}
+			}
+			result, err := template.Execute(map[string]interface{}{"name": "n"}, options)
+			if err != nil {
+				t.Errorf("Execute() error = %v", err)
+				return
+			}
+			if i%2 == 0 && len(result.Errors) != 1 {
+				t.Errorf("Expected 1 error with failing filter, got %d", len(result.Errors))
+			}
+			if i%2 == 1 && (len(result.Errors) != 0 || result.Output != "n") {
+				t.Errorf("Expected 'n' without errors, got %q, %v", result.Output, result.Errors)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
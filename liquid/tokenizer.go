@@ -22,8 +22,19 @@ type Tokenizer struct {
 	ss           *StringScanner
 	source       string
 	tokens       []string
+	spans        []Span   // Span of each token in the source
+	base         Position // Position of the source in the template, see Span
 	offset       int
 	forLiquidTag bool
+	trace        *formatTrace // Set by Format to record where tokens are parsed
+}
+
+// formatTrace records, for each token, the depth of the block body that
+// parsed it (1 for the document body). Tokens consumed directly by a tag, such
+// as the body of a raw tag, keep depth 0.
+type formatTrace struct {
+	depth  int
+	depths []int
 }
 
 // NewTokenizer creates a new tokenizer.
@@ -75,6 +86,39 @@ func (t *Tokenizer) Shift() string {
 	return token
 }
 
+// traceToken records the depth of the block body parsing the last shifted token.
+func (t *Tokenizer) traceToken() {
+	if t.trace != nil && t.offset > 0 && t.offset <= len(t.trace.depths) {
+		t.trace.depths[t.offset-1] = t.trace.depth
+	}
+}
+
+// unshift puts back the last shifted token.
+func (t *Tokenizer) unshift() {
+	if t.offset == 0 {
+		return
+	}
+	t.offset--
+	if t.lineNumber != nil {
+		if t.forLiquidTag {
+			*t.lineNumber--
+		} else {
+			*t.lineNumber -= strings.Count(t.tokens[t.offset], "\n")
+		}
+	}
+}
+
+// Span returns the span of the last token returned by Shift, or the zero
+// Span before the first one. Tokens of {% liquid %} markup are lines, without
+// their surrounding whitespace.
+func (t *Tokenizer) Span() Span {
+	if t.offset == 0 || t.offset > len(t.spans) {
+		return Span{}
+	}
+	span := t.spans[t.offset-1]
+	return Span{Start: span.Start.in(t.base), End: span.End.in(t.base)}
+}
+
 // LineNumber returns the current line number.
 func (t *Tokenizer) LineNumber() *int {
 	return t.lineNumber
@@ -88,8 +132,25 @@ func (t *Tokenizer) ForLiquidTag() bool {
 func (t *Tokenizer) tokenize() {
 	if t.forLiquidTag {
 		t.tokens = strings.Split(t.source, "\n")
+		t.spans = make([]Span, len(t.tokens))
+		pos := Position{Line: 1, Column: 1}
+		for i, token := range t.tokens {
+			line := strings.TrimLeft(token, " \t\r")
+			start := pos.advance(token[:len(token)-len(line)])
+			t.spans[i] = Span{Start: start, End: start.advance(strings.TrimRight(line, " \t\r"))}
+			pos = pos.advance(token)
+			pos = Position{Offset: pos.Offset + 1, Line: pos.Line + 1, Column: 1}
+		}
 	} else {
+		pos, offset := Position{Line: 1, Column: 1}, 0
+		add := func(token string, start int) {
+			pos = pos.advance(t.source[offset:start])
+			offset = start
+			t.tokens = append(t.tokens, token)
+			t.spans = append(t.spans, Span{Start: pos, End: pos.advance(token)})
+		}
 		for !t.ss.EOS() {
+			start := t.ss.Pos()
 			token := t.shiftNormal()
 			if token == "" {
 				// If we get an empty token but we're not at EOS, there might be remaining text
@@ -97,13 +158,13 @@ func (t *Tokenizer) tokenize() {
 					// Get remaining text
 					rest := t.ss.Rest()
 					if rest != "" {
-						t.tokens = append(t.tokens, rest)
+						add(rest, t.ss.Pos())
 						t.ss.Terminate()
 					}
 				}
 				break
 			}
-			t.tokens = append(t.tokens, token)
+			add(token, start)
 		}
 	}
 
@@ -298,3 +298,41 @@ func TestTokenizerShiftNormalEdgeCases(t *testing.T) {
 		t.Error("Expected tokens for source with variable")
 	}
 }
+
+func TestTokenizerSpan(t *testing.T) {
+	source := "Hi\n  {{ name }}{% if a %}\nok"
+	tokenizer := NewTokenizer(source, nil, false, nil, false)
+	if span := tokenizer.Span(); span.IsValid() {
+		t.Errorf("Span() before Shift = %#v, want the zero Span", span)
+	}
+
+	want := []Span{
+		{Start: Position{0, 1, 1}, End: Position{5, 2, 3}},
+		{Start: Position{5, 2, 3}, End: Position{15, 2, 13}},
+		{Start: Position{15, 2, 13}, End: Position{25, 2, 23}},
+		{Start: Position{25, 2, 23}, End: Position{28, 3, 3}},
+	}
+	for i, w := range want {
+		token := tokenizer.Shift()
+		if span := tokenizer.Span(); span != w || span.Source(source) != token {
+			t.Errorf("token %d %q: Span() = %#v, want %#v", i, token, span, w)
+		}
+	}
+}
+
+func TestTokenizerSpanForLiquidTag(t *testing.T) {
+	source := "{% liquid assign a = 1\n  echo a  \n%}"
+	markup := source[10 : len(source)-2]
+	tokenizer := NewTokenizer(markup, nil, false, nil, true)
+	tokenizer.base = Position{Offset: 10, Line: 1, Column: 11}
+
+	for _, want := range []string{"assign a = 1", "echo a", ""} {
+		tokenizer.Shift()
+		if got := tokenizer.Span().Source(source); got != want {
+			t.Errorf("Span().Source() = %q, want %q", got, want)
+		}
+	}
+	if got, want := tokenizer.Span(), (Span{Start: Position{34, 3, 1}, End: Position{34, 3, 1}}); got != want {
+		t.Errorf("Span() = %#v, want %#v", got, want)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -17,6 +18,52 @@ var (
 	UnixTimestampRegex = regexp.MustCompile(`^\d+$`)
 )
 
+// OrderedHash is implemented by hash values that keep their keys in insertion
+// order, which {% for key, value in hash insertion_order %} iterates in.
+type OrderedHash interface {
+	Keys() []string
+	Get(key string) interface{}
+}
+
+// HashEntries returns the [key, value] pairs of a hash, a map or an
+// OrderedHash, sorted by key. With insertionOrder, an OrderedHash keeps its
+// own order. It returns false when value is not a hash.
+func HashEntries(value interface{}, insertionOrder bool) ([]interface{}, bool) {
+	if hash, ok := value.(OrderedHash); ok {
+		keys := hash.Keys()
+		if !insertionOrder {
+			keys = append([]string(nil), keys...)
+			sort.Strings(keys)
+		}
+		entries := make([]interface{}, len(keys))
+		for i, key := range keys {
+			entries[i] = []interface{}{key, hash.Get(key)}
+		}
+		return entries, true
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Map {
+		return nil, false
+	}
+	keys := v.MapKeys()
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = ToS(key.Interface(), nil)
+	}
+	order := make([]int, len(keys))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return names[order[i]] < names[order[j]] })
+
+	entries := make([]interface{}, len(keys))
+	for i, index := range order {
+		entries[i] = []interface{}{keys[index].Interface(), v.MapIndex(keys[index]).Interface()}
+	}
+	return entries, true
+}
+
 // SliceCollection slices a collection from index `from` to index `to` (exclusive).
 // If `to` is nil, slices to the end.
 func SliceCollection(collection interface{}, from int, to *int) []interface{} {
@@ -1,6 +1,7 @@
 package liquid
 
 import (
+	"reflect"
 	"testing"
 	"time"
 )
@@ -614,3 +615,38 @@ type testStringer struct {
 func (t *testStringer) String() string {
 	return t.value
 }
+
+type testOrderedHash struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (h *testOrderedHash) Keys() []string             { return h.keys }
+func (h *testOrderedHash) Get(key string) interface{} { return h.values[key] }
+
+func TestHashEntries(t *testing.T) {
+	type MapOfAny map[string]any
+
+	entries, ok := HashEntries(MapOfAny{"b": 2, "a": 1, "c": 3}, false)
+	want := []interface{}{[]interface{}{"a", 1}, []interface{}{"b", 2}, []interface{}{"c", 3}}
+	if !ok || !reflect.DeepEqual(entries, want) {
+		t.Errorf("HashEntries(map) = %v, %v, want %v", entries, ok, want)
+	}
+
+	ordered := &testOrderedHash{keys: []string{"z", "a"}, values: map[string]interface{}{"z": 26, "a": 1}}
+	entries, _ = HashEntries(ordered, true)
+	if want := []interface{}{[]interface{}{"z", 26}, []interface{}{"a", 1}}; !reflect.DeepEqual(entries, want) {
+		t.Errorf("HashEntries(ordered, true) = %v, want %v", entries, want)
+	}
+	entries, _ = HashEntries(ordered, false)
+	if want := []interface{}{[]interface{}{"a", 1}, []interface{}{"z", 26}}; !reflect.DeepEqual(entries, want) {
+		t.Errorf("HashEntries(ordered, false) = %v, want %v", entries, want)
+	}
+	if ordered.keys[0] != "z" {
+		t.Error("HashEntries() must not sort the keys of an OrderedHash in place")
+	}
+
+	if _, ok := HashEntries([]interface{}{1, 2}, false); ok {
+		t.Error("HashEntries(slice) should return false")
+	}
+}
@@ -2,14 +2,15 @@ package liquid
 
 import (
 	"regexp"
+	"sort"
 	"strings"
 )
 
 var (
 	variableFilterMarkupRegex        = regexp.MustCompile(`\|\s*(.*)`)
-	variableFilterParser             = regexp.MustCompile(`(?:\s+|"[^"]*"|'[^']*'|(?:[^\s,\|'"]|"[^"]*"|'[^']*')+)+`)
-	variableFilterArgsRegex          = regexp.MustCompile(`(?::|,)\s*((?:\w+\s*:\s*)?(?:[^\s,\|'"]|"[^"]*"|'[^']*')+)`)
-	variableJustTagAttributes        = regexp.MustCompile(`^(\w[\w-]*)\s*:\s*((?:"[^"]*"|'[^']*'|(?:[^\s,\|'"]|"[^"]*"|'[^']*')+)+)$`)
+	variableFilterParser             = regexp.MustCompile(`(?:\s+|"[^"]*"|'[^']*'|(?:` + literalFragment + `|[^\s,\|'"]|"[^"]*"|'[^']*')+)+`)
+	variableFilterArgsRegex          = regexp.MustCompile(`(?::|,)\s*((?:\w+\s*:\s*)?(?:` + literalFragment + `|[^\s,\|'"]|"[^"]*"|'[^']*')+)`)
+	variableJustTagAttributes        = regexp.MustCompile(`^(\w[\w-]*)\s*:\s*((?:"[^"]*"|'[^']*'|(?:` + literalFragment + `|[^\s,\|'"]|"[^"]*"|'[^']*')+)+)$`)
 	variableMarkupWithQuotedFragment = regexp.MustCompile(`^([^\|]+)(.*)$`)
 )
 
@@ -38,6 +39,7 @@ type Variable struct {
 	name         interface{}
 	parseContext ParseContextInterface
 	lineNumber   *int
+	span         Span
 	markup       string
 	filters      [][]interface{}
 }
@@ -54,6 +56,7 @@ func NewVariable(markup string, parseContext ParseContextInterface) *Variable {
 		markup:       markup,
 		parseContext: parseContext,
 		lineNumber:   lineNum,
+		span:         parseSpan(parseContext),
 	}
 
 	// Use parser switching based on error mode
@@ -74,6 +77,7 @@ func NewVariable(markup string, parseContext ParseContextInterface) *Variable {
 	ps := &ParserSwitching{
 		parseContext:  psContext,
 		lineNumber:    lineNum,
+		span:          v.span,
 		markupContext: v.markupContext,
 	}
 
@@ -153,6 +157,36 @@ func (v *Variable) LineNumber() *int {
 	return v.lineNumber
 }
 
+// Span returns where the variable is in the template.
+func (v *Variable) Span() Span {
+	return v.span
+}
+
+// ParseTreeChildren returns the variable's expression followed by its filter arguments.
+func (v *Variable) ParseTreeChildren() []interface{} {
+	children := []interface{}{v.name}
+	for _, filter := range v.filters {
+		if len(filter) > 1 {
+			if args, ok := filter[1].([]interface{}); ok {
+				children = append(children, args...)
+			}
+		}
+		if len(filter) > 2 {
+			if kwargs, ok := filter[2].(map[string]interface{}); ok {
+				keys := make([]string, 0, len(kwargs))
+				for key := range kwargs {
+					keys = append(keys, key)
+				}
+				sort.Strings(keys)
+				for _, key := range keys {
+					children = append(children, kwargs[key])
+				}
+			}
+		}
+	}
+	return children
+}
+
 func (v *Variable) laxParse(markup string) {
 	v.filters = [][]interface{}{}
 	matches := variableMarkupWithQuotedFragment.FindStringSubmatch(markup)
@@ -382,16 +416,21 @@ func (v *Variable) Render(context TagContext) interface{} {
 }
 
 // RenderToOutputBuffer renders the variable to the output buffer.
-func (v *Variable) RenderToOutputBuffer(context TagContext, output *string) {
+func (v *Variable) RenderToOutputBuffer(context TagContext, output *OutputBuffer) {
 	// Catch panics from drop method invocations and convert to error messages
 	defer func() {
 		if r := recover(); r != nil {
+			// A canceled render stops here instead of rendering an inline error
+			if canceled, ok := r.(*CanceledError); ok {
+				panic(canceled)
+			}
+
 			// Get the context to handle errors
 			ctxInterface := context.Context()
 			ctx, ok := ctxInterface.(*Context)
 			if !ok {
 				// If we can't get the context, just output a generic error
-				*output += "Liquid error: internal"
+				output.WriteString("Liquid error: internal")
 				return
 			}
 
@@ -412,12 +451,12 @@ func (v *Variable) RenderToOutputBuffer(context TagContext, output *string) {
 
 			// Handle the error and append the error message to output
 			errorMsg := ctx.HandleError(err, v.lineNumber)
-			*output += errorMsg
+			output.WriteString(errorMsg)
 		}
 	}()
 
 	val := v.Render(context)
-	*output += ToS(val, nil)
+	output.WriteString(ToS(val, nil))
 }
 
 // parseContextWrapper is a minimal wrapper for ParseContextInterface when it doesn't implement ErrorMode/AddWarning.
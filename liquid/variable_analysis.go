@@ -0,0 +1,389 @@
+package liquid
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// VariableKind classifies a variable reference by where its value comes from.
+type VariableKind int
+
+const (
+	// GlobalVariable is read from the assigns passed to the render.
+	GlobalVariable VariableKind = iota
+	// LoopVariable is bound by a loop: the for/tablerow item, forloop and tablerowloop.
+	LoopVariable
+	// AssignedVariable is set by assign, capture, increment or decrement.
+	AssignedVariable
+	// PartialVariable is passed to a partial by render or include (with/for ... as, and attributes).
+	PartialVariable
+)
+
+// String returns the name of the kind.
+func (k VariableKind) String() string {
+	switch k {
+	case GlobalVariable:
+		return "global"
+	case LoopVariable:
+		return "loop"
+	case AssignedVariable:
+		return "assigned"
+	case PartialVariable:
+		return "partial"
+	default:
+		return "unknown"
+	}
+}
+
+// ScopedVariable is a variable bound by a tag.
+type ScopedVariable struct {
+	Name string
+	// Source is the expression the variable is bound to, or nil when its
+	// value cannot be traced back to one (captures, counters, loop drops).
+	Source interface{}
+	// Iterates is true when the variable holds each item of Source in turn.
+	Iterates bool
+}
+
+// PartialReference describes a partial rendered by include or render.
+type PartialReference struct {
+	// Name is the template name, or "" when it is not a string literal.
+	Name string
+	// Isolated is true when the partial cannot see the caller's variables (render).
+	Isolated bool
+	// Variables are bound in the partial before it renders.
+	Variables []ScopedVariable
+}
+
+// VariableScope describes how a tag changes the variables in scope.
+type VariableScope struct {
+	// Assigns are set in the template's outermost scope once the tag has rendered.
+	Assigns []ScopedVariable
+	// Locals are only visible while Body renders.
+	Locals []ScopedVariable
+	// Body lists the children (from ParseTreeChildren) rendered with Locals in scope.
+	// The other children are evaluated in the enclosing scope.
+	Body []interface{}
+	// Partial is set by tags that render another template.
+	Partial *PartialReference
+}
+
+// ScopedNode is implemented by tags that introduce variables, so that
+// AnalyzeVariables can follow Liquid's scoping rules.
+type ScopedNode interface {
+	VariableScope() *VariableScope
+}
+
+// VariableReference is a variable lookup found by AnalyzeVariables.
+type VariableReference struct {
+	// Name is the lookup as written, e.g. "item.price".
+	Name string
+	// Path is the lookup resolved through loop aliases, plain assigns and
+	// partial variables, e.g. "order.items[].price". "[]" stands for any
+	// item of a collection or a key computed at render time.
+	Path string
+	// Kind is where the root variable of Name comes from.
+	Kind VariableKind
+	// Global is true when Path is rooted in the render assigns.
+	Global bool
+	// LineNumber is the line of the enclosing tag or output, or 0 when the
+	// template was parsed without line numbers.
+	LineNumber int
+	// Template is the name of the partial containing the lookup, or "" for
+	// the analyzed template.
+	Template string
+}
+
+// VariableAnalysis is the result of AnalyzeVariables.
+type VariableAnalysis struct {
+	// References lists every variable lookup in template order.
+	References []VariableReference
+	// Errors holds the errors raised while loading partials.
+	Errors []error
+}
+
+// Globals returns the distinct paths read from the render assigns, sorted.
+func (a *VariableAnalysis) Globals() []string {
+	seen := make(map[string]bool)
+	paths := []string{}
+	for _, ref := range a.References {
+		if ref.Global && !seen[ref.Path] {
+			seen[ref.Path] = true
+			paths = append(paths, ref.Path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// OfKind returns the references of the given kind.
+func (a *VariableAnalysis) OfKind(kind VariableKind) []VariableReference {
+	var refs []VariableReference
+	for _, ref := range a.References {
+		if ref.Kind == kind {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// AnalyzeVariables statically lists the variables a template reads.
+//
+// The parse tree is walked with ParseTreeVisitor; tags implementing
+// ScopedNode define loop, assign and partial scopes. Partials are loaded from
+// the "file_system" register of the template, or the environment's file
+// system, when one is configured; otherwise only the expressions passed to
+// them are reported.
+func AnalyzeVariables(tmpl *Template) *VariableAnalysis {
+	analysis := &VariableAnalysis{References: []VariableReference{}}
+	if tmpl == nil || tmpl.root == nil {
+		return analysis
+	}
+
+	analyzer := &variableAnalyzer{
+		analysis:    analysis,
+		environment: tmpl.environment,
+		fileSystem:  analysisFileSystem(tmpl),
+		partials:    make(map[string]*Template),
+		visiting:    make(map[string]bool),
+	}
+	analyzer.walk(tmpl.root, newAnalysisScope(nil), 0)
+	return analysis
+}
+
+// analysisFileSystem returns the file system partials are loaded from, or nil.
+func analysisFileSystem(tmpl *Template) FileSystem {
+	if fs, ok := tmpl.registers["file_system"].(FileSystem); ok {
+		return fs
+	}
+	if tmpl.environment != nil {
+		if fs := tmpl.environment.FileSystem(); fs != nil {
+			if _, blank := fs.(*BlankFileSystem); !blank {
+				return fs
+			}
+		}
+	}
+	return nil
+}
+
+// analysisBinding is a variable known to a scope.
+type analysisBinding struct {
+	kind   VariableKind
+	path   string // resolved path prefix, "" when unknown
+	global bool
+}
+
+// analysisScope mirrors the scopes of a Context: loops push a scope, assigns
+// write to the outermost one.
+type analysisScope struct {
+	parent   *analysisScope
+	bindings map[string]analysisBinding
+}
+
+func newAnalysisScope(parent *analysisScope) *analysisScope {
+	return &analysisScope{parent: parent, bindings: make(map[string]analysisBinding)}
+}
+
+func (s *analysisScope) outermost() *analysisScope {
+	for s.parent != nil {
+		s = s.parent
+	}
+	return s
+}
+
+func (s *analysisScope) find(name string) (analysisBinding, bool) {
+	for ; s != nil; s = s.parent {
+		if binding, ok := s.bindings[name]; ok {
+			return binding, true
+		}
+	}
+	return analysisBinding{}, false
+}
+
+type variableAnalyzer struct {
+	analysis    *VariableAnalysis
+	environment *Environment
+	fileSystem  FileSystem
+	partials    map[string]*Template
+	visiting    map[string]bool
+	template    string
+}
+
+func (a *variableAnalyzer) walk(node interface{}, scope *analysisScope, line int) {
+	if withLine, ok := node.(interface{ LineNumber() *int }); ok {
+		if n := withLine.LineNumber(); n != nil {
+			line = *n
+		}
+	}
+
+	if lookup, ok := node.(*VariableLookup); ok {
+		a.record(lookup, scope, line)
+	}
+
+	children := ForParseTreeVisitor(node, nil).Children()
+	scoped, ok := node.(ScopedNode)
+	if !ok {
+		for _, child := range children {
+			a.walk(child, scope, line)
+		}
+		return
+	}
+
+	vs := scoped.VariableScope()
+	inner := newAnalysisScope(scope)
+	for _, local := range vs.Locals {
+		inner.bindings[local.Name] = a.bind(local, LoopVariable, scope)
+	}
+	for _, child := range children {
+		if isBodyChild(vs.Body, child) {
+			a.walk(child, inner, line)
+		} else {
+			a.walk(child, scope, line)
+		}
+	}
+
+	if vs.Partial != nil {
+		a.walkPartial(vs.Partial, scope)
+	}
+
+	outermost := scope.outermost()
+	for _, assign := range vs.Assigns {
+		outermost.bindings[assign.Name] = a.bind(assign, AssignedVariable, scope)
+	}
+}
+
+// isBodyChild reports whether child is one of the body nodes of a VariableScope.
+func isBodyChild(body []interface{}, child interface{}) bool {
+	if !reflect.TypeOf(child).Comparable() {
+		return false
+	}
+	for _, node := range body {
+		if node == child {
+			return true
+		}
+	}
+	return false
+}
+
+// walkPartial analyzes a partial with the scoping of include (shared scope)
+// or render (isolated scope).
+func (a *variableAnalyzer) walkPartial(partial *PartialReference, scope *analysisScope) {
+	name := ResolveParentTemplateName(partial.Name, a.template)
+	if name == "" || a.fileSystem == nil || a.visiting[name] {
+		return
+	}
+	tmpl := a.loadPartial(name)
+	if tmpl == nil {
+		return
+	}
+
+	var inner *analysisScope
+	if partial.Isolated {
+		inner = newAnalysisScope(nil)
+	} else {
+		inner = newAnalysisScope(scope)
+	}
+	for _, variable := range partial.Variables {
+		inner.bindings[variable.Name] = a.bind(variable, PartialVariable, scope)
+	}
+
+	caller := a.template
+	a.template = name
+	a.visiting[name] = true
+	a.walk(tmpl.root, inner, 0)
+	delete(a.visiting, name)
+	a.template = caller
+}
+
+func (a *variableAnalyzer) loadPartial(name string) *Template {
+	if tmpl, ok := a.partials[name]; ok {
+		return tmpl
+	}
+	a.partials[name] = nil
+
+	source, err := a.fileSystem.ReadTemplateFile(name)
+	if err != nil {
+		a.analysis.Errors = append(a.analysis.Errors, err)
+		return nil
+	}
+	tmpl, err := parsePartialSource(a.environment, name, source)
+	if err != nil {
+		a.analysis.Errors = append(a.analysis.Errors, err)
+		return nil
+	}
+	a.partials[name] = tmpl
+	return tmpl
+}
+
+// bind resolves the source of a scoped variable in scope.
+func (a *variableAnalyzer) bind(variable ScopedVariable, kind VariableKind, scope *analysisScope) analysisBinding {
+	binding := analysisBinding{kind: kind}
+	lookup, ok := variable.Source.(*VariableLookup)
+	if !ok {
+		return binding
+	}
+	binding.path, binding.global = a.resolve(lookup, scope)
+	if binding.path != "" && variable.Iterates {
+		binding.path += "[]"
+	}
+	return binding
+}
+
+// resolve returns the path of lookup and whether it is rooted in the render assigns.
+func (a *variableAnalyzer) resolve(lookup *VariableLookup, scope *analysisScope) (string, bool) {
+	name, ok := lookup.name.(string)
+	if !ok {
+		return "", false
+	}
+	suffix := lookupPathSuffix(lookup)
+	binding, found := scope.find(name)
+	if !found {
+		return name + suffix, true
+	}
+	if binding.path == "" {
+		return "", false
+	}
+	return binding.path + suffix, binding.global
+}
+
+func (a *variableAnalyzer) record(lookup *VariableLookup, scope *analysisScope, line int) {
+	name, ok := lookup.name.(string)
+	if !ok {
+		return
+	}
+
+	ref := VariableReference{
+		Name:       name + lookupPathSuffix(lookup),
+		LineNumber: line,
+		Template:   a.template,
+	}
+	if binding, found := scope.find(name); found {
+		ref.Kind = binding.kind
+	}
+	ref.Path, ref.Global = a.resolve(lookup, scope)
+	if ref.Path == "" {
+		ref.Path = ref.Name
+	}
+	a.analysis.References = append(a.analysis.References, ref)
+}
+
+// lookupPathSuffix renders the lookups of a VariableLookup as a path suffix.
+func lookupPathSuffix(lookup *VariableLookup) string {
+	var b strings.Builder
+	for _, key := range lookup.lookups {
+		switch k := key.(type) {
+		case string:
+			b.WriteString(".")
+			b.WriteString(k)
+		case int:
+			b.WriteString("[")
+			b.WriteString(strconv.Itoa(k))
+			b.WriteString("]")
+		default:
+			b.WriteString("[]")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,82 @@
+package liquid
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnalyzeVariablesOutputs(t *testing.T) {
+	tmpl, err := ParseTemplate("{{ contact.first_name }}\n{{ order.items[0].price | times: rate, precision: digits }}\n{{ order[key] }}", &TemplateOptions{LineNumbers: true})
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+
+	analysis := AnalyzeVariables(tmpl)
+	want := []VariableReference{
+		{Name: "contact.first_name", Path: "contact.first_name", Kind: GlobalVariable, Global: true, LineNumber: 1},
+		{Name: "order.items[0].price", Path: "order.items[0].price", Kind: GlobalVariable, Global: true, LineNumber: 2},
+		{Name: "rate", Path: "rate", Kind: GlobalVariable, Global: true, LineNumber: 2},
+		{Name: "digits", Path: "digits", Kind: GlobalVariable, Global: true, LineNumber: 2},
+		{Name: "order[]", Path: "order[]", Kind: GlobalVariable, Global: true, LineNumber: 3},
+		{Name: "key", Path: "key", Kind: GlobalVariable, Global: true, LineNumber: 3},
+	}
+	if !reflect.DeepEqual(analysis.References, want) {
+		t.Errorf("References = %+v, want %+v", analysis.References, want)
+	}
+
+	globals := analysis.Globals()
+	wantGlobals := []string{"contact.first_name", "digits", "key", "order.items[0].price", "order[]", "rate"}
+	if !reflect.DeepEqual(globals, wantGlobals) {
+		t.Errorf("Globals() = %v, want %v", globals, wantGlobals)
+	}
+}
+
+func TestAnalyzeVariablesWithoutLineNumbers(t *testing.T) {
+	tmpl, err := ParseTemplate("{{ a }}", nil)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	refs := AnalyzeVariables(tmpl).References
+	if len(refs) != 1 || refs[0].LineNumber != 0 {
+		t.Errorf("References = %+v, want one reference without line number", refs)
+	}
+}
+
+func TestAnalyzeVariablesUnparsedTemplate(t *testing.T) {
+	if refs := AnalyzeVariables(NewTemplate(nil)).References; len(refs) != 0 {
+		t.Errorf("References = %+v, want none", refs)
+	}
+}
+
+func TestParseTreeVisitorExpressionChildren(t *testing.T) {
+	tmpl, err := ParseTemplate("{{ a | plus: b }}", nil)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	variable := tmpl.Root().Nodelist()[0].(*Variable)
+
+	children := NewParseTreeVisitor(variable, nil).Children()
+	if len(children) != 2 {
+		t.Fatalf("Children() = %v, want 2 lookups", children)
+	}
+	for i, name := range []string{"a", "b"} {
+		lookup, ok := children[i].(*VariableLookup)
+		if !ok || lookup.Name() != name {
+			t.Errorf("Children()[%d] = %v, want lookup %q", i, children[i], name)
+		}
+	}
+}
+
+func TestVariableKindString(t *testing.T) {
+	kinds := map[VariableKind]string{
+		GlobalVariable:   "global",
+		LoopVariable:     "loop",
+		AssignedVariable: "assigned",
+		PartialVariable:  "partial",
+	}
+	for kind, want := range kinds {
+		if got := kind.String(); got != want {
+			t.Errorf("String() = %q, want %q", got, want)
+		}
+	}
+}
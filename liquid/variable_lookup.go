@@ -209,6 +209,23 @@ func (vl *VariableLookup) Lookups() []interface{} {
 	return vl.lookups
 }
 
+// ParseTreeChildren returns the expressions used as dynamic names or keys,
+// as in {{ [name] }} or {{ items[index] }}.
+func (vl *VariableLookup) ParseTreeChildren() []interface{} {
+	var children []interface{}
+	if _, ok := vl.name.(string); !ok {
+		children = append(children, vl.name)
+	}
+	for _, lookup := range vl.lookups {
+		switch lookup.(type) {
+		case string, int, nil:
+		default:
+			children = append(children, lookup)
+		}
+	}
+	return children
+}
+
 // Evaluate evaluates the variable lookup in the given context.
 func (vl *VariableLookup) Evaluate(context *Context) interface{} {
 	name := context.Evaluate(vl.name)
@@ -146,10 +146,10 @@ func TestVariableRender(t *testing.T) {
 	ctx := NewContext()
 	ctx.Set("name", "bob")
 
-	output := ""
-	v.RenderToOutputBuffer(ctx, &output)
-	if output != "bob" {
-		t.Errorf("Expected 'bob', got %q", output)
+	output := NewOutputBuffer()
+	v.RenderToOutputBuffer(ctx, output)
+	if output.String() != "bob" {
+		t.Errorf("Expected 'bob', got %q", output.String())
 	}
 }
 
@@ -207,8 +207,8 @@ func TestRenderToOutputBufferPreservesLiquidErrorTypes(t *testing.T) {
 			pc := &mockParseContext{lineNum: &lineNum}
 			v := NewVariable("panicker.trigger", pc)
 
-			output := ""
-			v.RenderToOutputBuffer(ctx, &output)
+			output := NewOutputBuffer()
+			v.RenderToOutputBuffer(ctx, output)
 
 			// Error should be in context
 			if len(ctx.Errors()) == 0 {
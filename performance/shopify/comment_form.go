@@ -33,7 +33,7 @@ func NewCommentForm(tagName, markup string, parseContext liquid.ParseContextInte
 }
 
 // RenderToOutputBuffer renders the comment_form tag
-func (c *CommentForm) RenderToOutputBuffer(context liquid.TagContext, output *string) {
+func (c *CommentForm) RenderToOutputBuffer(context liquid.TagContext, output *liquid.OutputBuffer) {
 	ctx := context.Context().(*liquid.Context)
 	article := ctx.FindVariable(c.variableName, false)
 
@@ -69,5 +69,5 @@ func (c *CommentForm) RenderToOutputBuffer(context liquid.TagContext, output *st
 %s
 </form>`, articleID, bodyOutput)
 
-	*output += formHTML
+	output.WriteString(formHTML)
 }